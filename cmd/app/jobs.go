@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/budgets"
+	"subscription-service/internal/config"
+	"subscription-service/internal/jobs"
+	"subscription-service/internal/log"
+	"subscription-service/internal/reminders"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/service"
+)
+
+// Встроенные фоновые задачи
+const (
+	jobPurge               = "jobs.purge"
+	jobRecalculateStatuses = "subscriptions.recalculate_statuses"
+)
+
+// registerJobs регистрирует обработчики встроенных задач и их расписания
+func registerJobs(cfg *config.Config, w *jobs.Worker, cron *jobs.Cron, jr *repo.JobsRepo,
+	svc *service.Service, rem *reminders.Scheduler, bud *budgets.Checker, logger *log.Logger) error {
+	jobs.Handle(w, jobPurge, func(ctx context.Context, _ struct{}) error {
+		n, err := jr.Purge(ctx, cfg.Jobs.Retention)
+		if err == nil && n > 0 {
+			logger.Info("finished jobs purged", "count", n)
+		}
+		return err
+	})
+	jobs.Handle(w, jobRecalculateStatuses, func(ctx context.Context, _ struct{}) error {
+		// системная задача: действует от имени admin
+		_, err := svc.RecalculateStatuses(auth.WithPrincipal(ctx, auth.Principal{Role: auth.RoleAdmin}))
+		return err
+	})
+	jobs.Handle(w, budgets.CheckJob, func(ctx context.Context, _ struct{}) error {
+		return bud.Check(ctx)
+	})
+	jobs.Handle(w, budgets.NotifyJob, bud.Deliver)
+	if rem != nil {
+		jobs.Handle(w, reminders.TickJob, func(ctx context.Context, _ struct{}) error {
+			return rem.Tick(ctx)
+		})
+	}
+
+	type schedule struct {
+		spec, kind string
+		opts       []jobs.Option
+	}
+	schedules := []schedule{
+		// следующий запуск всё равно повторит работу, повторы не нужны
+		{cfg.Jobs.PurgeSchedule, jobPurge, []jobs.Option{jobs.MaxAttempts(1)}},
+		{cfg.Jobs.RecalculateStatusesSchedule, jobRecalculateStatuses, nil},
+		{cfg.Jobs.BudgetsCheckSchedule, budgets.CheckJob, []jobs.Option{jobs.MaxAttempts(1)}},
+	}
+	if rem != nil {
+		schedules = append(schedules,
+			schedule{"@every " + cfg.Reminders.Interval.String(), reminders.TickJob, []jobs.Option{jobs.MaxAttempts(1)}})
+	}
+	for _, s := range schedules {
+		if s.spec == "" {
+			continue
+		}
+		if err := cron.Add(s.spec, s.kind, nil, s.opts...); err != nil {
+			return fmt.Errorf("%s: %w", s.kind, err)
+		}
+	}
+	return nil
+}
@@ -2,69 +2,399 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
 	"subscription-service/internal/api"
+	"subscription-service/internal/budgets"
 	"subscription-service/internal/config"
+	"subscription-service/internal/events"
+	"subscription-service/internal/graphqlapi"
+	"subscription-service/internal/grpcapi"
+	"subscription-service/internal/jobs"
 	"subscription-service/internal/log"
+	"subscription-service/internal/metrics"
+	"subscription-service/internal/model"
+	"subscription-service/internal/notify"
+	"subscription-service/internal/reminders"
 	"subscription-service/internal/repo"
 	"subscription-service/internal/service"
+	"subscription-service/internal/tracing"
+	"subscription-service/migrations"
 	"syscall"
 	"time"
+	_ "time/tzdata" // часовые пояса пользователей: в образе alpine нет zoneinfo
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
 
 	_ "subscription-service/docs" // Swagger docs
 )
 
 func main() {
+	configPath := flag.String("config", "", "path to YAML config (default "+config.DefaultPath+" if present)")
+	printConfig := flag.Bool("print-config", false, "print effective config with secrets masked and exit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [migrate <command>]\n\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), "\n"+migrateUsage)
+	}
+	flag.Parse()
+
 	// 1) Конфиг + логгер
-	cfg := config.MustLoad()
-	logger := log.New(cfg.AppEnv)
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+		os.Exit(2)
+	}
+	if *printConfig {
+		fmt.Print(cfg.Redacted())
+		return
+	}
+	logger, err := log.New(log.Options{
+		Level:            cfg.Log.Level,
+		Format:           cfg.Log.Format,
+		Output:           cfg.Log.Output,
+		MaxSizeMB:        cfg.Log.MaxSizeMB,
+		MaxBackups:       cfg.Log.MaxBackups,
+		MaxAgeDays:       cfg.Log.MaxAgeDays,
+		DebugSampleEvery: cfg.Log.DebugSampleEvery,
+		RedactKeys:       cfg.Log.RedactKeys,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logger: %v\n", err)
+		os.Exit(2)
+	}
+	defer logger.Close()
+	slog.SetDefault(logger.Logger) // для логов вне запроса (log.FromContext)
+
+	if flag.Arg(0) == "migrate" {
+		code := runMigrate(cfg, logger, flag.Args()[1:])
+		logger.Close()
+		os.Exit(code)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Error("tracing setup failed", "err", err)
+		os.Exit(1)
+	}
 
 	// 2) Подключение к БД
-	db, err := repo.NewPostgres(cfg.DatabaseURL, logger)
+	db, err := repo.NewPostgres(cfg.Database.URL, repo.PoolOptions{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnectTimeout:  cfg.Database.ConnectTimeout,
+	}, logger)
 	if err != nil {
 		logger.Error("db connect failed", "err", err)
 		os.Exit(1)
 	}
 	defer db.Close()
 
-	// 3) Миграции
-	if err := repo.ApplyMigrations(db, "migrations", logger); err != nil {
-		logger.Error("migrations failed", "err", err)
-		os.Exit(1)
+	// 3) Миграции (если их не применяет отдельный job)
+	migrator := repo.NewMigrator(db, migrationsFS(cfg), logger)
+	if cfg.Database.AutoMigrate {
+		if _, err := migrator.Up(context.Background(), 0); err != nil {
+			logger.Error("migrations failed", "err", err)
+			os.Exit(1)
+		}
 	}
 
 	// 4) Слои
-	rp := repo.NewSubscriptionsRepo(db)
+	rp := repo.NewSubscriptionsRepo(db, cfg.Database.StatementTimeout)
+	metrics.RegisterDB(db)
+	metrics.RegisterBusiness(rp.Stats)
 	svc := service.New(rp, logger)
-	h := api.NewHandlers(svc, logger)
-	router := api.NewRouter(h, logger)
+	hub := events.NewHub(cfg.Events.BufferSize)
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	defer stopEvents()
+	if cfg.Events.Backend == "postgres" {
+		er := repo.NewEventsRepo(db, cfg.Database.StatementTimeout)
+		svc.SetEvents(events.NewPGPublisher(er))
+		go events.NewListener(er, hub, logger).Run(eventsCtx)
+	} else {
+		svc.SetEvents(hub)
+	}
+	catalog := service.NewCatalog(repo.NewServicesRepo(db, cfg.Database.StatementTimeout), svc)
+	svc.SetCatalog(catalog, cfg.Catalog.RejectUnknown)
+	usersRepo := repo.NewUsersRepo(db, cfg.Database.StatementTimeout)
+	users := service.NewUsers(usersRepo, svc)
+	remRepo := repo.NewRemindersRepo(db, cfg.Database.StatementTimeout)
+	notifications := service.NewNotifications(remRepo, cfg.Reminders.LeadDays, cfg.Reminders.Channels)
+	budgetsRepo := repo.NewBudgetsRepo(db, cfg.Database.StatementTimeout)
+	jobsRepo := repo.NewJobsRepo(db, cfg.Database.StatementTimeout)
+	queue := jobs.NewQueue(jobsRepo)
+	keys := service.NewAPIKeys(repo.NewAPIKeysRepo(db, cfg.Database.StatementTimeout), logger)
+	h := api.NewHandlers(svc)
+	kh := api.NewAPIKeyHandlers(keys)
+	defLimit, routeLimits := rateLimits(cfg)
+	rl := api.NewRateLimiter(api.NewMemoryRateLimitStore(), defLimit, routeLimits)
+	rl.SetIPLimit(ipLimit(cfg))
+	cors := api.NewCORS(corsOptions(cfg))
+	health := api.NewHealth(2*time.Second,
+		api.Check{Name: "db", Fn: db.PingContext},
+		api.MigrationsCheck(migrator.Pending),
+	)
+	var gql http.Handler
+	if cfg.GraphQL.Enabled {
+		gh, err := graphqlapi.NewHandler(svc, graphqlapi.Limits{
+			MaxDepth:      cfg.GraphQL.MaxDepth,
+			MaxComplexity: cfg.GraphQL.MaxComplexity,
+		})
+		if err != nil {
+			logger.Error("graphql schema failed", "err", err)
+			os.Exit(1)
+		}
+		gql = gh
+	}
+	router := api.NewRouter(api.RouterConfig{
+		Subscriptions: h,
+		Users:         api.NewUserHandlers(users, svc),
+		Catalog:       api.NewCatalogHandlers(catalog),
+		APIKeys:       kh,
+		Logging:       api.NewLoggingHandlers(logger.Controls()),
+		Health:        health,
+		Events:        api.NewEventStream(hub, svc, cfg.Events.Heartbeat),
+		Notifications: api.NewNotificationHandlers(notifications),
+		Budgets:       api.NewBudgetHandlers(service.NewBudgets(budgetsRepo, usersRepo, svc)),
+		Jobs:          api.NewJobHandlers(queue),
+		GraphQL:       gql,
+		RateLimiter:   rl,
+		CORS:          cors,
+		Logger:        logger,
+		JWTSecret:     []byte(cfg.Auth.JWTSecret),
+		ServeMetrics:  cfg.Metrics.Port == "",
+	})
+
+	// Горячая перезагрузка: SIGHUP или изменение файлов конфига
+	reloader := config.NewReloader(*configPath, cfg, logger)
+	reloader.Subscribe(func(c *config.Config) {
+		_ = logger.Controls().SetLevel(c.Log.Level) // уровень уже проверен Validate
+		logger.Controls().SetDebugSampleEvery(c.Log.DebugSampleEvery)
+		rl.SetLimits(rateLimits(c))
+		rl.SetIPLimit(ipLimit(c))
+		cors.Update(corsOptions(c))
+	})
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	go reloader.Run(reloadCtx)
+
+	// Фоновые задачи: воркер и расписание (JOBS_ENABLED=false — только постановка)
+	var worker *jobs.Worker
+	cronCtx, stopCron := context.WithCancel(context.Background())
+	defer stopCron()
+	if cfg.Jobs.Enabled {
+		worker = jobs.NewWorker(jobsRepo, jobs.WorkerConfig{
+			Concurrency:  cfg.Jobs.Concurrency,
+			PollInterval: cfg.Jobs.PollInterval,
+			Timeout:      cfg.Jobs.Timeout,
+			Logger:       logger,
+		})
+		ns := notifiers(cfg, logger)
+		var rem *reminders.Scheduler
+		if cfg.Reminders.Enabled {
+			rem = reminders.New(remRepo, reminders.Config{
+				LeadDays:    cfg.Reminders.LeadDays,
+				Channels:    cfg.Reminders.Channels,
+				BatchSize:   cfg.Reminders.BatchSize,
+				MaxAttempts: cfg.Reminders.MaxAttempts,
+				Notifiers:   ns,
+				Logger:      logger,
+			})
+		}
+		// предупреждения о бюджетах идут по тем же каналам, что и напоминания
+		bud := budgets.New(budgetsRepo, remRepo, queue, budgets.Config{
+			Channels:  cfg.Reminders.Channels,
+			Notifiers: ns,
+			Logger:    logger,
+		})
+		cron := jobs.NewCron(queue, logger)
+		if err := registerJobs(cfg, worker, cron, jobsRepo, svc, rem, bud, logger); err != nil {
+			logger.Error("jobs schedule invalid", "err", err)
+			os.Exit(2)
+		}
+		worker.Start()
+		go cron.Run(cronCtx)
+	}
 
 	// 5) HTTP-сервер
 	srv := &http.Server{
-		Addr:         ":" + cfg.HTTPPort,
+		Addr:         ":" + cfg.HTTP.Port,
 		Handler:      router,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 20 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+	}
+	// SSE-потоки не завершаются сами: закрываем их в начале Shutdown, иначе он ждал бы до таймаута
+	srv.RegisterOnShutdown(hub.Close)
+	tlsOn := cfg.HTTP.TLSCertFile != ""
+	if tlsOn {
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
 	}
 
 	// 6) Запуск + Graceful shutdown
 	go func() {
-		logger.Info("http starting", "addr", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Info("http starting", "addr", srv.Addr, "tls", tlsOn)
+		var err error
+		if tlsOn {
+			err = srv.ListenAndServeTLS(cfg.HTTP.TLSCertFile, cfg.HTTP.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Error("http run failed", "err", err)
 			os.Exit(1)
 		}
 	}()
 
+	// Метрики на отдельном порту, чтобы не светить их на публичном
+	var metricsSrv *http.Server
+	if cfg.Metrics.Port != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		metricsSrv = &http.Server{Addr: ":" + cfg.Metrics.Port, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			logger.Info("metrics starting", "addr", metricsSrv.Addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("metrics run failed", "err", err)
+			}
+		}()
+	}
+
+	// gRPC API на отдельном порту
+	var (
+		grpcSrv    *grpc.Server
+		grpcHealth *grpchealth.Server
+	)
+	if cfg.GRPC.Port != "" {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+		if err != nil {
+			logger.Error("grpc listen failed", "err", err)
+			os.Exit(1)
+		}
+		grpcSrv, grpcHealth = grpcapi.NewServer(grpcapi.Config{
+			Service:   svc,
+			Keys:      keys,
+			JWTSecret: []byte(cfg.Auth.JWTSecret),
+			Logger:    logger,
+		})
+		go func() {
+			logger.Info("grpc starting", "addr", lis.Addr().String())
+			if err := grpcSrv.Serve(lis); err != nil {
+				logger.Error("grpc run failed", "err", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Сначала снимаем готовность, затем ждём, пока балансировщик перестанет слать трафик
+	health.Drain()
+	if grpcHealth != nil {
+		grpcHealth.Shutdown() // все сервисы → NOT_SERVING
+	}
+	logger.Info("draining", "delay", cfg.HTTP.ShutdownDelay.String())
+	time.Sleep(cfg.HTTP.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
 	defer cancel()
 	logger.Info("shutting down...")
+	stopReload()
+	stopEvents()
+	stopCron()
 	_ = srv.Shutdown(ctx)
+	if metricsSrv != nil {
+		_ = metricsSrv.Shutdown(ctx)
+	}
+	if grpcSrv != nil {
+		stopGRPC(ctx, grpcSrv)
+	}
+	// выполняемые задачи доделываются в пределах того же таймаута, остальные вернутся в очередь
+	if worker != nil {
+		if err := worker.Shutdown(ctx); err != nil {
+			logger.Warn("jobs drain incomplete", "err", err)
+		}
+	}
+	_ = shutdownTracing(ctx)
+}
+
+// stopGRPC дожидается завершения активных вызовов, по истечении ctx обрывает их
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+// notifiers — каналы доставки напоминаний; email — только при заданном SMTP-сервере
+func notifiers(cfg *config.Config, logger *log.Logger) map[string]notify.Notifier {
+	res := map[string]notify.Notifier{
+		model.ChannelLog:     notify.NewLog(logger),
+		model.ChannelWebhook: notify.NewWebhook(cfg.Reminders.Webhook.Secret, cfg.Reminders.Webhook.Timeout),
+	}
+	if s := cfg.Reminders.SMTP; s.Host != "" {
+		res[model.ChannelEmail] = notify.NewSMTP(notify.SMTPConfig{
+			Addr:     net.JoinHostPort(s.Host, s.Port),
+			Username: s.Username,
+			Password: s.Password,
+			From:     s.From,
+			Timeout:  30 * time.Second,
+		})
+	}
+	return res
+}
+
+// migrationsFS — встроенные миграции или каталог из database.migrations_dir
+func migrationsFS(cfg *config.Config) fs.FS {
+	if cfg.Database.MigrationsDir != "" {
+		return os.DirFS(cfg.Database.MigrationsDir)
+	}
+	return migrations.FS
+}
+
+func rateLimits(cfg *config.Config) (api.Limit, map[string]api.Limit) {
+	routes := map[string]api.Limit{}
+	for route, l := range cfg.RateLimit.Routes {
+		routes[route] = api.Limit{RPS: l.RPS, Burst: l.Burst}
+	}
+	return api.Limit{RPS: cfg.RateLimit.RPS, Burst: cfg.RateLimit.Burst}, routes
+}
+
+// ipLimit: trusted_proxies уже проверены Validate
+func ipLimit(cfg *config.Config) (api.Limit, []netip.Prefix) {
+	proxies, _ := cfg.RateLimit.Proxies()
+	return api.Limit{RPS: cfg.RateLimit.IPRPS, Burst: cfg.RateLimit.IPBurst}, proxies
+}
+
+func corsOptions(cfg *config.Config) api.CORSOptions {
+	return api.CORSOptions{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	}
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/log"
+	"subscription-service/internal/repo"
+)
+
+const migrateUsage = `migrate commands:
+  migrate up [N]         apply N pending migrations (all by default)
+  migrate down [N]       revert N last applied migrations (1 by default)
+  migrate status         show applied and pending versions
+  migrate create NAME    create empty NNN_name.up.sql/.down.sql in database.migrations_dir (or ./migrations)
+  migrate force VERSION  mark versions <= VERSION applied and newer ones pending, without running SQL
+  migrate validate       check migration files and applied checksums
+`
+
+// runMigrate выполняет подкоманду migrate и возвращает код выхода
+func runMigrate(cfg *config.Config, logger *log.Logger, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+	cmd, rest := args[0], args[1:]
+	num := func(def int64) (int64, error) {
+		if len(rest) == 0 {
+			return def, nil
+		}
+		n, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%s: invalid number %q", cmd, rest[0])
+		}
+		return n, nil
+	}
+
+	// create работает только с файлами, БД не нужна
+	if cmd == "create" {
+		if len(rest) != 1 {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return 2
+		}
+		dir := cfg.Database.MigrationsDir
+		if dir == "" {
+			dir = "migrations"
+		}
+		up, down, err := repo.CreateMigration(dir, rest[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate create: %v\n", err)
+			return 1
+		}
+		fmt.Println(up)
+		fmt.Println(down)
+		return 0
+	}
+
+	db, err := repo.NewPostgres(cfg.Database.URL, repo.PoolOptions{
+		MaxOpenConns:   2,
+		MaxIdleConns:   1,
+		ConnectTimeout: cfg.Database.ConnectTimeout,
+	}, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "db connect failed: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	m := repo.NewMigrator(db, migrationsFS(cfg), logger)
+	ctx := context.Background()
+
+	switch cmd {
+	case "up":
+		var n int64
+		if n, err = num(0); err == nil {
+			var cnt int
+			cnt, err = m.Up(ctx, int(n))
+			fmt.Printf("applied %d migration(s)\n", cnt)
+		}
+	case "down":
+		var n int64
+		if n, err = num(1); err == nil {
+			var cnt int
+			cnt, err = m.Down(ctx, int(n))
+			fmt.Printf("reverted %d migration(s)\n", cnt)
+		}
+	case "force":
+		if len(rest) != 1 {
+			err = errors.New("force: VERSION required")
+			break
+		}
+		var v int64
+		if v, err = num(0); err == nil {
+			err = m.Force(ctx, v)
+		}
+	case "validate":
+		if err = m.Validate(ctx); err == nil {
+			fmt.Println("ok")
+		}
+	case "status":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n\n%s", cmd, migrateUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate %s: %v\n", cmd, err)
+		return 1
+	}
+	if err := printMigrationStatus(ctx, m); err != nil {
+		fmt.Fprintf(os.Stderr, "migrate status: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(ctx context.Context, m *repo.Migrator) error {
+	st, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range st {
+		status, at := "pending", "-"
+		if s.Applied {
+			status, at = "applied", s.AppliedAt.Format(time.DateTime)
+		}
+		switch {
+		case s.Missing:
+			status = "applied, file missing"
+		case s.Modified:
+			status = "applied, file modified"
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", s.Version, s.Name, status, at)
+	}
+	return w.Flush()
+}
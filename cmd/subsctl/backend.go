@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/config"
+	"subscription-service/internal/events"
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/service"
+	"subscription-service/pkg/client"
+)
+
+// backend — источник данных subsctl: напрямую БД (через internal/service) или HTTP API
+type backend interface {
+	List(ctx context.Context, q model.ListQuery) ([]model.Subscription, error)
+	Get(ctx context.Context, id uuid.UUID) (model.Subscription, error)
+	Create(ctx context.Context, in model.SubscriptionCreate) (model.Subscription, error)
+	Update(ctx context.Context, id uuid.UUID, in model.SubscriptionUpdate) (model.Subscription, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Total(ctx context.Context, q totalQuery) (int64, error)
+	RecalculateStatuses(ctx context.Context) (int64, error)
+	Close() error
+}
+
+// totalQuery — параметры total в виде строк YYYY-MM, как их передают флаги
+type totalQuery struct {
+	UserID, ServiceName, Category, Tag, From, To string
+}
+
+// --- БД ---
+
+// dbBackend работает от имени admin: оператору доступны все пользователи
+type dbBackend struct {
+	db  *sql.DB
+	svc *service.Service
+}
+
+func newDBBackend(cfg *config.Config, logger *log.Logger) (*dbBackend, error) {
+	db, err := repo.NewPostgres(cfg.Database.URL, repo.PoolOptions{
+		MaxOpenConns:   2,
+		MaxIdleConns:   1,
+		ConnectTimeout: cfg.Database.ConnectTimeout,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+	rp := repo.NewSubscriptionsRepo(db, cfg.Database.StatementTimeout)
+	svc := service.New(rp, logger)
+	svc.SetCatalog(service.NewCatalog(repo.NewServicesRepo(db, cfg.Database.StatementTimeout), svc), cfg.Catalog.RejectUnknown)
+	// изменения из CLI видны в SSE-потоке сервиса, только если события идут через Postgres
+	if cfg.Events.Backend == "postgres" {
+		svc.SetEvents(events.NewPGPublisher(repo.NewEventsRepo(db, cfg.Database.StatementTimeout)))
+	}
+	return &dbBackend{db: db, svc: svc}, nil
+}
+
+func (b *dbBackend) admin(ctx context.Context) context.Context {
+	return auth.WithPrincipal(ctx, auth.Principal{Role: auth.RoleAdmin})
+}
+
+func (b *dbBackend) List(ctx context.Context, q model.ListQuery) ([]model.Subscription, error) {
+	return b.svc.List(b.admin(ctx), q)
+}
+
+func (b *dbBackend) Get(ctx context.Context, id uuid.UUID) (model.Subscription, error) {
+	return b.svc.GetByID(b.admin(ctx), id)
+}
+
+func (b *dbBackend) Create(ctx context.Context, in model.SubscriptionCreate) (model.Subscription, error) {
+	return b.svc.Create(b.admin(ctx), in)
+}
+
+func (b *dbBackend) Update(ctx context.Context, id uuid.UUID, in model.SubscriptionUpdate) (model.Subscription, error) {
+	return b.svc.Update(b.admin(ctx), id, in)
+}
+
+func (b *dbBackend) Delete(ctx context.Context, id uuid.UUID) error {
+	return b.svc.Delete(b.admin(ctx), id)
+}
+
+func (b *dbBackend) Total(ctx context.Context, q totalQuery) (int64, error) {
+	from, err := model.ParseYearMonth(q.From)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --from (YYYY-MM)")
+	}
+	to, err := model.ParseYearMonth(q.To)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --to (YYYY-MM)")
+	}
+	return b.svc.Total(b.admin(ctx), model.TotalQuery{
+		UserID: q.UserID, ServiceName: q.ServiceName, Category: q.Category, Tag: q.Tag, From: from, To: to,
+	})
+}
+
+func (b *dbBackend) RecalculateStatuses(ctx context.Context) (int64, error) {
+	return b.svc.RecalculateStatuses(b.admin(ctx))
+}
+
+func (b *dbBackend) Close() error { return b.db.Close() }
+
+// --- HTTP API (pkg/client) ---
+
+type httpBackend struct{ c *client.Client }
+
+func newHTTPBackend(baseURL, token, apiKey string) (*httpBackend, error) {
+	c, err := client.New(baseURL, client.WithToken(token), client.WithAPIKey(apiKey), client.WithUserAgent("subsctl"))
+	if err != nil {
+		return nil, err
+	}
+	return &httpBackend{c: c}, nil
+}
+
+func (b *httpBackend) List(ctx context.Context, q model.ListQuery) ([]model.Subscription, error) {
+	return b.c.List(ctx, client.ListParams{
+		UserID: q.UserID, ServiceName: q.ServiceName, Status: q.Status, Category: q.Category, Tag: q.Tag,
+		Limit: q.Limit, Offset: q.Offset,
+	})
+}
+
+func (b *httpBackend) Get(ctx context.Context, id uuid.UUID) (model.Subscription, error) {
+	return b.c.Get(ctx, id)
+}
+
+func (b *httpBackend) Create(ctx context.Context, in model.SubscriptionCreate) (model.Subscription, error) {
+	return b.c.Create(ctx, in)
+}
+
+func (b *httpBackend) Update(ctx context.Context, id uuid.UUID, in model.SubscriptionUpdate) (model.Subscription, error) {
+	return b.c.Update(ctx, id, in)
+}
+
+func (b *httpBackend) Delete(ctx context.Context, id uuid.UUID) error { return b.c.Delete(ctx, id) }
+
+func (b *httpBackend) Total(ctx context.Context, q totalQuery) (int64, error) {
+	from, err := model.ParseYearMonth(q.From)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --from (YYYY-MM)")
+	}
+	to, err := model.ParseYearMonth(q.To)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --to (YYYY-MM)")
+	}
+	return b.c.Total(ctx, client.TotalParams{
+		UserID: q.UserID, ServiceName: q.ServiceName, Category: q.Category, Tag: q.Tag, From: from, To: to,
+	})
+}
+
+func (b *httpBackend) RecalculateStatuses(ctx context.Context) (int64, error) {
+	return b.c.RecalculateStatuses(ctx)
+}
+
+func (b *httpBackend) Close() error { return nil }
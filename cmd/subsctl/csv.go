@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"subscription-service/internal/model"
+)
+
+const exportPageSize = 500
+
+// exportCSV выгружает все подписки по фильтру постранично; формат совпадает с import
+func exportCSV(ctx context.Context, b backend, q model.ListQuery, w io.Writer) (int, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(subscriptionColumns); err != nil {
+		return 0, err
+	}
+	n := 0
+	q.Limit = exportPageSize
+	for q.Offset = 0; ; q.Offset += exportPageSize {
+		page, err := b.List(ctx, q)
+		if err != nil {
+			return n, err
+		}
+		for _, s := range page {
+			if err := cw.Write(subscriptionRow(s)); err != nil {
+				return n, err
+			}
+		}
+		n += len(page)
+		if len(page) < exportPageSize {
+			break
+		}
+	}
+	cw.Flush()
+	return n, cw.Error()
+}
+
+// importCSV создаёт подписки из CSV с заголовком: service_name, price, user_id, start_date[, end_date,
+// category, custom_category, tags, billing_period]; остальные колонки (id, status из export) игнорируются.
+// category из export переносится, только если custom_category=true: иначе это категория сервиса из каталога.
+// Ошибочные строки пропускаются и перечисляются в errw.
+func importCSV(ctx context.Context, b backend, r io.Reader, errw io.Writer) (imported, failed int, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return 0, 0, fmt.Errorf("read header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[h] = i
+	}
+	for _, req := range []string{"service_name", "price", "user_id", "start_date"} {
+		if _, ok := col[req]; !ok {
+			return 0, 0, fmt.Errorf("missing column %q", req)
+		}
+	}
+	get := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil {
+			var in model.SubscriptionCreate
+			if in, err = importRow(get, rec); err == nil {
+				_, err = b.Create(ctx, in)
+			}
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(errw, "line %d: %v\n", line, err)
+			continue
+		}
+		imported++
+	}
+	return imported, failed, nil
+}
+
+func importRow(get func([]string, string) string, rec []string) (model.SubscriptionCreate, error) {
+	price, err := strconv.ParseInt(get(rec, "price"), 10, 64)
+	if err != nil {
+		return model.SubscriptionCreate{}, fmt.Errorf("invalid price %q", get(rec, "price"))
+	}
+	in := model.SubscriptionCreate{
+		ServiceName: get(rec, "service_name"),
+		Price:       price,
+		UserID:      get(rec, "user_id"),
+		StartYM:     get(rec, "start_date"),
+		EndYM:       get(rec, "end_date"),
+		Category:    get(rec, "category"),
+	}
+	if c := get(rec, "custom_category"); c != "" {
+		custom, err := strconv.ParseBool(c)
+		if err != nil {
+			return model.SubscriptionCreate{}, fmt.Errorf("invalid custom_category %q", c)
+		}
+		if !custom {
+			in.Category = ""
+		}
+	}
+	if p := get(rec, "billing_period"); p != "" {
+		if in.BillingPeriod, err = strconv.Atoi(p); err != nil {
+			return model.SubscriptionCreate{}, fmt.Errorf("invalid billing_period %q", p)
+		}
+	}
+	for _, t := range strings.Split(get(rec, "tags"), tagSeparator) {
+		if t = strings.TrimSpace(t); t != "" {
+			in.Tags = append(in.Tags, t)
+		}
+	}
+	return in, nil
+}
+
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "" || path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
@@ -0,0 +1,272 @@
+// subsctl — CLI оператора: просмотр и правка подписок напрямую в БД или через HTTP API (--url)
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+)
+
+const usage = `usage: subsctl [flags] <command> [command flags]
+
+commands:
+  list     [--user ID] [--service NAME] [--status S] [--category C] [--tag T] [--limit N] [--offset N]
+  get      ID
+  create   --service NAME --price N --user ID --start YYYY-MM [--end YYYY-MM] [--period MONTHS]
+  update   ID [--service NAME] [--price N] [--period MONTHS] [--start YYYY-MM] [--end YYYY-MM|""]
+  delete   ID
+  total    --from YYYY-MM --to YYYY-MM [--user ID] [--service NAME] [--category C] [--tag T]
+  export   [--user ID] [--service NAME] [--status S] [--category C] [--tag T]    CSV to stdout
+  import   [FILE|-]                                     CSV from export or service_name,price,user_id,start_date[,end_date,category,custom_category,tags,billing_period]
+  recalc-statuses                                       recalculate upcoming/active/expired
+  config                                                print effective config with secrets masked (DB mode)
+
+Without --url subsctl connects to the database from the app config (--config, configs/.env, env)
+and acts as admin. With --url it calls the HTTP API using --token (JWT) or --api-key.
+
+flags:
+`
+
+type globals struct {
+	configPath string
+	baseURL    string
+	token      string
+	apiKey     string
+	output     string
+	timeout    time.Duration
+}
+
+func main() {
+	var g globals
+	fs := flag.NewFlagSet("subsctl", flag.ExitOnError)
+	fs.StringVar(&g.configPath, "config", "", "path to YAML config (default "+config.DefaultPath+" if present)")
+	fs.StringVar(&g.baseURL, "url", os.Getenv("SUBSCTL_URL"), "HTTP API base URL, e.g. http://localhost:8080 (env SUBSCTL_URL); empty — direct DB access")
+	fs.StringVar(&g.token, "token", os.Getenv("SUBSCTL_TOKEN"), "JWT for --url (env SUBSCTL_TOKEN)")
+	fs.StringVar(&g.apiKey, "api-key", os.Getenv("SUBSCTL_API_KEY"), "API key for --url (env SUBSCTL_API_KEY)")
+	fs.StringVar(&g.output, "o", "table", "output format: table | json | csv")
+	fs.DurationVar(&g.timeout, "timeout", 5*time.Minute, "overall command timeout")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[1:])
+	if fs.NArg() == 0 || !validFormat(g.output) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	err := run(ctx, g, fs.Arg(0), fs.Args()[1:])
+	cancel()
+	if err != nil {
+		var ue usageError
+		if errors.As(err, &ue) {
+			fmt.Fprintf(os.Stderr, "subsctl: %v\n\n", err)
+			fs.Usage()
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "subsctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+func run(ctx context.Context, g globals, cmd string, args []string) error {
+	out := printer{w: os.Stdout, format: g.output}
+
+	var (
+		cfg *config.Config
+		b   backend
+	)
+	if g.baseURL == "" || cmd == "config" {
+		var err error
+		if cfg, err = config.Load(g.configPath); err != nil {
+			return fmt.Errorf("invalid config:\n%w", err)
+		}
+	}
+	if cmd == "config" {
+		fmt.Print(cfg.Redacted())
+		return nil
+	}
+	if g.baseURL != "" {
+		var err error
+		if b, err = newHTTPBackend(g.baseURL, g.token, g.apiKey); err != nil {
+			return err
+		}
+	} else {
+		// логи сервиса — в stderr, чтобы не смешивать с выводом команды
+		logger, err := log.New(log.Options{Level: "warn", Format: "text", Output: "stderr"})
+		if err != nil {
+			return err
+		}
+		if b, err = newDBBackend(cfg, logger); err != nil {
+			return err
+		}
+	}
+	defer b.Close()
+
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	str := func(name, help string) *string { return fs.String(name, "", help) }
+	// parseID разбирает флаги команды с ID: flag останавливается на первом позиционном
+	// аргументе, поэтому ID перед флагами ("update ID --price N") снимается заранее
+	parseID := func() (uuid.UUID, error) {
+		var pos []string
+		rest := args
+		if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+			pos, rest = rest[:1], rest[1:]
+		}
+		if err := fs.Parse(rest); err != nil {
+			return uuid.Nil, usageError(err.Error())
+		}
+		pos = append(pos, fs.Args()...)
+		if len(pos) != 1 {
+			return uuid.Nil, usageError(cmd + ": ID required")
+		}
+		id, err := uuid.Parse(pos[0])
+		if err != nil {
+			return uuid.Nil, usageError(cmd + ": invalid ID")
+		}
+		return id, nil
+	}
+
+	switch cmd {
+	case "list", "export":
+		user, svc, status := str("user", "user UUID"), str("service", "service name"), str("status", "upcoming | active | expired | archived")
+		category, tag := str("category", "category (own or from the service catalog)"), str("tag", "tag")
+		limit, offset := fs.Int("limit", 50, "page size (list)"), fs.Int("offset", 0, "offset (list)")
+		if err := fs.Parse(args); err != nil {
+			return usageError(err.Error())
+		}
+		q := model.ListQuery{
+			UserID: *user, ServiceName: *svc, Status: *status, Category: *category, Tag: *tag, Limit: *limit, Offset: *offset,
+		}
+		if cmd == "export" {
+			n, err := exportCSV(ctx, b, q, os.Stdout)
+			fmt.Fprintf(os.Stderr, "exported %d subscription(s)\n", n)
+			return err
+		}
+		items, err := b.List(ctx, q)
+		if err != nil {
+			return err
+		}
+		return out.subscriptions(items)
+
+	case "get", "delete":
+		id, err := parseID()
+		if err != nil {
+			return err
+		}
+		if cmd == "delete" {
+			if err := b.Delete(ctx, id); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "deleted %s\n", id)
+			return nil
+		}
+		s, err := b.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		return out.subscriptions([]model.Subscription{s})
+
+	case "create":
+		svc, user, start, end := str("service", "service name"), str("user", "user UUID"), str("start", "YYYY-MM"), str("end", "YYYY-MM")
+		price := fs.Int64("price", 0, "price per billing period, RUB")
+		period := fs.Int("period", 0, "billing period, months (1, 3, 6, 12); default 1")
+		if err := fs.Parse(args); err != nil {
+			return usageError(err.Error())
+		}
+		s, err := b.Create(ctx, model.SubscriptionCreate{
+			ServiceName: *svc, Price: *price, BillingPeriod: *period, UserID: *user, StartYM: *start, EndYM: *end,
+		})
+		if err != nil {
+			return err
+		}
+		return out.subscriptions([]model.Subscription{s})
+
+	case "update":
+		svc, start, end := str("service", "service name"), str("start", "YYYY-MM"), str("end", `YYYY-MM, "" — no end`)
+		price := fs.Int64("price", 0, "price per billing period, RUB")
+		period := fs.Int("period", 0, "billing period, months (1, 3, 6, 12)")
+		id, err := parseID()
+		if err != nil {
+			return err
+		}
+		// меняем только явно переданные поля
+		var in model.SubscriptionUpdate
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "service":
+				in.ServiceName = svc
+			case "price":
+				in.Price = price
+			case "period":
+				in.BillingPeriod = period
+			case "start":
+				in.StartYM = start
+			case "end":
+				in.EndYM = end
+			}
+		})
+		s, err := b.Update(ctx, id, in)
+		if err != nil {
+			return err
+		}
+		return out.subscriptions([]model.Subscription{s})
+
+	case "total":
+		user, svc, from, to := str("user", "user UUID"), str("service", "service name"), str("from", "YYYY-MM"), str("to", "YYYY-MM")
+		cat, tag := str("category", "category"), str("tag", "tag")
+		if err := fs.Parse(args); err != nil {
+			return usageError(err.Error())
+		}
+		if *from == "" || *to == "" {
+			return usageError("total: --from and --to required")
+		}
+		total, err := b.Total(ctx, totalQuery{UserID: *user, ServiceName: *svc, Category: *cat, Tag: *tag, From: *from, To: *to})
+		if err != nil {
+			return err
+		}
+		return out.value("total", total)
+
+	case "import":
+		if err := fs.Parse(args); err != nil {
+			return usageError(err.Error())
+		}
+		in, err := openInput(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		imported, failed, err := importCSV(ctx, b, in, os.Stderr)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "imported %d, failed %d\n", imported, failed)
+		if failed > 0 {
+			return fmt.Errorf("%d row(s) failed", failed)
+		}
+		return nil
+
+	case "recalc-statuses":
+		n, err := b.RecalculateStatuses(ctx)
+		if err != nil {
+			return err
+		}
+		return out.value("updated", n)
+	}
+	return usageError(fmt.Sprintf("unknown command %q", cmd))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"subscription-service/internal/model"
+)
+
+// subscriptionColumns — колонки table/csv; даты в YYYY-MM, как их принимают create и import,
+// метки через tagSeparator
+var subscriptionColumns = []string{
+	"id", "service_name", "price", "user_id", "start_date", "end_date", "status",
+	"category", "custom_category", "tags", "billing_period",
+}
+
+// tagSeparator — разделитель меток в одной колонке (запятых в метках не бывает, но они разделяют CSV)
+const tagSeparator = ";"
+
+func subscriptionRow(s model.Subscription) []string {
+	end := ""
+	if s.EndDate != nil {
+		end = s.EndDate.Format("2006-01")
+	}
+	return []string{
+		s.ID.String(), s.ServiceName, strconv.FormatInt(s.Price, 10), s.UserID.String(),
+		s.StartDate.Format("2006-01"), end, s.Status,
+		s.Category, strconv.FormatBool(s.CustomCategory), strings.Join(s.Tags, tagSeparator),
+		strconv.Itoa(s.BillingPeriod),
+	}
+}
+
+// printer выводит результат в формате --output: table | json | csv
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p printer) subscriptions(items []model.Subscription) error {
+	if p.format == "json" {
+		if items == nil {
+			items = []model.Subscription{}
+		}
+		return p.json(items)
+	}
+	rows := make([][]string, 0, len(items))
+	for _, s := range items {
+		rows = append(rows, subscriptionRow(s))
+	}
+	return p.rows(subscriptionColumns, rows)
+}
+
+// value выводит одно именованное число (total, updated, ...)
+func (p printer) value(name string, v int64) error {
+	if p.format == "json" {
+		return p.json(map[string]int64{name: v})
+	}
+	return p.rows([]string{name}, [][]string{{strconv.FormatInt(v, 10)}})
+}
+
+func (p printer) json(v any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (p printer) rows(header []string, rows [][]string) error {
+	if p.format == "csv" {
+		cw := csv.NewWriter(p.w)
+		_ = cw.Write(header)
+		_ = cw.WriteAll(rows)
+		return cw.Error()
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	return tw.Flush()
+}
+
+func validFormat(f string) bool {
+	switch f {
+	case "table", "json", "csv":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/service"
+)
+
+type APIKeyHandlers struct {
+	keys *service.APIKeys
+}
+
+func NewAPIKeyHandlers(k *service.APIKeys) *APIKeyHandlers {
+	return &APIKeyHandlers{keys: k}
+}
+
+// Create godoc
+// @Summary      Создать API-ключ
+// @Description  Создаёт ключ для сервисного доступа; значение ключа возвращается только один раз
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        key  body      model.APIKeyCreate  true  "API key"
+// @Success      201  {object}  model.APIKeyCreated
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /api/v1/admin/api-keys [post]
+func (h *APIKeyHandlers) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.APIKeyCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	k, err := h.keys.Create(r.Context(), req)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("api key create failed", "err", err)
+		writeError(w, r, err)
+		return
+	}
+	log.FromContext(r.Context()).Info("api key created", "id", k.ID, "name", k.Name, "scopes", k.Scopes)
+	writeJSON(w, http.StatusCreated, k)
+}
+
+// List godoc
+// @Summary      Список API-ключей
+// @Description  Возвращает ключи без их значений
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   model.APIKey
+// @Failure      403  {object}  map[string]string
+// @Router       /api/v1/admin/api-keys [get]
+func (h *APIKeyHandlers) List(w http.ResponseWriter, r *http.Request) {
+	items, err := h.keys.List(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// Revoke godoc
+// @Summary      Отозвать API-ключ
+// @Tags         admin
+// @Param        id   path      string  true  "UUID ключа"
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/admin/api-keys/{id} [delete]
+func (h *APIKeyHandlers) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	if err := h.keys.Revoke(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	log.FromContext(r.Context()).Info("api key revoked", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
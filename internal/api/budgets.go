@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/service"
+)
+
+type BudgetHandlers struct {
+	svc *service.Budgets
+}
+
+func NewBudgetHandlers(s *service.Budgets) *BudgetHandlers {
+	return &BudgetHandlers{svc: s}
+}
+
+// Create godoc
+// @Summary      Создать бюджет
+// @Description  Месячный бюджет: общий (без category и service_name), на категорию или на сервис. Один бюджет на область
+// @Tags         budgets
+// @Accept       json
+// @Produce      json
+// @Param        budget  body      model.BudgetCreate  true  "Budget"
+// @Success      201  {object}  model.Budget
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/budgets/ [post]
+func (h *BudgetHandlers) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.BudgetCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	b, err := h.svc.Create(r.Context(), req)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("budget create failed", "err", err)
+		writeError(w, r, err)
+		return
+	}
+	log.FromContext(r.Context()).Info("budget created", "budget_id", b.ID, "user_id", b.UserID)
+	writeJSON(w, http.StatusCreated, b)
+}
+
+// List godoc
+// @Summary      Бюджеты пользователя
+// @Tags         budgets
+// @Produce      json
+// @Param        user_id  query  string  false  "UUID пользователя (для admin и API-ключей)"
+// @Success      200  {array}   model.Budget
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /api/v1/budgets/ [get]
+func (h *BudgetHandlers) List(w http.ResponseWriter, r *http.Request) {
+	items, err := h.svc.List(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// Get godoc
+// @Summary      Получить бюджет
+// @Tags         budgets
+// @Produce      json
+// @Param        id   path      string  true  "UUID бюджета"
+// @Success      200  {object}  model.Budget
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/budgets/{id} [get]
+func (h *BudgetHandlers) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	b, err := h.svc.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+// Update godoc
+// @Summary      Изменить сумму бюджета
+// @Description  Область бюджета не меняется. Превышение новой суммы в текущем месяце снова вызовет предупреждение
+// @Tags         budgets
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string              true  "UUID бюджета"
+// @Param        budget  body      model.BudgetUpdate  true  "Budget update"
+// @Success      200  {object}  model.Budget
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/budgets/{id} [put]
+func (h *BudgetHandlers) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	var req model.BudgetUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	b, err := h.svc.Update(r.Context(), id, req)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("budget update failed", "budget_id", id, "err", err)
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+// Delete godoc
+// @Summary      Удалить бюджет
+// @Tags         budgets
+// @Param        id   path      string  true  "UUID бюджета"
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/budgets/{id} [delete]
+func (h *BudgetHandlers) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	log.FromContext(r.Context()).Info("budget deleted", "budget_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Status godoc
+// @Summary      Траты в сравнении с бюджетами
+// @Description  Для каждого бюджета — траты по месяцам (как total с фильтром бюджета), остаток и превышение.
+// @Description  Текущий (по часовому поясу пользователя) и будущие месяцы — прогноз по текущим подпискам (forecast=true)
+// @Tags         budgets
+// @Produce      json
+// @Param        user_id  query  string  false  "UUID пользователя (для admin и API-ключей)"
+// @Param        from     query  string  false  "Начало периода (YYYY-MM), по умолчанию текущий месяц"
+// @Param        to       query  string  false  "Конец периода (YYYY-MM), по умолчанию from; до 36 месяцев"
+// @Success      200  {array}   model.BudgetStatus
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /api/v1/budgets/status [get]
+func (h *BudgetHandlers) Status(w http.ResponseWriter, r *http.Request) {
+	var from, to time.Time
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := parseYYYYMM(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid " + p.name + " (YYYY-MM)"})
+			return
+		}
+		*p.dst = t
+	}
+	items, err := h.svc.Status(r.Context(), r.URL.Query().Get("user_id"), from, to)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
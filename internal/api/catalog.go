@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/service"
+)
+
+type CatalogHandlers struct {
+	catalog *service.Catalog
+}
+
+func NewCatalogHandlers(c *service.Catalog) *CatalogHandlers {
+	return &CatalogHandlers{catalog: c}
+}
+
+// List godoc
+// @Summary      Каталог сервисов
+// @Tags         services
+// @Produce      json
+// @Param        category  query  string  false  "Категория"
+// @Param        q         query  string  false  "Подстрока названия или псевдонима"
+// @Param        limit     query  int     false  "Лимит (до 500)"  default(50)
+// @Param        offset    query  int     false  "Смещение"  default(0)
+// @Success      200  {array}   model.CatalogService
+// @Router       /api/v1/services [get]
+func (h *CatalogHandlers) List(w http.ResponseWriter, r *http.Request) {
+	q := model.CatalogQuery{
+		Category: r.URL.Query().Get("category"),
+		Search:   r.URL.Query().Get("q"),
+		Limit:    min(max(model.ParseInt(r.URL.Query().Get("limit"), 50), 1), 500),
+		Offset:   max(model.ParseInt(r.URL.Query().Get("offset"), 0), 0),
+	}
+	items, err := h.catalog.List(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// Get godoc
+// @Summary      Сервис из каталога
+// @Tags         services
+// @Produce      json
+// @Param        id   path      string  true  "UUID сервиса"
+// @Success      200  {object}  model.CatalogService
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/services/{id} [get]
+func (h *CatalogHandlers) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	s, err := h.catalog.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+// Create godoc
+// @Summary      Добавить сервис в каталог
+// @Description  Подписки, чьё название совпадает с названием или псевдонимом (без учёта регистра и лишних пробелов), переименовываются в каноническое
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        service  body      model.CatalogServiceCreate  true  "Service"
+// @Success      201  {object}  model.CatalogService
+// @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/admin/services [post]
+func (h *CatalogHandlers) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CatalogServiceCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	s, err := h.catalog.Create(r.Context(), req)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("catalog service create failed", "err", err)
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, s)
+}
+
+// Update godoc
+// @Summary      Изменить сервис каталога
+// @Description  aliases и plans заменяются целиком; прежнее название остаётся псевдонимом. Подписки переименовываются, как при создании
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                      true  "UUID сервиса"
+// @Param        service  body      model.CatalogServiceUpdate  true  "Service update"
+// @Success      200  {object}  model.CatalogService
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/admin/services/{id} [put]
+func (h *CatalogHandlers) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	var req model.CatalogServiceUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	s, err := h.catalog.Update(r.Context(), id, req)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("catalog service update failed", "service_id", id, "err", err)
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+// Delete godoc
+// @Summary      Удалить сервис из каталога
+// @Description  Подписки сохраняют своё название
+// @Tags         admin
+// @Param        id   path      string  true  "UUID сервиса"
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/admin/services/{id} [delete]
+func (h *CatalogHandlers) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	if err := h.catalog.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	log.FromContext(r.Context()).Info("catalog service deleted", "service_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Merge godoc
+// @Summary      Объединить дубликаты сервиса
+// @Description  Сервисы service_ids удаляются из каталога, их названия и names становятся псевдонимами сервиса id; подписки со всеми этими названиями переименовываются в каноническое
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string              true  "UUID сервиса, в который объединяются дубликаты"
+// @Param        merge  body      model.CatalogMerge  true  "Duplicates"
+// @Success      200  {object}  model.CatalogMergeResult
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/admin/services/{id}/merge [post]
+func (h *CatalogHandlers) Merge(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	var req model.CatalogMerge
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	res, err := h.catalog.Merge(r.Context(), id, req)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("catalog merge failed", "service_id", id, "err", err)
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
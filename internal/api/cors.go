@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type CORSOptions struct {
+	AllowedOrigins   []string // "*" — любой origin
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// CORS отвечает на preflight и проставляет Access-Control-* для разрешённых origin;
+// без AllowedOrigins ничего не делает. Настройки меняются на лету через Update.
+type CORS struct {
+	policy atomic.Pointer[corsPolicy]
+}
+
+type corsPolicy struct {
+	CORSOptions
+	methods, headers, maxAge string
+}
+
+func NewCORS(o CORSOptions) *CORS {
+	c := &CORS{}
+	c.Update(o)
+	return c
+}
+
+func (c *CORS) Update(o CORSOptions) {
+	c.policy.Store(&corsPolicy{
+		CORSOptions: o,
+		methods:     strings.Join(o.AllowedMethods, ", "),
+		headers:     strings.Join(o.AllowedHeaders, ", "),
+		maxAge:      strconv.Itoa(int(o.MaxAge.Seconds())),
+	})
+}
+
+func (p *corsPolicy) allowed(origin string) bool {
+	for _, a := range p.AllowedOrigins {
+		if a == "*" || strings.EqualFold(a, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := c.policy.Load()
+		origin := r.Header.Get("Origin")
+		if origin == "" || !p.allowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		h.Set("Access-Control-Allow-Origin", origin)
+		if p.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", p.methods)
+			h.Set("Access-Control-Allow-Headers", p.headers)
+			h.Set("Access-Control-Max-Age", p.maxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
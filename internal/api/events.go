@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/events"
+	"subscription-service/internal/log"
+	"subscription-service/internal/service"
+)
+
+// EventStream — SSE-поток изменений подписок
+type EventStream struct {
+	hub       *events.Hub
+	svc       *service.Service
+	heartbeat time.Duration
+}
+
+func NewEventStream(hub *events.Hub, svc *service.Service, heartbeat time.Duration) *EventStream {
+	return &EventStream{hub: hub, svc: svc, heartbeat: heartbeat}
+}
+
+// Stream godoc
+// @Summary      Поток изменений подписок (SSE)
+// @Description  text/event-stream: события created, updated, deleted (data — {"id","type","subscription","at"}),
+// @Description  reset — пропущенные события не восстановить, перечитайте список. Обычный пользователь получает только свои.
+// @Tags         subscriptions
+// @Produce      text/event-stream
+// @Param        user_id        query   string  false  "UUID пользователя (для admin и API-ключей)"
+// @Param        Last-Event-ID  header  string  false  "ID последнего полученного события (или query last_event_id)"
+// @Success      200  {string}  string  "event stream"
+// @Failure      403  {object}  map[string]string
+// @Router       /api/v1/subscriptions/events [get]
+func (h *EventStream) Stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": auth.ErrUnauthenticated.Error()})
+		return
+	}
+	// без user_id admin и API-ключи получают все события, пользователь — свои
+	var userID uuid.UUID
+	if s := r.URL.Query().Get("user_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user_id"})
+			return
+		}
+		userID = id
+	} else if !p.Unrestricted() {
+		userID = p.UserID
+	}
+	if userID != uuid.Nil {
+		if err := h.svc.CheckUserAccess(ctx, userID); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id") // EventSource не умеет заголовки при первом подключении
+	}
+	var last int64
+	if lastID != "" {
+		n, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid Last-Event-ID"})
+			return
+		}
+		last = n
+	}
+
+	// поток живёт дольше HTTP_WRITE_TIMEOUT
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.FromContext(ctx).Warn("sse: cannot disable write deadline", "err", err)
+	}
+
+	sub, replay, gap := h.hub.Subscribe(last, func(ev events.Event) bool {
+		return userID == uuid.Nil || ev.Subscription != nil && ev.Subscription.UserID == userID
+	})
+	defer h.hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx не должен буферизовать поток
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	send := func(ev events.Event) error {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		if ev.ID != 0 {
+			fmt.Fprintf(w, "id: %d\n", ev.ID)
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	if gap {
+		log.FromContext(ctx).Debug("sse: last event id not in buffer", "last_event_id", last)
+		replay = []events.Event{{Type: events.Reset, At: time.Now().UTC()}}
+	}
+	for _, ev := range replay {
+		if err := send(ev); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(h.heartbeat)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				// сервер останавливается или клиент не успевал читать — клиент переподключится с Last-Event-ID
+				log.FromContext(ctx).Debug("sse: stream closed by server", "dropped", sub.Dropped())
+				return
+			}
+			if err := send(ev); err != nil {
+				return
+			}
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
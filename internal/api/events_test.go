@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/events"
+	"subscription-service/internal/model"
+	"subscription-service/internal/service"
+)
+
+// sseEvent — событие потока: поля id и event
+type sseEvent struct{ id, typ string }
+
+func readSSE(t *testing.T, sc *bufio.Scanner) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "" && ev.typ != "":
+			return ev
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.typ = strings.TrimPrefix(line, "event: ")
+		}
+	}
+	t.Fatalf("stream ended: %v", sc.Err())
+	return ev
+}
+
+func TestEventStream(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	admin := auth.Principal{Role: auth.RoleAdmin, UserID: uuid.New()}
+	user := auth.Principal{Role: auth.RoleUser, UserID: alice}
+	deliver := func(hub *events.Hub, id int64, owner uuid.UUID) {
+		hub.Deliver(events.Event{ID: id, Type: events.Updated, Subscription: &model.Subscription{UserID: owner}})
+	}
+
+	tests := []struct {
+		name      string
+		principal auth.Principal
+		query     string
+		lastID    string // заголовок Last-Event-ID
+		code      int
+		want      []sseEvent // replay и затем одно живое событие
+	}{
+		{
+			name: "admin resumes all", principal: admin, lastID: "1", code: http.StatusOK,
+			want: []sseEvent{{"2", "updated"}, {"3", "updated"}, {"101", "updated"}},
+		},
+		{
+			name: "user gets only own", principal: user, lastID: "1", code: http.StatusOK,
+			want: []sseEvent{{"3", "updated"}, {"102", "updated"}},
+		},
+		{
+			name: "admin filters by user_id", principal: admin, query: "?user_id=" + bob.String(), lastID: "1", code: http.StatusOK,
+			want: []sseEvent{{"2", "updated"}, {"101", "updated"}},
+		},
+		{
+			name: "last_event_id in query", principal: admin, query: "?last_event_id=2", code: http.StatusOK,
+			want: []sseEvent{{"3", "updated"}, {"101", "updated"}},
+		},
+		{
+			name: "id not in buffer sends reset", principal: admin, lastID: "99", code: http.StatusOK,
+			want: []sseEvent{{"", "reset"}, {"101", "updated"}},
+		},
+		{name: "no last id", principal: admin, code: http.StatusOK, want: []sseEvent{{"101", "updated"}}},
+		{name: "invalid last id", principal: admin, lastID: "abc", code: http.StatusBadRequest},
+		{name: "invalid user_id", principal: admin, query: "?user_id=bob", code: http.StatusBadRequest},
+		{name: "other user's stream", principal: user, query: "?user_id=" + bob.String(), code: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := events.NewHub(3)
+			deliver(hub, 1, alice)
+			deliver(hub, 2, bob)
+			deliver(hub, 3, alice)
+
+			h := NewEventStream(hub, &service.Service{}, time.Hour)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				h.Stream(w, r.WithContext(auth.WithPrincipal(r.Context(), tt.principal)))
+			}))
+			defer srv.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+tt.query, nil)
+			if tt.lastID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastID)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.code {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+				t.Fatalf("Content-Type %q", ct)
+			}
+
+			sc := bufio.NewScanner(resp.Body)
+			replay := tt.want[:len(tt.want)-1]
+			for _, want := range replay {
+				if got := readSSE(t, sc); got != want {
+					t.Fatalf("replay event %+v, want %+v", got, want)
+				}
+			}
+			// подписка уже есть: живые события приходят после replay
+			deliver(hub, 101, bob)
+			deliver(hub, 102, alice)
+			if got, want := readSSE(t, sc), tt.want[len(tt.want)-1]; got != want {
+				t.Fatalf("live event %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -1,25 +1,29 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"subscription-service/internal/auth"
 	"subscription-service/internal/log"
 	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
 	"subscription-service/internal/service"
 )
 
+// Handlers логируют через логгер запроса (log.FromContext), см. RequestLogger
 type Handlers struct {
-	svc    *service.Service
-	logger *log.Logger
+	svc *service.Service
 }
 
-func NewHandlers(s *service.Service, l *log.Logger) *Handlers {
-	return &Handlers{svc: s, logger: l}
+func NewHandlers(s *service.Service) *Handlers {
+	return &Handlers{svc: s}
 }
 
 func writeJSON(w http.ResponseWriter, code int, v any) {
@@ -28,6 +32,41 @@ func writeJSON(w http.ResponseWriter, code int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// writeError отвечает ошибкой сервиса: доменные ошибки и ValidationError — своими кодами с текстом,
+// остальное (сбой БД и т.п.) — 500 без подробностей, причина пишется в лог запроса
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	code := errStatus(err)
+	msg := err.Error()
+	if code == http.StatusInternalServerError {
+		log.FromContext(r.Context()).Error("request failed", "err", err)
+		msg = "internal error"
+	}
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+// errStatus подбирает HTTP-код для ошибки сервиса; неизвестные ошибки — 500
+func errStatus(err error) int {
+	var invalid *service.ValidationError
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, auth.ErrForbidden):
+		return http.StatusForbidden
+	case errors.As(err, &invalid), errors.Is(err, repo.ErrUnknownUser):
+		return http.StatusBadRequest
+	case errors.Is(err, repo.ErrNotFound), errors.Is(err, repo.ErrJobNotFound), errors.Is(err, repo.ErrUserNotFound),
+		errors.Is(err, repo.ErrServiceNotFound), errors.Is(err, repo.ErrBudgetNotFound), errors.Is(err, repo.ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repo.ErrJobState), errors.Is(err, repo.ErrSubscriptionArchived),
+		errors.Is(err, repo.ErrUserExists), errors.Is(err, repo.ErrUserArchived), errors.Is(err, repo.ErrEmailTaken),
+		errors.Is(err, repo.ErrServiceNameTaken), errors.Is(err, repo.ErrBudgetExists):
+		return http.StatusConflict
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout // сработал statement timeout
+	}
+	return http.StatusInternalServerError
+}
+
 func parseYYYYMM(s string) (time.Time, error) {
 	// Принимаем YYYY-MM
 	return time.Parse("2006-01", s)
@@ -46,17 +85,17 @@ func parseYYYYMM(s string) (time.Time, error) {
 func (h *Handlers) Create(w http.ResponseWriter, r *http.Request) {
 	var req model.SubscriptionCreate
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("invalid json", "err", err)
+		log.FromContext(r.Context()).Error("invalid json", "err", err)
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
 		return
 	}
 	sub, err := h.svc.Create(r.Context(), req)
 	if err != nil {
-		h.logger.Warn("create failed", "err", err)
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		log.FromContext(r.Context()).Warn("create failed", "err", err)
+		writeError(w, r, err)
 		return
 	}
-	h.logger.Info("subscription created", "id", sub.ID, "user_id", sub.UserID)
+	log.FromContext(r.Context()).Info("subscription created", "id", sub.ID, "user_id", sub.UserID)
 	writeJSON(w, http.StatusCreated, sub)
 }
 
@@ -79,8 +118,8 @@ func (h *Handlers) GetByID(w http.ResponseWriter, r *http.Request) {
 	}
 	sub, err := h.svc.GetByID(r.Context(), id)
 	if err != nil {
-		h.logger.Warn("subscription not found", "id", id, "err", err)
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		log.FromContext(r.Context()).Warn("subscription not found", "id", id, "err", err)
+		writeError(w, r, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, sub)
@@ -112,11 +151,11 @@ func (h *Handlers) Update(w http.ResponseWriter, r *http.Request) {
 	}
 	sub, err := h.svc.Update(r.Context(), id, req)
 	if err != nil {
-		h.logger.Warn("update failed", "id", id, "err", err)
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		log.FromContext(r.Context()).Warn("update failed", "id", id, "err", err)
+		writeError(w, r, err)
 		return
 	}
-	h.logger.Info("subscription updated", "id", sub.ID)
+	log.FromContext(r.Context()).Info("subscription updated", "id", sub.ID)
 	writeJSON(w, http.StatusOK, sub)
 }
 
@@ -138,11 +177,11 @@ func (h *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := h.svc.Delete(r.Context(), id); err != nil {
-		h.logger.Warn("delete failed", "id", id, "err", err)
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		log.FromContext(r.Context()).Warn("delete failed", "id", id, "err", err)
+		writeError(w, r, err)
 		return
 	}
-	h.logger.Info("subscription deleted", "id", id)
+	log.FromContext(r.Context()).Info("subscription deleted", "id", id)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -151,8 +190,11 @@ func (h *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
 // @Description  Возвращает список подписок по фильтрам
 // @Tags         subscriptions
 // @Produce      json
-// @Param        user_id      query  string  false  "UUID пользователя"
+// @Param        user_id      query  string  false  "UUID пользователя (учитывается только для admin)"
 // @Param        service_name query  string  false  "Название сервиса"
+// @Param        status       query  string  false  "Статус: upcoming | active | expired | archived"
+// @Param        category     query  string  false  "Категория (своя или сервиса из каталога, без учёта регистра)"
+// @Param        tag          query  string  false  "Метка"
 // @Param        limit        query  int     false  "Лимит"  default(50)
 // @Param        offset       query  int     false  "Смещение"  default(0)
 // @Success      200  {array}  model.Subscription
@@ -162,13 +204,15 @@ func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
 	q := model.ListQuery{
 		UserID:      r.URL.Query().Get("user_id"),
 		ServiceName: r.URL.Query().Get("service_name"),
+		Status:      r.URL.Query().Get("status"),
+		Category:    r.URL.Query().Get("category"),
+		Tag:         r.URL.Query().Get("tag"),
 		Limit:       model.ParseInt(r.URL.Query().Get("limit"), 50),
 		Offset:      model.ParseInt(r.URL.Query().Get("offset"), 0),
 	}
 	items, err := h.svc.List(r.Context(), q)
 	if err != nil {
-		h.logger.Error("list failed", "err", err)
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		writeError(w, r, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, items)
@@ -176,11 +220,14 @@ func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
 
 // Total godoc
 // @Summary      Общая стоимость подписок
-// @Description  Возвращает суммарную стоимость подписок за выбранный период
+// @Description  Возвращает суммарную стоимость подписок за выбранный период; с group_by — ещё и по категориям или меткам (model.GroupedTotal)
 // @Tags         subscriptions
 // @Produce      json
-// @Param        user_id      query  string  true  "UUID пользователя"
+// @Param        user_id      query  string  false "UUID пользователя (для обычного пользователя — всегда он сам)"
 // @Param        service_name query  string  false "Название сервиса"
+// @Param        category     query  string  false "Категория"
+// @Param        tag          query  string  false "Метка"
+// @Param        group_by     query  string  false "category | tag"
 // @Param        from         query  string  true  "Начало периода (YYYY-MM)"
 // @Param        to           query  string  true  "Конец периода (YYYY-MM)"
 // @Success      200  {object}  map[string]int64
@@ -188,36 +235,233 @@ func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
 // @Router       /api/v1/subscriptions/total [get]
 func (h *Handlers) Total(w http.ResponseWriter, r *http.Request) {
 	user := r.URL.Query().Get("user_id")
-	if user == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "user_id required"})
+	q, msg := totalQuery(r, user)
+	if msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
+	writeTotal(w, r, h.svc, q)
+}
+
+// Forecast godoc
+// @Summary      Прогноз стоимости подписок
+// @Description  Стоимость по месяцам, если текущие подписки не менять: каждая действует до end_date (без неё — бессрочно) и списывается раз в billing_period месяцев по цене с учётом запланированных изменений. В каждом месяце — подписки со списанием в нём
+// @Tags         subscriptions
+// @Produce      json
+// @Param        user_id      query  string  false "UUID пользователя (для обычного пользователя — всегда он сам)"
+// @Param        months       query  int     false "Сколько месяцев (1..60)"  default(12)
+// @Param        from         query  string  false "Первый месяц (YYYY-MM), по умолчанию текущий"
+// @Param        service_name query  string  false "Название сервиса"
+// @Param        category     query  string  false "Категория"
+// @Param        tag          query  string  false "Метка"
+// @Success      200  {object}  model.Forecast
+// @Failure      400  {object}  map[string]string
+// @Router       /api/v1/subscriptions/forecast [get]
+func (h *Handlers) Forecast(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	q := model.ForecastQuery{
+		UserID:      r.URL.Query().Get("user_id"),
+		ServiceName: r.URL.Query().Get("service_name"),
+		Category:    r.URL.Query().Get("category"),
+		Tag:         r.URL.Query().Get("tag"),
+		From:        time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+		Months:      model.ParseInt(r.URL.Query().Get("months"), model.DefaultForecastMonths),
+	}
+	if v := r.URL.Query().Get("from"); v != "" {
+		from, err := parseYYYYMM(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid from (YYYY-MM)"})
+			return
+		}
+		q.From = from
+	}
+	f, err := h.svc.Forecast(r.Context(), q)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("forecast failed", "user_id", q.UserID, "err", err)
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, f)
+}
+
+// writeTotal отвечает суммой за период, а с group_by — model.GroupedTotal
+func writeTotal(w http.ResponseWriter, r *http.Request, svc *service.Service, q model.TotalQuery) {
+	total, err := svc.Total(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		writeJSON(w, http.StatusOK, map[string]int64{"total": total})
+		return
+	}
+	groups, err := svc.TotalBy(r.Context(), q, groupBy)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, model.GroupedTotal{Total: total, GroupBy: groupBy, Groups: groups})
+}
+
+// AddTags godoc
+// @Summary      Добавить метки подписке
+// @Description  Метки приводятся к нижнему регистру; уже имеющиеся пропускаются. До 20 меток по 50 символов, без запятых
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string            true  "UUID подписки"
+// @Param        tags  body      model.TagsUpdate  true  "Tags"
+// @Success      200  {object}  model.Subscription
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/subscriptions/{id}/tags [post]
+func (h *Handlers) AddTags(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	var req model.TagsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	sub, err := h.svc.AddTags(r.Context(), id, req.Tags)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("add tags failed", "id", id, "err", err)
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// RemoveTag godoc
+// @Summary      Снять метку с подписки
+// @Description  Отсутствующая метка — не ошибка
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id   path      string  true  "UUID подписки"
+// @Param        tag  path      string  true  "Метка"
+// @Success      200  {object}  model.Subscription
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/subscriptions/{id}/tags/{tag} [delete]
+func (h *Handlers) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	sub, err := h.svc.RemoveTag(r.Context(), id, chi.URLParam(r, "tag"))
+	if err != nil {
+		log.FromContext(r.Context()).Warn("remove tag failed", "id", id, "err", err)
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// SetPrice godoc
+// @Summary      Запланировать цену подписки
+// @Description  С месяца month (позже start_date) подписка стоит price за период оплаты; изменение на тот же месяц заменяется. До 24 изменений
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string                   true  "UUID подписки"
+// @Param        price  body      model.PriceChangeCreate  true  "Price change"
+// @Success      200  {object}  model.Subscription
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/subscriptions/{id}/prices [post]
+func (h *Handlers) SetPrice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	var req model.PriceChangeCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	sub, err := h.svc.SetPrice(r.Context(), id, req)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("set price failed", "id", id, "err", err)
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// RemovePrice godoc
+// @Summary      Отменить запланированную цену
+// @Description  Отсутствующее изменение — не ошибка
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id     path      string  true  "UUID подписки"
+// @Param        month  path      string  true  "Месяц изменения (YYYY-MM)"
+// @Success      200  {object}  model.Subscription
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/subscriptions/{id}/prices/{month} [delete]
+func (h *Handlers) RemovePrice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	sub, err := h.svc.RemovePrice(r.Context(), id, chi.URLParam(r, "month"))
+	if err != nil {
+		log.FromContext(r.Context()).Warn("remove price failed", "id", id, "err", err)
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// totalQuery разбирает from, to и фильтры service_name, category и tag; msg — текст ошибки для 400
+func totalQuery(r *http.Request, userID string) (q model.TotalQuery, msg string) {
 	fromS := r.URL.Query().Get("from")
 	toS := r.URL.Query().Get("to")
 	if fromS == "" || toS == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from and to required (YYYY-MM)"})
-		return
+		return q, "from and to required (YYYY-MM)"
 	}
 	from, err := parseYYYYMM(fromS)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid from (YYYY-MM)"})
-		return
+		return q, "invalid from (YYYY-MM)"
 	}
 	to, err := parseYYYYMM(toS)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid to (YYYY-MM)"})
-		return
+		return q, "invalid to (YYYY-MM)"
 	}
-	total, err := h.svc.Total(r.Context(), model.TotalQuery{
-		UserID:      user,
+	return model.TotalQuery{
+		UserID:      userID,
 		ServiceName: r.URL.Query().Get("service_name"),
+		Category:    r.URL.Query().Get("category"),
+		Tag:         r.URL.Query().Get("tag"),
 		From:        from,
 		To:          to,
-	})
+	}, ""
+}
+
+// RecalculateStatuses godoc
+// @Summary      Пересчитать статусы подписок
+// @Description  Приводит status всех подписок к текущему месяцу (upcoming / active / expired)
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]int64
+// @Failure      403  {object}  map[string]string
+// @Router       /api/v1/admin/subscriptions/recalculate-statuses [post]
+func (h *Handlers) RecalculateStatuses(w http.ResponseWriter, r *http.Request) {
+	n, err := h.svc.RecalculateStatuses(r.Context())
 	if err != nil {
-		h.logger.Error("total calc failed", "user_id", user, "err", err)
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		writeError(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]int64{"total": total})
+	writeJSON(w, http.StatusOK, map[string]int64{"updated": n})
 }
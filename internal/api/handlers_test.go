@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/service"
+)
+
+func TestErrStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{auth.ErrUnauthenticated, http.StatusUnauthorized},
+		{auth.ErrForbidden, http.StatusForbidden},
+		{&service.ValidationError{}, http.StatusBadRequest},
+		{fmt.Errorf("create: %w", &service.ValidationError{}), http.StatusBadRequest},
+		{repo.ErrUnknownUser, http.StatusBadRequest},
+		{repo.ErrNotFound, http.StatusNotFound},
+		{fmt.Errorf("resolve: %w", repo.ErrServiceNotFound), http.StatusNotFound},
+		{repo.ErrAPIKeyNotFound, http.StatusNotFound},
+		{repo.ErrSubscriptionArchived, http.StatusConflict},
+		{repo.ErrEmailTaken, http.StatusConflict},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{errors.New("connection reset by peer"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := errStatus(tt.err); got != tt.want {
+			t.Errorf("errStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+		msg  string
+	}{
+		{name: "domain error keeps message", err: repo.ErrNotFound, code: http.StatusNotFound, msg: repo.ErrNotFound.Error()},
+		{name: "internal error is hidden", err: errors.New("pq: relation does not exist"), code: http.StatusInternalServerError, msg: "internal error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeError(rec, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+			var body map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.code || body["error"] != tt.msg {
+				t.Fatalf("got %d %q, want %d %q", rec.Code, body["error"], tt.code, tt.msg)
+			}
+		})
+	}
+}
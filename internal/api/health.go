@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check — проверка зависимости для /readyz
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+type checkResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+type readiness struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+// MigrationsCheck — не готов, пока есть неприменённые миграции (pending — repo.Migrator.Pending)
+func MigrationsCheck(pending func(ctx context.Context) (int, error)) Check {
+	return Check{Name: "migrations", Fn: func(ctx context.Context) error {
+		n, err := pending(ctx)
+		if err == nil && n > 0 {
+			err = fmt.Errorf("%d pending migration(s)", n)
+		}
+		return err
+	}}
+}
+
+// Health отдаёт /livez (процесс жив) и /readyz (зависимости доступны и приложение не останавливается)
+type Health struct {
+	checks   []Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// timeout — общий дедлайн проверок одного запроса /readyz
+func NewHealth(timeout time.Duration, checks ...Check) *Health {
+	return &Health{checks: checks, timeout: timeout}
+}
+
+// Drain переводит /readyz в 503, чтобы балансировщик вывел под до srv.Shutdown
+func (h *Health) Drain() { h.draining.Store(true) }
+
+func (h *Health) Live(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, readiness{Status: "draining"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	res := readiness{Status: "ok", Checks: make(map[string]checkResult, len(h.checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := c.Fn(ctx)
+			cr := checkResult{Status: "ok", DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				cr.Status, cr.Error = "fail", err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			res.Checks[c.Name] = cr
+			if err != nil {
+				res.Status = "fail"
+			}
+		}()
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if res.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, res)
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthReady(t *testing.T) {
+	ok := Check{Name: "db", Fn: func(context.Context) error { return nil }}
+	down := Check{Name: "db", Fn: func(context.Context) error { return errors.New("connection refused") }}
+	slow := Check{Name: "cache", Fn: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	pending := func(n int, err error) func(context.Context) (int, error) {
+		return func(context.Context) (int, error) { return n, err }
+	}
+
+	tests := []struct {
+		name   string
+		checks []Check
+		drain  bool
+		code   int
+		status string
+		failed map[string]string // проверка → текст ошибки
+	}{
+		{name: "no checks", code: http.StatusOK, status: "ok"},
+		{name: "all ok", checks: []Check{ok, MigrationsCheck(pending(0, nil))}, code: http.StatusOK, status: "ok"},
+		{
+			name: "dependency down", checks: []Check{down, MigrationsCheck(pending(0, nil))},
+			code: http.StatusServiceUnavailable, status: "fail", failed: map[string]string{"db": "connection refused"},
+		},
+		{
+			name: "pending migrations", checks: []Check{ok, MigrationsCheck(pending(2, nil))},
+			code: http.StatusServiceUnavailable, status: "fail", failed: map[string]string{"migrations": "2 pending migration(s)"},
+		},
+		{
+			name: "migration version unreadable", checks: []Check{MigrationsCheck(pending(0, errors.New("relation schema_migrations does not exist")))},
+			code: http.StatusServiceUnavailable, status: "fail", failed: map[string]string{"migrations": "relation schema_migrations does not exist"},
+		},
+		{
+			name: "timeout", checks: []Check{ok, slow},
+			code: http.StatusServiceUnavailable, status: "fail", failed: map[string]string{"cache": context.DeadlineExceeded.Error()},
+		},
+		{name: "draining skips checks", checks: []Check{down}, drain: true, code: http.StatusServiceUnavailable, status: "draining"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealth(20*time.Millisecond, tt.checks...)
+			if tt.drain {
+				h.Drain()
+			}
+			rec := httptest.NewRecorder()
+			h.Ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			var body readiness
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.code || body.Status != tt.status {
+				t.Fatalf("got %d %q, want %d %q", rec.Code, body.Status, tt.code, tt.status)
+			}
+			if tt.drain {
+				if len(body.Checks) != 0 {
+					t.Fatalf("draining ran checks: %+v", body.Checks)
+				}
+				return
+			}
+			if len(body.Checks) != len(tt.checks) {
+				t.Fatalf("checks = %+v, want %d", body.Checks, len(tt.checks))
+			}
+			for name, cr := range body.Checks {
+				want, failed := tt.failed[name]
+				switch {
+				case failed && (cr.Status != "fail" || cr.Error != want):
+					t.Errorf("check %s = %+v, want fail %q", name, cr, want)
+				case !failed && (cr.Status != "ok" || cr.Error != ""):
+					t.Errorf("check %s = %+v, want ok", name, cr)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthLiveWhileDraining(t *testing.T) {
+	h := NewHealth(time.Second)
+	h.Drain()
+	rec := httptest.NewRecorder()
+	h.Live(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("livez while draining = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"subscription-service/internal/jobs"
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+)
+
+type JobHandlers struct {
+	queue *jobs.Queue
+}
+
+func NewJobHandlers(q *jobs.Queue) *JobHandlers {
+	return &JobHandlers{queue: q}
+}
+
+func jobID(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// List godoc
+// @Summary      Список фоновых задач
+// @Description  Задачи очереди, новые первыми
+// @Tags         admin
+// @Produce      json
+// @Param        kind    query  string  false  "Вид задачи"
+// @Param        status  query  string  false  "Статус: queued | running | succeeded | failed | cancelled"
+// @Param        limit   query  int     false  "Лимит (до 500)"  default(50)
+// @Param        offset  query  int     false  "Смещение"  default(0)
+// @Success      200  {array}   model.Job
+// @Failure      403  {object}  map[string]string
+// @Router       /api/v1/admin/jobs [get]
+func (h *JobHandlers) List(w http.ResponseWriter, r *http.Request) {
+	q := model.JobQuery{
+		Kind:   r.URL.Query().Get("kind"),
+		Status: r.URL.Query().Get("status"),
+		Limit:  min(max(model.ParseInt(r.URL.Query().Get("limit"), 50), 1), 500),
+		Offset: max(model.ParseInt(r.URL.Query().Get("offset"), 0), 0),
+	}
+	items, err := h.queue.List(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// Get godoc
+// @Summary      Фоновая задача
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      int  true  "ID задачи"
+// @Success      200  {object}  model.Job
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/admin/jobs/{id} [get]
+func (h *JobHandlers) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := jobID(r)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	j, err := h.queue.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, j)
+}
+
+// Retry godoc
+// @Summary      Повторить фоновую задачу
+// @Description  Ставит failed или cancelled задачу в очередь заново со сброшенным счётчиком попыток
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      int  true  "ID задачи"
+// @Success      200  {object}  model.Job
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/admin/jobs/{id}/retry [post]
+func (h *JobHandlers) Retry(w http.ResponseWriter, r *http.Request) {
+	id, ok := jobID(r)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	j, err := h.queue.Retry(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	log.FromContext(r.Context()).Info("job retried", "job_id", id, "job_kind", j.Kind)
+	writeJSON(w, http.StatusOK, j)
+}
+
+// Cancel godoc
+// @Summary      Отменить фоновую задачу
+// @Description  Отменяет queued или running задачу; уже запущенный обработчик не прерывается, но его результат не записывается
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      int  true  "ID задачи"
+// @Success      200  {object}  model.Job
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/admin/jobs/{id}/cancel [post]
+func (h *JobHandlers) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, ok := jobID(r)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	j, err := h.queue.Cancel(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	log.FromContext(r.Context()).Info("job cancelled", "job_id", id, "job_kind", j.Kind)
+	writeJSON(w, http.StatusOK, j)
+}
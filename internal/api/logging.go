@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"subscription-service/internal/log"
+)
+
+// LoggingHandlers — просмотр и изменение настроек логирования без рестарта
+type LoggingHandlers struct {
+	ctl *log.Controls
+}
+
+func NewLoggingHandlers(ctl *log.Controls) *LoggingHandlers { return &LoggingHandlers{ctl: ctl} }
+
+type loggingSettings struct {
+	Level            string `json:"level"`
+	DebugSampleEvery int    `json:"debug_sample_every"`
+}
+
+type loggingUpdate struct {
+	Level            *string `json:"level,omitempty"`
+	DebugSampleEvery *int    `json:"debug_sample_every,omitempty"`
+}
+
+// Get godoc
+// @Summary      Настройки логирования
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  loggingSettings
+// @Router       /api/v1/admin/logging [get]
+func (h *LoggingHandlers) Get(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, loggingSettings{Level: h.ctl.Level(), DebugSampleEvery: h.ctl.DebugSampleEvery()})
+}
+
+// Update godoc
+// @Summary      Изменить настройки логирования
+// @Description  Уровень (debug|info|warn|error) и сэмплирование debug-записей применяются сразу
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        settings body      loggingUpdate  true  "Logging settings"
+// @Success      200      {object}  loggingSettings
+// @Failure      400      {object}  map[string]string
+// @Router       /api/v1/admin/logging [put]
+func (h *LoggingHandlers) Update(w http.ResponseWriter, r *http.Request) {
+	var req loggingUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if req.Level != nil {
+		if err := h.ctl.SetLevel(*req.Level); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if req.DebugSampleEvery != nil {
+		h.ctl.SetDebugSampleEvery(*req.DebugSampleEvery)
+	}
+	log.FromContext(r.Context()).Info("logging settings changed", "level", h.ctl.Level(), "debug_sample_every", h.ctl.DebugSampleEvery())
+	h.Get(w, r)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/service"
+)
+
+type NotificationHandlers struct {
+	svc *service.Notifications
+}
+
+func NewNotificationHandlers(s *service.Notifications) *NotificationHandlers {
+	return &NotificationHandlers{svc: s}
+}
+
+// GetPreferences godoc
+// @Summary      Настройки напоминаний
+// @Description  Срок (за сколько дней) и каналы напоминаний о продлении и окончании подписок.
+// @Description  Если пользователь их не менял, возвращаются значения по умолчанию.
+// @Tags         notifications
+// @Produce      json
+// @Param        user_id  query     string  false  "UUID пользователя (для admin и API-ключей)"
+// @Success      200      {object}  model.NotificationPreferences
+// @Failure      400      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Router       /api/v1/notification-preferences [get]
+func (h *NotificationHandlers) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	p, err := h.svc.Get(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// UpdatePreferences godoc
+// @Summary      Изменить настройки напоминаний
+// @Description  Частичное изменение: lead_days 1..28, channels из email, webhook, log;
+// @Description  для email нужен email, для webhook — webhook_url
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        user_id      query     string                               false  "UUID пользователя (для admin и API-ключей)"
+// @Param        preferences  body      model.NotificationPreferencesUpdate  true   "Preferences"
+// @Success      200          {object}  model.NotificationPreferences
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Router       /api/v1/notification-preferences [put]
+func (h *NotificationHandlers) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	var req model.NotificationPreferencesUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	p, err := h.svc.Update(r.Context(), r.URL.Query().Get("user_id"), req)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("notification preferences update failed", "err", err)
+		writeError(w, r, err)
+		return
+	}
+	log.FromContext(r.Context()).Info("notification preferences updated", "user_id", p.UserID, "channels", p.Channels)
+	writeJSON(w, http.StatusOK, p)
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/log"
+)
+
+// Limit — параметры token bucket: RPS пополнение в секунду, Burst — ёмкость
+type Limit struct {
+	RPS   float64
+	Burst int
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // когда появится следующий токен (если не Allowed)
+	Reset      time.Duration // когда bucket заполнится полностью
+}
+
+// RateLimitStore — хранилище bucket'ов; для нескольких реплик реализуется поверх общего стора (Redis и т.п.)
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, l Limit) (RateLimitResult, error)
+}
+
+// RateLimiter — middleware ограничения частоты запросов по клиенту и маршруту
+type RateLimiter struct {
+	store  RateLimitStore
+	limits atomic.Pointer[limits]
+	ip     atomic.Pointer[ipLimit]
+}
+
+type ipLimit struct {
+	limit   Limit
+	trusted []netip.Prefix
+}
+
+type limits struct {
+	def    Limit
+	routes map[string]Limit
+}
+
+// NewRateLimiter: routes — ключи вида "GET /api/v1/subscriptions/total" или просто шаблон маршрута.
+// Маршрут со своим лимитом получает отдельный bucket, остальные делят общий bucket клиента.
+func NewRateLimiter(store RateLimitStore, def Limit, routes map[string]Limit) *RateLimiter {
+	rl := &RateLimiter{store: store}
+	rl.SetLimits(def, routes)
+	rl.SetIPLimit(Limit{}, nil)
+	return rl
+}
+
+// SetIPLimit задаёт лимит IPMiddleware; нулевой Limit — выключено. trusted — адреса прокси,
+// от которых принимается X-Forwarded-For; без них ключ — адрес соединения
+func (rl *RateLimiter) SetIPLimit(l Limit, trusted []netip.Prefix) {
+	rl.ip.Store(&ipLimit{limit: l, trusted: trusted})
+}
+
+// SetLimits заменяет лимиты на лету (hot reload); уже накопленные bucket'ы сохраняются
+func (rl *RateLimiter) SetLimits(def Limit, routes map[string]Limit) {
+	rl.limits.Store(&limits{def: def, routes: routes})
+}
+
+// Middleware подключается на уровне конечного маршрута (r.With), чтобы шаблон маршрута был известен
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pattern := chi.RouteContext(r.Context()).RoutePattern()
+		cur := rl.limits.Load()
+		limit, key := cur.def, clientKey(r)
+		if l, ok := cur.routes[r.Method+" "+pattern]; ok {
+			limit, key = l, key+"|"+r.Method+" "+pattern
+		} else if l, ok := cur.routes[pattern]; ok {
+			limit, key = l, key+"|"+pattern
+		}
+		if rl.take(w, r, key, limit) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// IPMiddleware ставится перед Authenticate: ограничивает запросы с одного адреса,
+// в том числе с неверными учётными данными, которые до Middleware не доходят
+func (rl *RateLimiter) IPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := rl.ip.Load()
+		if rl.take(w, r, "ip:"+clientIP(r, ip.trusted), ip.limit) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// take списывает токен и выставляет заголовки; false — ответ 429 уже записан
+func (rl *RateLimiter) take(w http.ResponseWriter, r *http.Request, key string, limit Limit) bool {
+	if limit.RPS <= 0 || limit.Burst <= 0 {
+		return true
+	}
+
+	res, err := rl.store.Take(r.Context(), key, limit)
+	if err != nil {
+		// стор недоступен — не блокируем трафик
+		log.FromContext(r.Context()).Error("rate limit store failed", "err", err)
+		return true
+	}
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	if !res.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate limit exceeded"})
+		return false
+	}
+	return true
+}
+
+// clientKey: API-ключ, иначе пользователь. Middleware стоит после Authenticate,
+// поэтому principal есть всегда; анонимные запросы ограничивает IPMiddleware
+func clientKey(r *http.Request) string {
+	p, _ := auth.FromContext(r.Context())
+	if p.Role == auth.RoleService {
+		return "key:" + p.KeyID.String()
+	}
+	return "user:" + p.UserID.String()
+}
+
+// clientIP — адрес соединения; если он из trusted, X-Forwarded-For читается справа налево
+// до первого недоверенного адреса (левее него значения подставляет сам клиент)
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !isTrusted(peer, trusted) {
+		return host
+	}
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		host = addr.Unmap().String()
+		if !isTrusted(addr, trusted) {
+			break
+		}
+	}
+	return host
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// --- in-process store ---
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // момент, когда bucket снова заполнится; после него запись не нужна
+}
+
+// MemoryRateLimitStore — bucket'ы в памяти процесса, подходит для одной реплики
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, l Limit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.last).Seconds()*l.RPS)
+	b.last = now
+
+	res := RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.tokens) / l.RPS)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = secondsToDuration((float64(l.Burst) - b.tokens) / l.RPS)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// sweep раз в минуту удаляет заполнившиеся bucket'ы — они эквивалентны новым
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for k, b := range s.buckets {
+		if now.After(b.full) {
+			delete(s.buckets, k)
+		}
+	}
+}
+
+func secondsToDuration(sec float64) time.Duration {
+	return time.Duration(sec * float64(time.Second))
+}
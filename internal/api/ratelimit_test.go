@@ -0,0 +1,292 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"subscription-service/internal/auth"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestStore() (*MemoryRateLimitStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryRateLimitStore()
+	s.now = clock.now
+	return s, clock
+}
+
+func TestMemoryRateLimitStoreTake(t *testing.T) {
+	limit := Limit{RPS: 2, Burst: 3}
+	type step struct {
+		advance    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst then reject",
+			steps: []step{
+				{allowed: true, remaining: 2, reset: 500 * time.Millisecond},
+				{allowed: true, remaining: 1, reset: time.Second},
+				{allowed: true, remaining: 0, reset: 1500 * time.Millisecond},
+				{allowed: false, remaining: 0, retryAfter: 500 * time.Millisecond, reset: 1500 * time.Millisecond},
+			},
+		},
+		{
+			name: "partial refill",
+			steps: []step{
+				{allowed: true, remaining: 2, reset: 500 * time.Millisecond},
+				{allowed: true, remaining: 1, reset: time.Second},
+				{allowed: true, remaining: 0, reset: 1500 * time.Millisecond},
+				{advance: 250 * time.Millisecond, allowed: false, remaining: 0, retryAfter: 250 * time.Millisecond, reset: 1250 * time.Millisecond},
+				{advance: 250 * time.Millisecond, allowed: true, remaining: 0, reset: 1500 * time.Millisecond},
+			},
+		},
+		{
+			name: "refill capped at burst",
+			steps: []step{
+				{allowed: true, remaining: 2, reset: 500 * time.Millisecond},
+				{advance: time.Hour, allowed: true, remaining: 2, reset: 500 * time.Millisecond},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, clock := newTestStore()
+			for i, st := range tt.steps {
+				clock.advance(st.advance)
+				res, err := s.Take(context.Background(), "k", limit)
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				want := RateLimitResult{Allowed: st.allowed, Remaining: st.remaining, RetryAfter: st.retryAfter, Reset: st.reset}
+				if res != want {
+					t.Fatalf("step %d: got %+v, want %+v", i, res, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitStoreKeysIndependent(t *testing.T) {
+	s, _ := newTestStore()
+	limit := Limit{RPS: 1, Burst: 1}
+	if res, _ := s.Take(context.Background(), "a", limit); !res.Allowed {
+		t.Fatal("first take for a rejected")
+	}
+	if res, _ := s.Take(context.Background(), "a", limit); res.Allowed {
+		t.Fatal("second take for a allowed")
+	}
+	if res, _ := s.Take(context.Background(), "b", limit); !res.Allowed {
+		t.Fatal("take for b rejected")
+	}
+}
+
+func TestMemoryRateLimitStoreSweep(t *testing.T) {
+	tests := []struct {
+		name    string
+		advance time.Duration
+		kept    bool
+	}{
+		{name: "within sweep interval", advance: 30 * time.Second, kept: true},
+		{name: "refilled after interval", advance: 2 * time.Minute, kept: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, clock := newTestStore()
+			limit := Limit{RPS: 1, Burst: 5}
+			// первый Take тоже запускает sweep и выставляет lastSweep
+			if _, err := s.Take(context.Background(), "old", limit); err != nil {
+				t.Fatal(err)
+			}
+			clock.advance(tt.advance)
+			if _, err := s.Take(context.Background(), "new", limit); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := s.buckets["old"]; ok != tt.kept {
+				t.Fatalf("bucket kept = %v, want %v", ok, tt.kept)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitStoreSweepKeepsDraining(t *testing.T) {
+	s, clock := newTestStore()
+	slow := Limit{RPS: 0.01, Burst: 2} // заполняется за 200 секунд
+	s.Take(context.Background(), "slow", slow)
+	s.Take(context.Background(), "slow", slow)
+	clock.advance(2 * time.Minute)
+	s.Take(context.Background(), "other", slow)
+	if _, ok := s.buckets["slow"]; !ok {
+		t.Fatal("not yet refilled bucket was swept")
+	}
+}
+
+func serveLimited(rl *RateLimiter, mw func(http.Handler) http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.With(mw).Get("/api/v1/subscriptions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimiterHeaders(t *testing.T) {
+	s, clock := newTestStore()
+	rl := NewRateLimiter(s, Limit{RPS: 0.5, Burst: 2}, nil)
+	user := auth.Principal{UserID: uuid.New(), Role: auth.RoleUser}
+	newReq := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/1", nil)
+		return req.WithContext(auth.WithPrincipal(req.Context(), user))
+	}
+
+	tests := []struct {
+		advance    time.Duration
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{status: http.StatusNoContent, remaining: "1", reset: "2"},
+		{status: http.StatusNoContent, remaining: "0", reset: "4"},
+		{status: http.StatusTooManyRequests, remaining: "0", reset: "4", retryAfter: "2"},
+		{advance: 1500 * time.Millisecond, status: http.StatusTooManyRequests, remaining: "0", reset: "3", retryAfter: "1"},
+		{advance: 500 * time.Millisecond, status: http.StatusNoContent, remaining: "0", reset: "4"},
+	}
+	for i, tt := range tests {
+		clock.advance(tt.advance)
+		rec := serveLimited(rl, rl.Middleware, newReq())
+		if rec.Code != tt.status {
+			t.Fatalf("step %d: status %d, want %d", i, rec.Code, tt.status)
+		}
+		h := rec.Header()
+		if got := h.Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("step %d: RateLimit-Limit = %q", i, got)
+		}
+		if got := h.Get("RateLimit-Remaining"); got != tt.remaining {
+			t.Errorf("step %d: RateLimit-Remaining = %q, want %q", i, got, tt.remaining)
+		}
+		if got := h.Get("RateLimit-Reset"); got != tt.reset {
+			t.Errorf("step %d: RateLimit-Reset = %q, want %q", i, got, tt.reset)
+		}
+		if got := h.Get("Retry-After"); got != tt.retryAfter {
+			t.Errorf("step %d: Retry-After = %q, want %q", i, got, tt.retryAfter)
+		}
+	}
+}
+
+func TestRateLimiterRouteBuckets(t *testing.T) {
+	s, _ := newTestStore()
+	rl := NewRateLimiter(s, Limit{RPS: 1, Burst: 5}, map[string]Limit{
+		"GET /api/v1/subscriptions/{id}": {RPS: 1, Burst: 1},
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/1", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{UserID: uuid.New(), Role: auth.RoleUser}))
+
+	if rec := serveLimited(rl, rl.Middleware, req); rec.Code != http.StatusNoContent || rec.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("first request: status %d, limit %q", rec.Code, rec.Header().Get("RateLimit-Limit"))
+	}
+	if rec := serveLimited(rl, rl.Middleware, req); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status %d, want 429", rec.Code)
+	}
+}
+
+func TestRateLimiterIPMiddleware(t *testing.T) {
+	s, _ := newTestStore()
+	rl := NewRateLimiter(s, Limit{}, nil)
+	newReq := func(addr string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/1", nil)
+		req.RemoteAddr = addr
+		return req
+	}
+
+	// без лимита пропускает всё
+	for i := 0; i < 3; i++ {
+		if rec := serveLimited(rl, rl.IPMiddleware, newReq("10.0.0.1:1000")); rec.Code != http.StatusNoContent {
+			t.Fatalf("disabled limiter: status %d", rec.Code)
+		}
+	}
+
+	rl.SetIPLimit(Limit{RPS: 1, Burst: 1}, nil)
+	if rec := serveLimited(rl, rl.IPMiddleware, newReq("10.0.0.1:1000")); rec.Code != http.StatusNoContent {
+		t.Fatalf("first request: status %d", rec.Code)
+	}
+	// другой порт того же адреса — тот же bucket
+	if rec := serveLimited(rl, rl.IPMiddleware, newReq("10.0.0.1:2000")); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("same ip: status %d, want 429", rec.Code)
+	}
+	if rec := serveLimited(rl, rl.IPMiddleware, newReq("10.0.0.2:1000")); rec.Code != http.StatusNoContent {
+		t.Fatalf("other ip: status %d", rec.Code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.1.1/32")}
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{name: "no proxy", remote: "203.0.113.5:1000", want: "203.0.113.5"},
+		{name: "untrusted peer ignores xff", remote: "203.0.113.5:1000", xff: []string{"198.51.100.1"}, want: "203.0.113.5"},
+		{name: "trusted peer", remote: "10.0.0.1:1000", xff: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "spoofed left entries", remote: "10.0.0.1:1000", xff: []string{"1.2.3.4, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "proxy chain", remote: "10.0.0.1:1000", xff: []string{"198.51.100.1, 192.168.1.1", "10.1.1.1"}, want: "198.51.100.1"},
+		{name: "trusted peer without xff", remote: "10.0.0.1:1000", want: "10.0.0.1"},
+		{name: "all hops trusted", remote: "10.0.0.1:1000", xff: []string{"10.2.2.2"}, want: "10.2.2.2"},
+		{name: "garbage stops walk", remote: "10.0.0.1:1000", xff: []string{"198.51.100.1, unknown"}, want: "10.0.0.1"},
+		{name: "ipv4-mapped peer", remote: "[::ffff:10.0.0.1]:1000", xff: []string{"198.51.100.1"}, want: "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if got := clientIP(req, trusted); got != tt.want {
+				t.Fatalf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterIPMiddlewareTrustedProxy(t *testing.T) {
+	s, _ := newTestStore()
+	rl := NewRateLimiter(s, Limit{}, nil)
+	rl.SetIPLimit(Limit{RPS: 1, Burst: 1}, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+	newReq := func(client string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/1", nil)
+		req.RemoteAddr = "10.0.0.1:1000"
+		req.Header.Set("X-Forwarded-For", client)
+		return req
+	}
+
+	// клиенты за одним балансировщиком не делят bucket
+	for _, client := range []string{"198.51.100.1", "198.51.100.2"} {
+		if rec := serveLimited(rl, rl.IPMiddleware, newReq(client)); rec.Code != http.StatusNoContent {
+			t.Fatalf("client %s: status %d", client, rec.Code)
+		}
+	}
+	if rec := serveLimited(rl, rl.IPMiddleware, newReq("198.51.100.1")); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("repeated client: status %d, want 429", rec.Code)
+	}
+}
@@ -7,35 +7,130 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
 
+	"subscription-service/internal/auth"
 	"subscription-service/internal/log"
+	"subscription-service/internal/metrics"
 )
 
-func NewRouter(h *Handlers, logger *log.Logger) *chi.Mux {
+// RouterConfig — обработчики и настройки, из которых собирается роутер
+type RouterConfig struct {
+	Subscriptions *Handlers
+	Users         *UserHandlers
+	Catalog       *CatalogHandlers
+	APIKeys       *APIKeyHandlers
+	Logging       *LoggingHandlers
+	Health        *Health
+	Events        *EventStream
+	Notifications *NotificationHandlers
+	Budgets       *BudgetHandlers
+	Jobs          *JobHandlers
+	GraphQL       http.Handler // nil — /graphql не обслуживается
+	RateLimiter   *RateLimiter
+	CORS          *CORS
+	Logger        *log.Logger
+	JWTSecret     []byte
+	ServeMetrics  bool // /metrics на основном порту
+}
+
+func NewRouter(c RouterConfig) *chi.Mux {
+	h, kh, rl := c.Subscriptions, c.APIKeys, c.RateLimiter
 	r := chi.NewRouter()
 
 	// Middleware
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Logger)
+	r.Use(Tracing)
+	r.Use(RequestLogger(c.Logger))
 	r.Use(middleware.Recoverer)
+	r.Use(Metrics)
+	r.Use(c.CORS.Middleware)
 
-	// Health-check
-	r.Get("/healthz", func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
-	})
+	// Пробы: /livez — процесс жив, /readyz — готов принимать трафик; /healthz оставлен для совместимости
+	r.Get("/livez", c.Health.Live)
+	r.Get("/healthz", c.Health.Live)
+	r.Get("/readyz", c.Health.Ready)
+
+	if c.ServeMetrics {
+		r.Handle("/metrics", metrics.Handler())
+	}
 
 	// Swagger UI
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 
+	// GraphQL для дашбордов: поля-отчёты дополнительно требуют reports:read (проверяются в резолверах)
+	if c.GraphQL != nil {
+		r.With(rl.IPMiddleware, Authenticate(c.JWTSecret, kh.keys), LogRoute, RequireScope(auth.ScopeSubscriptionsRead), rl.Middleware).
+			Handle("/graphql", c.GraphQL)
+	}
+
 	// API v1
 	r.Route("/api/v1", func(r chi.Router) {
+		// лимит по IP до аутентификации — перебор ключей и токенов тоже ограничен
+		r.Use(rl.IPMiddleware)
+		r.Use(Authenticate(c.JWTSecret, kh.keys))
+
+		// scope + лимит на уровне конечного маршрута, где известен его шаблон
+		read := chi.Chain(LogRoute, RequireScope(auth.ScopeSubscriptionsRead), rl.Middleware)
+		write := chi.Chain(LogRoute, RequireScope(auth.ScopeSubscriptionsWrite), rl.Middleware)
+		reports := chi.Chain(LogRoute, RequireScope(auth.ScopeReportsRead), rl.Middleware)
+
 		r.Route("/subscriptions", func(r chi.Router) {
-			r.Post("/", h.Create)
-			r.Get("/", h.List)
-			r.Get("/total", h.Total)
-			r.Get("/{id}", h.GetByID)
-			r.Put("/{id}", h.Update)
-			r.Delete("/{id}", h.Delete)
+			r.With(write...).Post("/", h.Create)
+			r.With(read...).Get("/", h.List)
+			r.With(reports...).Get("/total", h.Total)
+			r.With(reports...).Get("/forecast", h.Forecast)
+			r.With(read...).Get("/events", c.Events.Stream)
+			r.With(read...).Get("/{id}", h.GetByID)
+			r.With(write...).Put("/{id}", h.Update)
+			r.With(write...).Delete("/{id}", h.Delete)
+			r.With(write...).Post("/{id}/tags", h.AddTags)
+			r.With(write...).Delete("/{id}/tags/{tag}", h.RemoveTag)
+			r.With(write...).Post("/{id}/prices", h.SetPrice)
+			r.With(write...).Delete("/{id}/prices/{month}", h.RemovePrice)
+		})
+
+		r.With(read...).Get("/services", c.Catalog.List)
+		r.With(read...).Get("/services/{id}", c.Catalog.Get)
+
+		// список и удаление пользователей — только admin и API-ключи (проверяется в сервисе)
+		r.Route("/users", func(r chi.Router) {
+			r.With(write...).Post("/", c.Users.Create)
+			r.With(read...).Get("/", c.Users.List)
+			r.With(read...).Get("/{id}", c.Users.Get)
+			r.With(write...).Put("/{id}", c.Users.Update)
+			r.With(write...).Delete("/{id}", c.Users.Delete)
+			r.With(read...).Get("/{id}/subscriptions", c.Users.Subscriptions)
+			r.With(reports...).Get("/{id}/total", c.Users.Total)
+		})
+
+		r.Route("/budgets", func(r chi.Router) {
+			r.With(write...).Post("/", c.Budgets.Create)
+			r.With(read...).Get("/", c.Budgets.List)
+			r.With(reports...).Get("/status", c.Budgets.Status)
+			r.With(read...).Get("/{id}", c.Budgets.Get)
+			r.With(write...).Put("/{id}", c.Budgets.Update)
+			r.With(write...).Delete("/{id}", c.Budgets.Delete)
+		})
+
+		r.With(read...).Get("/notification-preferences", c.Notifications.GetPreferences)
+		r.With(write...).Put("/notification-preferences", c.Notifications.UpdatePreferences)
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(RequireAdmin)
+			admin := chi.Chain(LogRoute, rl.Middleware)
+			r.With(admin...).Post("/api-keys", kh.Create)
+			r.With(admin...).Get("/api-keys", kh.List)
+			r.With(admin...).Delete("/api-keys/{id}", kh.Revoke)
+			r.With(admin...).Get("/logging", c.Logging.Get)
+			r.With(admin...).Put("/logging", c.Logging.Update)
+			r.With(admin...).Post("/subscriptions/recalculate-statuses", h.RecalculateStatuses)
+			r.With(admin...).Post("/services", c.Catalog.Create)
+			r.With(admin...).Put("/services/{id}", c.Catalog.Update)
+			r.With(admin...).Delete("/services/{id}", c.Catalog.Delete)
+			r.With(admin...).Post("/services/{id}/merge", c.Catalog.Merge)
+			r.With(admin...).Get("/jobs", c.Jobs.List)
+			r.With(admin...).Get("/jobs/{id}", c.Jobs.Get)
+			r.With(admin...).Post("/jobs/{id}/retry", c.Jobs.Retry)
+			r.With(admin...).Post("/jobs/{id}/cancel", c.Jobs.Cancel)
 		})
 	})
 
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/service"
+)
+
+type UserHandlers struct {
+	users *service.Users
+	subs  *service.Service
+}
+
+func NewUserHandlers(u *service.Users, s *service.Service) *UserHandlers {
+	return &UserHandlers{users: u, subs: s}
+}
+
+// Create godoc
+// @Summary      Создать пользователя
+// @Description  admin и API-ключи создают любого пользователя (id можно не указывать), пользователь — свой профиль с id из токена
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        user body      model.UserCreate  true  "User"
+// @Success      201  {object}  model.User
+// @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/users/ [post]
+func (h *UserHandlers) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.UserCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	u, err := h.users.Create(r.Context(), req)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("user create failed", "err", err)
+		writeError(w, r, err)
+		return
+	}
+	log.FromContext(r.Context()).Info("user created", "user_id", u.ID)
+	writeJSON(w, http.StatusCreated, u)
+}
+
+// List godoc
+// @Summary      Список пользователей
+// @Description  Только для admin и API-ключей
+// @Tags         users
+// @Produce      json
+// @Param        archived  query  bool  false  "Включая архивированных"
+// @Param        limit     query  int   false  "Лимит (до 500)"  default(50)
+// @Param        offset    query  int   false  "Смещение"  default(0)
+// @Success      200  {array}   model.User
+// @Failure      403  {object}  map[string]string
+// @Router       /api/v1/users/ [get]
+func (h *UserHandlers) List(w http.ResponseWriter, r *http.Request) {
+	q := model.UserQuery{
+		Archived: r.URL.Query().Get("archived") == "true",
+		Limit:    min(max(model.ParseInt(r.URL.Query().Get("limit"), 50), 1), 500),
+		Offset:   max(model.ParseInt(r.URL.Query().Get("offset"), 0), 0),
+	}
+	items, err := h.users.List(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// Get godoc
+// @Summary      Получить пользователя
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "UUID пользователя"
+// @Success      200  {object}  model.User
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/users/{id} [get]
+func (h *UserHandlers) Get(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.user(w, r)
+	if ok {
+		writeJSON(w, http.StatusOK, u)
+	}
+}
+
+// Update godoc
+// @Summary      Обновить пользователя
+// @Description  Меняет переданные поля; архивированного пользователя изменить нельзя
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string            true  "UUID пользователя"
+// @Param        user  body      model.UserUpdate  true  "User update"
+// @Success      200  {object}  model.User
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/users/{id} [put]
+func (h *UserHandlers) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	var req model.UserUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	u, err := h.users.Update(r.Context(), id, req)
+	if err != nil {
+		log.FromContext(r.Context()).Warn("user update failed", "user_id", id, "err", err)
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// Delete godoc
+// @Summary      Удалить пользователя
+// @Description  archive — пользователь архивируется, его подписки получают статус archived и закрываются текущим месяцем; cascade — пользователь удаляется вместе с подписками и настройками напоминаний. Только для admin и API-ключей
+// @Tags         users
+// @Param        id    path   string  true   "UUID пользователя"
+// @Param        mode  query  string  false  "archive | cascade"  default(archive)
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/users/{id} [delete]
+func (h *UserHandlers) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	if err := h.users.Delete(r.Context(), id, r.URL.Query().Get("mode")); err != nil {
+		log.FromContext(r.Context()).Warn("user delete failed", "user_id", id, "err", err)
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Subscriptions godoc
+// @Summary      Подписки пользователя
+// @Description  То же, что GET /api/v1/subscriptions/?user_id=, но 404 для несуществующего пользователя
+// @Tags         users
+// @Produce      json
+// @Param        id           path   string  true   "UUID пользователя"
+// @Param        service_name query  string  false  "Название сервиса"
+// @Param        status       query  string  false  "Статус: upcoming | active | expired | archived"
+// @Param        category     query  string  false  "Категория"
+// @Param        tag          query  string  false  "Метка"
+// @Param        limit        query  int     false  "Лимит"  default(50)
+// @Param        offset       query  int     false  "Смещение"  default(0)
+// @Success      200  {array}   model.Subscription
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/users/{id}/subscriptions [get]
+func (h *UserHandlers) Subscriptions(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	items, err := h.subs.List(r.Context(), model.ListQuery{
+		UserID:      u.ID.String(),
+		ServiceName: r.URL.Query().Get("service_name"),
+		Status:      r.URL.Query().Get("status"),
+		Category:    r.URL.Query().Get("category"),
+		Tag:         r.URL.Query().Get("tag"),
+		Limit:       model.ParseInt(r.URL.Query().Get("limit"), 50),
+		Offset:      model.ParseInt(r.URL.Query().Get("offset"), 0),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// Total godoc
+// @Summary      Стоимость подписок пользователя
+// @Description  То же, что GET /api/v1/subscriptions/total?user_id=, но 404 для несуществующего пользователя
+// @Tags         users
+// @Produce      json
+// @Param        id           path   string  true   "UUID пользователя"
+// @Param        service_name query  string  false  "Название сервиса"
+// @Param        category     query  string  false  "Категория"
+// @Param        tag          query  string  false  "Метка"
+// @Param        group_by     query  string  false  "category | tag"
+// @Param        from         query  string  true   "Начало периода (YYYY-MM)"
+// @Param        to           query  string  true   "Конец периода (YYYY-MM)"
+// @Success      200  {object}  map[string]int64
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/users/{id}/total [get]
+func (h *UserHandlers) Total(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.user(w, r)
+	if !ok {
+		return
+	}
+	q, msg := totalQuery(r, u.ID.String())
+	if msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
+	writeTotal(w, r, h.subs, q)
+}
+
+// user загружает пользователя из пути с проверкой доступа; при ошибке ответ уже записан
+func (h *UserHandlers) user(w http.ResponseWriter, r *http.Request) (model.User, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return model.User{}, false
+	}
+	u, err := h.users.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return model.User{}, false
+	}
+	return u, true
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+	// RoleService — сервисный вызов по API-ключу, без пользовательского контекста
+	RoleService Role = "service"
+)
+
+const (
+	ScopeSubscriptionsRead  = "subscriptions:read"
+	ScopeSubscriptionsWrite = "subscriptions:write"
+	ScopeReportsRead        = "reports:read"
+)
+
+// KnownScope сообщает, поддерживается ли scope
+func KnownScope(s string) bool {
+	switch s {
+	case ScopeSubscriptionsRead, ScopeSubscriptionsWrite, ScopeReportsRead:
+		return true
+	}
+	return false
+}
+
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("forbidden")
+)
+
+// Principal — аутентифицированный вызывающий
+type Principal struct {
+	UserID uuid.UUID // uuid.Nil для API-ключей
+	Role   Role
+	KeyID  uuid.UUID // заполнен для RoleService
+	Scopes []string  // ограничивают только API-ключи
+}
+
+func (p Principal) IsAdmin() bool { return p.Role == RoleAdmin }
+
+// Unrestricted — доступ ко всем пользователям (admin и сервисные ключи)
+func (p Principal) Unrestricted() bool { return p.Role == RoleAdmin || p.Role == RoleService }
+
+// HasScope: пользователям по JWT доступны все scopes, ключам — только выданные
+func (p Principal) HasScope(scope string) bool {
+	if p.Role != RoleService {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type ctxKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
+
+type claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// ParseToken проверяет HS256 JWT: sub — UUID пользователя, role — user|admin
+func ParseToken(secret []byte, raw string) (Principal, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(raw, &c, func(*jwt.Token) (any, error) { return secret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return Principal{}, fmt.Errorf("invalid token: %w", err)
+	}
+	uid, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return Principal{}, fmt.Errorf("invalid token subject")
+	}
+	role := Role(c.Role)
+	switch role {
+	case "":
+		role = RoleUser
+	case RoleUser, RoleAdmin:
+	default:
+		return Principal{}, fmt.Errorf("unknown role %q", c.Role)
+	}
+	return Principal{UserID: uid, Role: role}, nil
+}
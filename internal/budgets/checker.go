@@ -0,0 +1,107 @@
+// Package budgets — фоновая проверка бюджетов: предупреждение, когда прогноз трат пользователя
+// за текущий месяц превышает его бюджет
+package budgets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/jobs"
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/notify"
+	"subscription-service/internal/repo"
+)
+
+// Виды задач очереди: проверка бюджетов (по расписанию) и доставка предупреждения по каналу
+const (
+	CheckJob  = "budgets.check"
+	NotifyJob = "budgets.notify"
+)
+
+// Config: Channels — каналы для пользователей без своих настроек напоминаний; Notifiers — по каналам
+type Config struct {
+	Channels  []string
+	Notifiers map[string]notify.Notifier
+	Logger    *log.Logger
+}
+
+// Delivery — payload задачи NotifyJob; адреса берутся из настроек пользователя при доставке
+type Delivery struct {
+	Alert   model.BudgetAlert `json:"alert"`
+	Channel string            `json:"channel"`
+}
+
+// Checker находит превышения и доставляет предупреждения через очередь задач: каждая доставка —
+// отдельная задача со своими повторами. О превышении бюджета в месяце предупреждают один раз
+// (после изменения суммы бюджета — снова).
+type Checker struct {
+	repo  *repo.BudgetsRepo
+	prefs *repo.RemindersRepo
+	queue *jobs.Queue
+	c     Config
+}
+
+func New(r *repo.BudgetsRepo, prefs *repo.RemindersRepo, q *jobs.Queue, c Config) *Checker {
+	return &Checker{repo: r, prefs: prefs, queue: q, c: c}
+}
+
+// Check — один проход (задача CheckJob). Предупреждение отмечается после постановки доставок;
+// если проход прервётся раньше, следующий поставит их снова — UniqueKey не даст дублей.
+func (c *Checker) Check(ctx context.Context) error {
+	alerts, err := c.repo.Exceeded(ctx)
+	if err != nil {
+		return fmt.Errorf("exceeded: %w", err)
+	}
+	for _, a := range alerts {
+		l := c.c.Logger.With("budget_id", a.Budget.ID, "user_id", a.Budget.UserID, "month", a.Month.Format("2006-01"))
+		p, err := c.preferences(ctx, a.Budget.UserID)
+		if err != nil {
+			return fmt.Errorf("preferences: %w", err)
+		}
+		if p.Enabled {
+			for _, ch := range p.Channels {
+				key := fmt.Sprintf("budget:%s:%s:%d:%s", a.Budget.ID, a.Month.Format("2006-01"), a.Budget.Amount, ch)
+				if _, _, err := c.queue.Enqueue(ctx, NotifyJob, Delivery{Alert: a, Channel: ch}, jobs.UniqueKey(key)); err != nil {
+					return fmt.Errorf("enqueue: %w", err)
+				}
+			}
+		}
+		if err := c.repo.MarkAlerted(ctx, a); err != nil {
+			return fmt.Errorf("mark alerted: %w", err)
+		}
+		l.Info("budget exceeded", "amount", a.Budget.Amount, "spent", a.Spent, "notify", p.Enabled)
+	}
+	return nil
+}
+
+// Deliver — обработчик NotifyJob (результаты видны в метрике задач по kind). Нет адреса
+// или канал не настроен — задача сразу failed.
+func (c *Checker) Deliver(ctx context.Context, d Delivery) error {
+	n, ok := c.c.Notifiers[d.Channel]
+	if !ok {
+		return jobs.Permanent(errors.New("channel not configured"))
+	}
+	p, err := c.preferences(ctx, d.Alert.Budget.UserID)
+	if err != nil {
+		return err
+	}
+	d.Alert.Email, d.Alert.WebhookURL = p.Email, p.WebhookURL
+	err = n.NotifyBudget(ctx, d.Alert)
+	if errors.Is(err, notify.ErrNoAddress) {
+		return jobs.Permanent(err)
+	}
+	return err
+}
+
+// preferences — настройки напоминаний пользователя или значения по умолчанию
+func (c *Checker) preferences(ctx context.Context, userID uuid.UUID) (model.NotificationPreferences, error) {
+	p, err := c.prefs.GetPreferences(ctx, userID)
+	if errors.Is(err, repo.ErrPreferencesNotFound) {
+		return model.NotificationPreferences{UserID: userID, Enabled: true, Channels: c.c.Channels}, nil
+	}
+	return p, err
+}
@@ -2,130 +2,560 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Config собирается слоями: значения по умолчанию → YAML (--config) → configs/.env → переменные окружения.
+// Тег env — имя переменной окружения, secret — маскировать при выводе (true — целиком, dsn — пароль в URL),
+// reload — поле применяется без рестарта (см. Reloader).
 type Config struct {
-	AppEnv      string
-	HTTPPort    string
-	DatabaseURL string
+	App       AppConfig       `yaml:"app"`
+	HTTP      HTTPConfig      `yaml:"http"`
+	GRPC      GRPCConfig      `yaml:"grpc"`
+	GraphQL   GraphQLConfig   `yaml:"graphql"`
+	Events    EventsConfig    `yaml:"events"`
+	Jobs      JobsConfig      `yaml:"jobs"`
+	Reminders RemindersConfig `yaml:"reminders"`
+	Catalog   CatalogConfig   `yaml:"catalog"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Auth      AuthConfig      `yaml:"auth"`
+	CORS      CORSConfig      `yaml:"cors"`
+	Log       LogConfig       `yaml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
 }
 
-func MustLoad() *Config {
-	_ = loadDotenvIfExists("configs/.env")
+type AppConfig struct {
+	Env string `yaml:"env" env:"APP_ENV"`
+}
 
-	if fileExists("configs/config.yaml") {
-		return mustLoadYAML("configs/config.yaml")
-	}
+type HTTPConfig struct {
+	Port            string        `yaml:"port" env:"HTTP_PORT"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+	// ShutdownDelay — сколько /readyz отвечает 503 до srv.Shutdown, чтобы балансировщик успел вывести под
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"HTTP_SHUTDOWN_DELAY"`
+	// TLS включается, если заданы оба файла
+	TLSCertFile string `yaml:"tls_cert_file" env:"HTTP_TLS_CERT_FILE"`
+	TLSKeyFile  string `yaml:"tls_key_file" env:"HTTP_TLS_KEY_FILE"`
+}
+
+type DatabaseConfig struct {
+	URL             string        `yaml:"url" env:"DATABASE_URL" secret:"dsn"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	// StatementTimeout — дедлайн каждого запроса (0 — без ограничения)
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+	// ConnectTimeout — сколько ждать БД при старте, повторяя попытки с растущей паузой
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	// MigrationsDir — каталог с миграциями; пусто — встроенные в бинарник
+	MigrationsDir string `yaml:"migrations_dir" env:"DB_MIGRATIONS_DIR"`
+	// AutoMigrate — применять миграции при старте; false, если их гоняет отдельный job (migrate up)
+	AutoMigrate bool `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+}
+
+type AuthConfig struct {
+	JWTSecret string `yaml:"jwt_secret" env:"AUTH_JWT_SECRET" secret:"true"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" reload:"true"`
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS" reload:"true"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" reload:"true"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" reload:"true"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE" reload:"true"`
+}
+
+// LogConfig: уровень debug|info|warn|error, формат text|json, вывод stdout|путь к файлу (ротация).
+// Пустые уровень и формат берутся из App.Env: prod — info/json, иначе debug/text.
+type LogConfig struct {
+	Level            string   `yaml:"level" env:"LOG_LEVEL" reload:"true"`
+	Format           string   `yaml:"format" env:"LOG_FORMAT"`
+	Output           string   `yaml:"output" env:"LOG_OUTPUT"`
+	MaxSizeMB        int      `yaml:"max_size_mb" env:"LOG_FILE_MAX_SIZE_MB"`
+	MaxBackups       int      `yaml:"max_backups" env:"LOG_FILE_MAX_BACKUPS"`
+	MaxAgeDays       int      `yaml:"max_age_days" env:"LOG_FILE_MAX_AGE_DAYS"`
+	DebugSampleEvery int      `yaml:"debug_sample_every" env:"LOG_DEBUG_SAMPLE_EVERY" reload:"true"` // писать 1 из N debug-записей
+	RedactKeys       []string `yaml:"redact_keys" env:"LOG_REDACT_KEYS"`                             // атрибуты, маскируемые в логах
+}
+
+// TracingConfig: экспортёр none|stdout|otlp, OTLP/HTTP endpoint host:port, доля сэмплируемых трейсов
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env:"TRACING_EXPORTER"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" env:"OTEL_EXPORTER_OTLP_INSECURE"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// GRPCConfig: Port — порт gRPC API; пусто — gRPC выключен
+type GRPCConfig struct {
+	Port string `yaml:"port" env:"GRPC_PORT"`
+}
+
+// GraphQLConfig: /graphql и лимиты запроса до выполнения; 0 — без ограничения
+type GraphQLConfig struct {
+	Enabled       bool `yaml:"enabled" env:"GRAPHQL_ENABLED"`
+	MaxDepth      int  `yaml:"max_depth" env:"GRAPHQL_MAX_DEPTH"`
+	MaxComplexity int  `yaml:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY"`
+}
+
+// EventsConfig — SSE-поток изменений. Backend: memory — события этой реплики,
+// postgres — LISTEN/NOTIFY, события всех реплик; BufferSize — сколько последних событий
+// хранится для возобновления по Last-Event-ID; Heartbeat — период комментария-пинга
+type EventsConfig struct {
+	Backend    string        `yaml:"backend" env:"EVENTS_BACKEND"`
+	BufferSize int           `yaml:"buffer_size" env:"EVENTS_BUFFER_SIZE"`
+	Heartbeat  time.Duration `yaml:"heartbeat" env:"EVENTS_HEARTBEAT"`
+}
+
+// JobsConfig — очередь фоновых задач. Enabled=false — реплика только ставит задачи, выполняют
+// их другие. Timeout — дедлайн одной попытки; Retention — сколько хранить завершённые задачи.
+// *Schedule — cron-расписания встроенных задач (5 полей, @daily, @every 1h), пусто — не запускать.
+type JobsConfig struct {
+	Enabled                     bool          `yaml:"enabled" env:"JOBS_ENABLED"`
+	Concurrency                 int           `yaml:"concurrency" env:"JOBS_CONCURRENCY"`
+	PollInterval                time.Duration `yaml:"poll_interval" env:"JOBS_POLL_INTERVAL"`
+	Timeout                     time.Duration `yaml:"timeout" env:"JOBS_TIMEOUT"`
+	Retention                   time.Duration `yaml:"retention" env:"JOBS_RETENTION"`
+	PurgeSchedule               string        `yaml:"purge_schedule" env:"JOBS_PURGE_SCHEDULE"`
+	RecalculateStatusesSchedule string        `yaml:"recalculate_statuses_schedule" env:"JOBS_RECALCULATE_STATUSES_SCHEDULE"`
+	BudgetsCheckSchedule        string        `yaml:"budgets_check_schedule" env:"JOBS_BUDGETS_CHECK_SCHEDULE"`
+}
+
+// CatalogConfig: RejectUnknown — подписки только на сервисы из каталога (по названию или псевдониму)
+type CatalogConfig struct {
+	RejectUnknown bool `yaml:"reject_unknown" env:"CATALOG_REJECT_UNKNOWN"`
+}
+
+// RemindersConfig — напоминания о продлении и окончании подписок: проход раз в Interval
+// задачей очереди (нужен jobs.enabled хотя бы на одной реплике). LeadDays и Channels действуют для пользователей без своих настроек (notification_preferences).
+type RemindersConfig struct {
+	Enabled     bool          `yaml:"enabled" env:"REMINDERS_ENABLED"`
+	Interval    time.Duration `yaml:"interval" env:"REMINDERS_INTERVAL"`
+	LeadDays    int           `yaml:"lead_days" env:"REMINDERS_LEAD_DAYS"`
+	Channels    []string      `yaml:"channels" env:"REMINDERS_CHANNELS"`
+	BatchSize   int           `yaml:"batch_size" env:"REMINDERS_BATCH_SIZE"`
+	MaxAttempts int           `yaml:"max_attempts" env:"REMINDERS_MAX_ATTEMPTS"`
+	SMTP        SMTPConfig    `yaml:"smtp"`
+	Webhook     WebhookConfig `yaml:"webhook"`
+}
+
+// SMTPConfig — канал email; пустой Host — канал выключен
+type SMTPConfig struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     string `yaml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD" secret:"true"`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+}
+
+// WebhookConfig — канал webhook: Secret подписывает тело (X-Signature), пусто — без подписи
+type WebhookConfig struct {
+	Secret  string        `yaml:"secret" env:"WEBHOOK_SECRET" secret:"true"`
+	Timeout time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT"`
+}
+
+// MetricsConfig: Port — отдельный порт для /metrics; пусто — /metrics на HTTP.Port
+type MetricsConfig struct {
+	Port string `yaml:"port" env:"METRICS_PORT"`
+}
+
+// RateLimitConfig: общий лимит на клиента и переопределения по маршрутам; RPS=0 — выключено.
+// IPRPS/IPBurst — лимит на адрес до аутентификации (перебор ключей и токенов), по умолчанию выключен;
+// TrustedProxies — адреса и подсети прокси, за которыми адрес клиента берётся из X-Forwarded-For
+type RateLimitConfig struct {
+	RPS            float64     `yaml:"rps" env:"RATE_LIMIT_RPS" reload:"true"`
+	Burst          int         `yaml:"burst" env:"RATE_LIMIT_BURST" reload:"true"`
+	Routes         RouteLimits `yaml:"routes" env:"RATE_LIMIT_ROUTES" reload:"true"`
+	IPRPS          float64     `yaml:"ip_rps" env:"RATE_LIMIT_IP_RPS" reload:"true"`
+	IPBurst        int         `yaml:"ip_burst" env:"RATE_LIMIT_IP_BURST" reload:"true"`
+	TrustedProxies []string    `yaml:"trusted_proxies" env:"RATE_LIMIT_TRUSTED_PROXIES" reload:"true"`
+}
 
-	cfg := &Config{
-		AppEnv:      getEnv("APP_ENV", "dev"),
-		HTTPPort:    getEnv("HTTP_PORT", "8080"),
-		DatabaseURL: getEnv("DATABASE_URL", ""),
+// Proxies разбирает TrustedProxies: подсети (10.0.0.0/8) и отдельные адреса
+func (c RateLimitConfig) Proxies() ([]netip.Prefix, error) {
+	var res []netip.Prefix
+	for _, s := range c.TrustedProxies {
+		if addr, err := netip.ParseAddr(s); err == nil {
+			res = append(res, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address or CIDR %q", s)
+		}
+		res = append(res, p.Masked())
 	}
-	if cfg.DatabaseURL == "" {
-		panic("DATABASE_URL is required")
+	return res, nil
+}
+
+type RateLimitRule struct {
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+// RouteLimits: в YAML — map, в env — "GET /api/v1/subscriptions/total=1:5,/api/v1/subscriptions/{id}=5:10"
+type RouteLimits map[string]RateLimitRule
+
+func (rl *RouteLimits) SetEnv(s string) error {
+	res := RouteLimits{}
+	for _, item := range splitList(s) {
+		route, rule, ok := strings.Cut(item, "=")
+		rps, burst, ok2 := strings.Cut(rule, ":")
+		if !ok || !ok2 {
+			return fmt.Errorf("invalid item %q, want ROUTE=RPS:BURST", item)
+		}
+		r, err := strconv.ParseFloat(rps, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rps in %q", item)
+		}
+		b, err := strconv.Atoi(burst)
+		if err != nil {
+			return fmt.Errorf("invalid burst in %q", item)
+		}
+		res[strings.TrimSpace(route)] = RateLimitRule{RPS: r, Burst: b}
 	}
-	return cfg
+	*rl = res
+	return nil
 }
 
-type yamlCfg struct {
-	App struct {
-		Env      string `yaml:"env"`
-		HTTPPort string `yaml:"http_port"`
-	} `yaml:"app"`
-	Database struct {
-		URL string `yaml:"url"`
-	} `yaml:"database"`
+const (
+	DefaultPath   = "configs/config.yaml"
+	DefaultDotenv = "configs/.env"
+)
+
+// Default — значения по умолчанию (нижний слой)
+func Default() *Config {
+	return &Config{
+		App: AppConfig{Env: "dev"},
+		HTTP: HTTPConfig{
+			Port:            "8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    20 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+			ShutdownDelay:   5 * time.Second,
+		},
+		GRPC:    GRPCConfig{Port: "50051"},
+		GraphQL: GraphQLConfig{Enabled: true, MaxDepth: 7, MaxComplexity: 1000},
+		Events:  EventsConfig{Backend: "memory", BufferSize: 1000, Heartbeat: 15 * time.Second},
+		Jobs: JobsConfig{
+			Enabled:                     true,
+			Concurrency:                 4,
+			PollInterval:                time.Second,
+			Timeout:                     5 * time.Minute,
+			Retention:                   7 * 24 * time.Hour,
+			PurgeSchedule:               "@daily",
+			RecalculateStatusesSchedule: "5 0 1 * *",
+			BudgetsCheckSchedule:        "@hourly",
+		},
+		Reminders: RemindersConfig{
+			Enabled:     true,
+			Interval:    time.Hour,
+			LeadDays:    3,
+			Channels:    []string{"log"},
+			BatchSize:   100,
+			MaxAttempts: 5,
+			SMTP:        SMTPConfig{Port: "587"},
+			Webhook:     WebhookConfig{Timeout: 10 * time.Second},
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:     16,
+			MaxIdleConns:     16,
+			ConnMaxLifetime:  30 * time.Minute,
+			StatementTimeout: 5 * time.Second,
+			ConnectTimeout:   30 * time.Second,
+			AutoMigrate:      true,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-API-Key", "X-Request-Id"},
+			MaxAge:         10 * time.Minute,
+		},
+		Log: LogConfig{
+			Output:           "stdout",
+			MaxSizeMB:        100,
+			MaxBackups:       5,
+			MaxAgeDays:       14,
+			DebugSampleEvery: 1,
+			RedactKeys:       []string{"user_id", "requested_user_id", "email", "token", "authorization", "api_key"},
+		},
+		Tracing: TracingConfig{
+			Exporter:     "none",
+			OTLPEndpoint: "localhost:4318",
+			OTLPInsecure: true,
+			SampleRatio:  1,
+		},
+		RateLimit: RateLimitConfig{RPS: 10, Burst: 20},
+	}
 }
 
-func mustLoadYAML(path string) *Config {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		panic(err)
+// Load собирает конфиг по слоям и валидирует его. path — YAML-файл (--config);
+// пусто — configs/config.yaml, если он есть. Ошибки валидации возвращаются все сразу.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" && fileExists(DefaultPath) {
+		path = DefaultPath
 	}
-	var yc yamlCfg
-	if err := yaml.Unmarshal(data, &yc); err != nil {
-		panic(err)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+
+	dotenv := map[string]string{}
+	if fileExists(DefaultDotenv) {
+		var err error
+		if dotenv, err = readDotenv(DefaultDotenv); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", DefaultDotenv, err)
+		}
 	}
-	cfg := &Config{
-		AppEnv:      firstNonEmpty(yc.App.Env, "dev"),
-		HTTPPort:    firstNonEmpty(yc.App.HTTPPort, "8080"),
-		DatabaseURL: yc.Database.URL,
+	// Переменные окружения главнее .env
+	lookup := func(k string) (string, bool) {
+		if v, ok := os.LookupEnv(k); ok {
+			return v, true
+		}
+		v, ok := dotenv[k]
+		return v, ok
 	}
-	if cfg.DatabaseURL == "" {
-		panic(errors.New("database.url must be set in config.yaml"))
+	// ошибки разбора env и валидации возвращаются вместе, чтобы исправить всё за один запуск
+	envErr := applyEnv(cfg, lookup)
+	cfg.applyEnvDefaults()
+	if err := errors.Join(envErr, cfg.Validate()); err != nil {
+		return nil, err
 	}
-	return cfg
+	return cfg, nil
 }
 
-func firstNonEmpty(s, def string) string {
-	if s != "" {
-		return s
+// applyEnvDefaults заполняет значения, зависящие от App.Env
+func (c *Config) applyEnvDefaults() {
+	level, format := "debug", "text"
+	if c.App.Env == "prod" {
+		level, format = "info", "json"
 	}
-	return def
+	c.Log.Level = firstNonEmpty(c.Log.Level, level)
+	c.Log.Format = firstNonEmpty(c.Log.Format, format)
 }
 
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
-}
+// Validate проверяет конфиг целиком и возвращает все ошибки через errors.Join
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) { errs = append(errs, fmt.Errorf(format, args...)) }
+
+	validPort := func(name, p string, optional bool) {
+		if p == "" && optional {
+			return
+		}
+		if n, err := strconv.Atoi(p); err != nil || n < 1 || n > 65535 {
+			add("%s: invalid port %q", name, p)
+		}
+	}
+	validPort("http.port", c.HTTP.Port, false)
+	validPort("metrics.port", c.Metrics.Port, true)
+	validPort("grpc.port", c.GRPC.Port, true)
+	if c.Metrics.Port != "" && c.Metrics.Port == c.HTTP.Port {
+		add("metrics.port must differ from http.port")
+	}
+	if c.GRPC.Port != "" && (c.GRPC.Port == c.HTTP.Port || c.GRPC.Port == c.Metrics.Port) {
+		add("grpc.port must differ from http.port and metrics.port")
+	}
+	for name, d := range map[string]time.Duration{
+		"http.read_timeout":     c.HTTP.ReadTimeout,
+		"http.write_timeout":    c.HTTP.WriteTimeout,
+		"http.idle_timeout":     c.HTTP.IdleTimeout,
+		"http.shutdown_timeout": c.HTTP.ShutdownTimeout,
+	} {
+		if d <= 0 {
+			add("%s must be > 0", name)
+		}
+	}
 
-func getEnv(k, def string) string {
-	if v := os.Getenv(k); v != "" {
-		return v
+	if c.HTTP.ShutdownDelay < 0 {
+		add("http.shutdown_delay must be >= 0")
+	}
+	if (c.HTTP.TLSCertFile == "") != (c.HTTP.TLSKeyFile == "") {
+		add("http: tls_cert_file and tls_key_file must be set together")
+	}
+	for _, f := range []string{c.HTTP.TLSCertFile, c.HTTP.TLSKeyFile} {
+		if f != "" && !fileExists(f) {
+			add("http: tls file %q not found", f)
+		}
 	}
-	return def
-}
 
-func loadDotenvIfExists(path string) error {
-	if !fileExists(path) {
-		return nil
+	if c.Database.URL == "" {
+		add("database.url (DATABASE_URL) is required")
+	} else if u, err := url.Parse(c.Database.URL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		add("database.url must be a postgres:// URL")
 	}
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return err
+	if c.Database.MaxOpenConns < 1 {
+		add("database.max_open_conns must be >= 1")
 	}
-	lines := splitLines(string(b))
-	for _, line := range lines {
-		if len(line) == 0 || line[0] == '#' {
-			continue
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		add("database.max_idle_conns must be between 0 and max_open_conns")
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		add("database.conn_max_lifetime must be >= 0")
+	}
+	if c.Database.StatementTimeout < 0 {
+		add("database.statement_timeout must be >= 0")
+	}
+	if c.Database.ConnectTimeout <= 0 {
+		add("database.connect_timeout must be > 0")
+	}
+	if d := c.Database.MigrationsDir; d != "" && !fileExists(d) {
+		add("database.migrations_dir: %q not found", d)
+	}
+
+	if c.GraphQL.MaxDepth < 0 || c.GraphQL.MaxComplexity < 0 {
+		add("graphql: max_depth and max_complexity must be >= 0")
+	}
+
+	if c.Events.Backend != "memory" && c.Events.Backend != "postgres" {
+		add("events.backend: unknown backend %q (memory | postgres)", c.Events.Backend)
+	}
+	if c.Events.BufferSize < 1 {
+		add("events.buffer_size must be >= 1")
+	}
+	if c.Events.Heartbeat <= 0 {
+		add("events.heartbeat must be > 0")
+	}
+
+	if c.Jobs.Concurrency < 1 {
+		add("jobs.concurrency must be >= 1")
+	}
+	if c.Jobs.PollInterval <= 0 || c.Jobs.Timeout <= 0 || c.Jobs.Retention <= 0 {
+		add("jobs: poll_interval, timeout and retention must be > 0")
+	}
+
+	if r := c.Reminders; r.Enabled {
+		if r.Interval < time.Second {
+			add("reminders.interval must be >= 1s")
+		}
+		if r.LeadDays < 1 || r.LeadDays > 28 {
+			add("reminders.lead_days must be between 1 and 28")
+		}
+		if r.BatchSize < 1 || r.MaxAttempts < 1 {
+			add("reminders: batch_size and max_attempts must be >= 1")
 		}
-		kv := splitKV(line)
-		if kv[0] != "" && kv[1] != "" {
-			os.Setenv(kv[0], kv[1])
+		for _, ch := range r.Channels {
+			switch ch {
+			case "log", "webhook":
+			case "email":
+				if r.SMTP.Host == "" {
+					add("reminders.channels: email requires reminders.smtp.host")
+				}
+			default:
+				add("reminders.channels: unknown channel %q (email | webhook | log)", ch)
+			}
+		}
+		if r.SMTP.Host != "" {
+			validPort("reminders.smtp.port", r.SMTP.Port, false)
+			if r.SMTP.From == "" {
+				add("reminders.smtp.from is required with smtp.host")
+			}
+		}
+		if r.Webhook.Timeout <= 0 {
+			add("reminders.webhook.timeout must be > 0")
 		}
 	}
-	return nil
-}
 
-func splitLines(s string) []string {
-	var res []string
-	cur := ""
-	for _, r := range s {
-		if r == '\r' {
+	if c.Auth.JWTSecret == "" {
+		add("auth.jwt_secret (AUTH_JWT_SECRET) is required")
+	} else if c.App.Env == "prod" && len(c.Auth.JWTSecret) < 32 {
+		add("auth.jwt_secret must be at least 32 bytes in prod")
+	}
+
+	for _, o := range c.CORS.AllowedOrigins {
+		if o == "*" {
+			if c.CORS.AllowCredentials {
+				add("cors: allow_credentials cannot be used with origin *")
+			}
 			continue
 		}
-		if r == '\n' {
-			res = append(res, cur)
-			cur = ""
-			continue
+		if u, err := url.Parse(o); err != nil || u.Scheme == "" || u.Host == "" {
+			add("cors.allowed_origins: invalid origin %q", o)
 		}
-		cur += string(r)
 	}
-	if cur != "" {
-		res = append(res, cur)
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		add("log.level: unknown level %q", c.Log.Level)
 	}
-	return res
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		add("log.format: unknown format %q", c.Log.Format)
+	}
+	if c.Log.DebugSampleEvery < 1 {
+		add("log.debug_sample_every must be >= 1")
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.Tracing.OTLPEndpoint == "" {
+			add("tracing.otlp_endpoint is required for otlp exporter")
+		}
+	default:
+		add("tracing.exporter: unknown exporter %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio must be within [0, 1]")
+	}
+
+	if c.RateLimit.RPS < 0 || c.RateLimit.Burst < 0 {
+		add("rate_limit: rps and burst must be >= 0")
+	}
+	if c.RateLimit.IPRPS < 0 || c.RateLimit.IPBurst < 0 {
+		add("rate_limit: ip_rps and ip_burst must be >= 0")
+	}
+	if _, err := c.RateLimit.Proxies(); err != nil {
+		add("rate_limit.trusted_proxies: %v", err)
+	}
+	for route, l := range c.RateLimit.Routes {
+		if l.RPS < 0 || l.Burst < 0 {
+			add("rate_limit.routes[%s]: rps and burst must be >= 0", route)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func firstNonEmpty(s, def string) string {
+	if s != "" {
+		return s
+	}
+	return def
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
-func splitKV(s string) [2]string {
-	for i := 0; i < len(s); i++ {
-		if s[i] == '=' {
-			return [2]string{s[:i], s[i+1:]}
+func splitList(s string) []string {
+	var res []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
 		}
 	}
-	return [2]string{"", ""}
+	return res
 }
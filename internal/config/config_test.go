@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadJoinsEnvAndValidationErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("database:\n  url: postgres://localhost/subs\n  max_open_conns: 0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HTTP_READ_TIMEOUT", "soon")
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("Load succeeded, want error")
+	}
+	for _, want := range []string{"HTTP_READ_TIMEOUT", "database.max_open_conns"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
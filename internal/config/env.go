@@ -0,0 +1,216 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envSetter — для типов со своим форматом в переменной окружения
+type envSetter interface {
+	SetEnv(s string) error
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv проходит по полям с тегом env и перекрывает их найденными значениями
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	var errs []error
+	walk(reflect.ValueOf(cfg).Elem(), func(f reflect.Value, sf reflect.StructField) {
+		name := sf.Tag.Get("env")
+		if name == "" {
+			return
+		}
+		raw, ok := lookup(name)
+		if !ok {
+			return
+		}
+		if err := setField(f, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// walk обходит листовые поля вложенных структур
+func walk(v reflect.Value, fn func(reflect.Value, reflect.StructField)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, sf := v.Field(i), t.Field(i)
+		if f.Kind() == reflect.Struct && sf.Tag.Get("env") == "" {
+			walk(f, fn)
+			continue
+		}
+		fn(f, sf)
+	}
+}
+
+func setField(f reflect.Value, raw string) error {
+	if s, ok := f.Addr().Interface().(envSetter); ok {
+		return s.SetEnv(raw)
+	}
+	if f.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		f.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		f.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", raw)
+		}
+		f.SetBool(b)
+	case reflect.Slice:
+		if f.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", f.Type())
+		}
+		f.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
+
+// readDotenv разбирает .env: комментарии, "export KEY=...", значения в '...' (как есть) и "..." (с \n, \", \\),
+// комментарий после значения без кавычек отделяется " #"
+func readDotenv(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: want KEY=VALUE", n)
+		}
+		v, err := dotenvValue(strings.TrimSpace(val))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		res[key] = v
+	}
+	return res, sc.Err()
+}
+
+func dotenvValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	switch q := s[0]; q {
+	case '\'', '"':
+		end := -1
+		for i := 1; i < len(s); i++ {
+			if q == '"' && s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == q {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return "", errors.New("unterminated quoted value")
+		}
+		if rest := strings.TrimSpace(s[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", errors.New("unexpected text after quoted value")
+		}
+		if q == '\'' {
+			return s[1:end], nil
+		}
+		return strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(s[1:end]), nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// Redacted — действующий конфиг в YAML с замаскированными секретами (для --print-config и логов)
+func (c *Config) Redacted() string {
+	cp := *c
+	walk(reflect.ValueOf(&cp).Elem(), func(f reflect.Value, sf reflect.StructField) {
+		switch sf.Tag.Get("secret") {
+		case "true":
+			if f.String() != "" {
+				f.SetString("****")
+			}
+		case "dsn":
+			f.SetString(maskDSN(f.String()))
+		}
+	})
+	out, err := yaml.Marshal(printable(reflect.ValueOf(cp)))
+	if err != nil {
+		return fmt.Sprintf("config: %v", err)
+	}
+	return string(out)
+}
+
+// printable переводит структуру в map по yaml-тегам, длительности — в строки ("10s" вместо наносекунд)
+func printable(v reflect.Value) any {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	if v.Kind() != reflect.Struct {
+		return v.Interface()
+	}
+	m := yaml.Node{Kind: yaml.MappingNode}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		var val yaml.Node
+		if err := val.Encode(printable(v.Field(i))); err != nil {
+			continue
+		}
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, &val)
+	}
+	return &m
+}
+
+func maskDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil {
+		return dsn
+	}
+	if _, ok := u.User.Password(); !ok {
+		return dsn
+	}
+	u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return strings.Replace(u.String(), ":xxxxx@", ":****@", 1)
+}
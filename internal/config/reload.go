@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"subscription-service/internal/log"
+)
+
+// Reloader перечитывает конфиг по SIGHUP или при изменении файлов, применяет поля с тегом reload
+// и уведомляет подписчиков. Остальные изменения требуют рестарта — о них пишется предупреждение.
+type Reloader struct {
+	path   string
+	logger *log.Logger
+
+	cur  atomic.Pointer[Config]
+	mu   sync.Mutex // сериализует Reload и подписку
+	subs []func(*Config)
+}
+
+func NewReloader(path string, initial *Config, logger *log.Logger) *Reloader {
+	r := &Reloader{path: path, logger: logger}
+	r.cur.Store(initial)
+	return r
+}
+
+// Current — действующий конфиг; не изменять
+func (r *Reloader) Current() *Config { return r.cur.Load() }
+
+// Subscribe регистрирует колбэк, вызываемый после каждого успешного применения изменений
+func (r *Reloader) Subscribe(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = append(r.subs, fn)
+}
+
+// Reload перечитывает слои и атомарно подменяет reload-поля; при ошибке валидации конфиг не меняется
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := Load(r.path)
+	if err != nil {
+		return err
+	}
+	old := r.cur.Load()
+	eff := *old
+	var applied []string
+	diffFields(reflect.ValueOf(&eff).Elem(), reflect.ValueOf(next).Elem(), "",
+		func(name string, dst, src reflect.Value, sf reflect.StructField) {
+			change := fmt.Sprintf("%s: %s -> %s", name, show(dst, sf), show(src, sf))
+			if sf.Tag.Get("reload") != "true" {
+				r.logger.Warn("config change requires restart, ignored", "change", change)
+				return
+			}
+			dst.Set(src)
+			applied = append(applied, change)
+		})
+	if len(applied) == 0 {
+		r.logger.Info("config reloaded, nothing to apply")
+		return nil
+	}
+	r.cur.Store(&eff)
+	r.logger.Info("config reloaded", "changes", strings.Join(applied, "; "))
+	for _, fn := range r.subs {
+		fn(&eff)
+	}
+	return nil
+}
+
+// diffFields вызывает fn для каждого отличающегося листового поля (имена по yaml-тегам)
+func diffFields(dst, src reflect.Value, prefix string, fn func(string, reflect.Value, reflect.Value, reflect.StructField)) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		d, s := dst.Field(i), src.Field(i)
+		if d.Kind() == reflect.Struct && d.Type() != durationType {
+			diffFields(d, s, name, fn)
+			continue
+		}
+		if !reflect.DeepEqual(d.Interface(), s.Interface()) {
+			fn(name, d, s, sf)
+		}
+	}
+}
+
+func show(v reflect.Value, sf reflect.StructField) string {
+	switch sf.Tag.Get("secret") {
+	case "true", "dsn":
+		return "****"
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// Run ждёт SIGHUP или изменения YAML/.env (с устранением дребезга) и вызывает Reload до отмены ctx
+func (r *Reloader) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	files := map[string]bool{}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.logger.Warn("config file watch disabled", "err", err)
+	} else {
+		defer w.Close()
+		yamlPath := r.path
+		if yamlPath == "" {
+			yamlPath = DefaultPath
+		}
+		// Следим за каталогами: редакторы и ConfigMap подменяют файл целиком
+		for _, f := range []string{yamlPath, DefaultDotenv} {
+			abs, _ := filepath.Abs(f)
+			files[abs] = true
+			if err := w.Add(filepath.Dir(abs)); err != nil {
+				r.logger.Warn("config file watch failed", "dir", filepath.Dir(abs), "err", err)
+			}
+		}
+		events = w.Events
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.logger.Info("SIGHUP received, reloading config")
+			r.reload()
+		case ev := <-events:
+			if abs, _ := filepath.Abs(ev.Name); files[abs] {
+				debounce = time.After(500 * time.Millisecond)
+			}
+		case <-debounce:
+			debounce = nil
+			r.logger.Info("config file changed, reloading")
+			r.reload()
+		}
+	}
+}
+
+func (r *Reloader) reload() {
+	if err := r.Reload(); err != nil {
+		r.logger.Error("config reload failed, keeping current config", "err", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Package events — события изменения подписок для SSE: раздача подписчикам и буфер
+// последних событий для возобновления по Last-Event-ID
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"subscription-service/internal/model"
+)
+
+// Типы событий
+const (
+	Created = "created"
+	Updated = "updated"
+	Deleted = "deleted"
+	// Reset — не из потока изменений: пропущенные события восстановить нельзя
+	// (Last-Event-ID вытеснен из буфера, переподключение к Postgres), клиенту нужно перечитать список
+	Reset = "reset"
+)
+
+type Event struct {
+	ID           int64               `json:"id,omitempty"` // у Reset нет
+	Type         string              `json:"type"`
+	Subscription *model.Subscription `json:"subscription,omitempty"` // для deleted — состояние до удаления
+	At           time.Time           `json:"at"`
+}
+
+// Publisher публикует изменение подписки (реализуют Hub и PGPublisher)
+type Publisher interface {
+	Publish(ctx context.Context, typ string, s model.Subscription) error
+}
+
+// subscriberBuffer — сколько событий может ждать медленный клиент, прежде чем его отключат
+// (браузер переподключится с Last-Event-ID и дочитает из буфера)
+const subscriberBuffer = 64
+
+// Hub раздаёт события подписчикам и хранит последние size событий (кольцевой буфер).
+// В режиме memory сам присваивает ID (Publish), в режиме postgres получает готовые события от Listener.
+type Hub struct {
+	mu     sync.Mutex
+	ring   []Event
+	start  int // индекс самого старого события
+	seq    int64
+	subs   map[*Subscriber]struct{}
+	closed bool
+}
+
+func NewHub(size int) *Hub {
+	return &Hub{
+		ring: make([]Event, 0, size),
+		// ID уникальны между перезапусками: Last-Event-ID старого процесса не совпадёт с новым событием
+		seq:  time.Now().UnixMicro(),
+		subs: map[*Subscriber]struct{}{},
+	}
+}
+
+// Subscriber — открытый поток; C закрывается при отключении (Dropped) или остановке хаба
+type Subscriber struct {
+	C       <-chan Event
+	c       chan Event
+	match   func(Event) bool
+	dropped bool
+}
+
+// Dropped — клиент не успевал читать и был отключён; читать после закрытия C
+func (s *Subscriber) Dropped() bool { return s.dropped }
+
+func (h *Hub) Publish(_ context.Context, typ string, s model.Subscription) error {
+	h.mu.Lock()
+	h.seq++
+	id := h.seq
+	h.mu.Unlock()
+	h.Deliver(Event{ID: id, Type: typ, Subscription: &s, At: time.Now().UTC()})
+	return nil
+}
+
+// Deliver добавляет событие с готовым ID в буфер и рассылает подписчикам
+func (h *Hub) Deliver(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	if len(h.ring) < cap(h.ring) {
+		h.ring = append(h.ring, ev)
+	} else if cap(h.ring) > 0 {
+		h.ring[h.start] = ev
+		h.start = (h.start + 1) % len(h.ring)
+	}
+	for s := range h.subs {
+		if !s.match(ev) {
+			continue
+		}
+		select {
+		case s.c <- ev:
+		default:
+			s.dropped = true
+			h.remove(s)
+		}
+	}
+}
+
+// Reset сбрасывает буфер и шлёт всем подписчикам событие Reset
+func (h *Hub) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ring, h.start = h.ring[:0], 0
+	for s := range h.subs {
+		select {
+		case s.c <- Event{Type: Reset, At: time.Now().UTC()}:
+		default:
+			s.dropped = true
+			h.remove(s)
+		}
+	}
+}
+
+// Subscribe регистрирует подписчика с фильтром match. Если задан lastID, возвращает события
+// после него из буфера; gap — lastID в буфере не найден и часть событий потеряна.
+func (h *Hub) Subscribe(lastID int64, match func(Event) bool) (s *Subscriber, replay []Event, gap bool) {
+	c := make(chan Event, subscriberBuffer)
+	s = &Subscriber{C: c, c: c, match: match}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(c)
+		return s, nil, false
+	}
+	if lastID != 0 {
+		// ищем по позиции, а не сравнением ID: в режиме postgres события реплик могут прийти не по порядку
+		found := false
+		for i := range h.ring {
+			ev := h.ring[(h.start+i)%len(h.ring)]
+			if found && match(ev) {
+				replay = append(replay, ev)
+			}
+			if ev.ID == lastID {
+				found = true
+			}
+		}
+		gap = !found
+	}
+	h.subs[s] = struct{}{}
+	return s, replay, gap
+}
+
+// Unsubscribe снимает подписчика (повторный вызов безопасен)
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(s)
+}
+
+// Close закрывает все потоки (при остановке сервера); новые подписки сразу закрыты
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		h.remove(s)
+	}
+}
+
+// remove вызывается под mu
+func (h *Hub) remove(s *Subscriber) {
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.c)
+	}
+}
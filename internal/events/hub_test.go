@@ -0,0 +1,142 @@
+package events
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+)
+
+func all(Event) bool { return true }
+
+func ids(evs []Event) []int64 {
+	res := []int64{}
+	for _, ev := range evs {
+		res = append(res, ev.ID)
+	}
+	return res
+}
+
+func TestHubSubscribeReplay(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	owner := func(id int64) uuid.UUID {
+		if id%2 == 0 {
+			return bob
+		}
+		return alice
+	}
+	tests := []struct {
+		name      string
+		size      int
+		delivered []int64 // ID в порядке прихода
+		lastID    int64
+		match     func(Event) bool
+		replay    []int64
+		gap       bool
+	}{
+		{name: "no last id", size: 4, delivered: []int64{1, 2, 3}, replay: []int64{}},
+		{name: "after last id", size: 4, delivered: []int64{1, 2, 3}, lastID: 1, replay: []int64{2, 3}},
+		{name: "up to date", size: 4, delivered: []int64{1, 2, 3}, lastID: 3, replay: []int64{}},
+		{name: "ring wrapped", size: 3, delivered: []int64{1, 2, 3, 4, 5}, lastID: 3, replay: []int64{4, 5}},
+		{name: "evicted from ring", size: 3, delivered: []int64{1, 2, 3, 4, 5}, lastID: 2, replay: []int64{}, gap: true},
+		{name: "unknown id", size: 4, delivered: []int64{1, 2}, lastID: 99, replay: []int64{}, gap: true},
+		{name: "empty buffer", size: 4, lastID: 1, replay: []int64{}, gap: true},
+		// события реплик приходят не по порядку ID: важна позиция в буфере
+		{name: "out of order", size: 4, delivered: []int64{10, 12, 11, 13}, lastID: 12, replay: []int64{11, 13}},
+		{
+			name: "filtered", size: 8, delivered: []int64{1, 2, 3, 4, 5}, lastID: 1, replay: []int64{3, 5},
+			match: func(ev Event) bool { return ev.Subscription.UserID == alice },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHub(tt.size)
+			for _, id := range tt.delivered {
+				h.Deliver(Event{ID: id, Type: Updated, Subscription: &model.Subscription{UserID: owner(id)}})
+			}
+			match := tt.match
+			if match == nil {
+				match = all
+			}
+			_, replay, gap := h.Subscribe(tt.lastID, match)
+			if got := ids(replay); !reflect.DeepEqual(got, tt.replay) || gap != tt.gap {
+				t.Fatalf("replay %v, gap %v; want %v, gap %v", got, gap, tt.replay, tt.gap)
+			}
+		})
+	}
+}
+
+func TestHubPublishAndFilter(t *testing.T) {
+	h := NewHub(8)
+	alice := uuid.New()
+	mine, _, _ := h.Subscribe(0, func(ev Event) bool { return ev.Subscription.UserID == alice })
+	everyone, _, _ := h.Subscribe(0, all)
+
+	ctx := context.Background()
+	h.Publish(ctx, Created, model.Subscription{UserID: uuid.New()})
+	h.Publish(ctx, Deleted, model.Subscription{UserID: alice, ServiceName: "Netflix"})
+
+	first, second := <-everyone.C, <-everyone.C
+	if second.ID != first.ID+1 || first.Type != Created || second.Type != Deleted {
+		t.Fatalf("everyone got %+v, %+v", first, second)
+	}
+	ev := <-mine.C
+	if ev.ID != second.ID || ev.Subscription.ServiceName != "Netflix" {
+		t.Fatalf("filtered subscriber got %+v", ev)
+	}
+	if len(mine.C) != 0 {
+		t.Fatalf("filtered subscriber has %d extra events", len(mine.C))
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	h := NewHub(subscriberBuffer * 2)
+	slow, _, _ := h.Subscribe(0, all)
+	for i := range subscriberBuffer + 1 {
+		h.Deliver(Event{ID: int64(i + 1), Type: Updated})
+	}
+	n := 0
+	for range slow.C {
+		n++
+	}
+	if n != subscriberBuffer || !slow.Dropped() {
+		t.Fatalf("read %d events, dropped %v; want %d and dropped", n, slow.Dropped(), subscriberBuffer)
+	}
+	// после отключения клиент дочитывает пропущенное по Last-Event-ID
+	_, replay, gap := h.Subscribe(int64(n), all)
+	if gap || len(replay) != 1 || replay[0].ID != int64(subscriberBuffer+1) {
+		t.Fatalf("resume: replay %v, gap %v", ids(replay), gap)
+	}
+	h.Unsubscribe(slow) // повторный вызов безопасен
+}
+
+func TestHubReset(t *testing.T) {
+	h := NewHub(4)
+	h.Deliver(Event{ID: 1, Type: Created})
+	s, _, _ := h.Subscribe(0, all)
+	h.Reset()
+	if ev := <-s.C; ev.Type != Reset || ev.ID != 0 {
+		t.Fatalf("got %+v, want reset without id", ev)
+	}
+	// буфер очищен: старый Last-Event-ID больше не найти
+	if _, _, gap := h.Subscribe(1, all); !gap {
+		t.Fatal("last id found after Reset")
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	h := NewHub(4)
+	s, _, _ := h.Subscribe(0, all)
+	h.Close()
+	if _, ok := <-s.C; ok || s.Dropped() {
+		t.Fatal("subscriber not closed on Close")
+	}
+	h.Deliver(Event{ID: 1, Type: Created})
+	late, replay, _ := h.Subscribe(0, all)
+	if _, ok := <-late.C; ok || len(replay) != 0 {
+		t.Fatal("subscription after Close is open")
+	}
+}
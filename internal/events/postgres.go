@@ -0,0 +1,73 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+)
+
+// PGPublisher публикует события через NOTIFY: их получают Listener-ы всех реплик
+// (включая эту), поэтому Last-Event-ID работает на любой реплике
+type PGPublisher struct {
+	repo *repo.EventsRepo
+}
+
+func NewPGPublisher(r *repo.EventsRepo) *PGPublisher { return &PGPublisher{repo: r} }
+
+func (p *PGPublisher) Publish(ctx context.Context, typ string, s model.Subscription) error {
+	payload, err := json.Marshal(Event{Type: typ, Subscription: &s, At: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	_, err = p.repo.Notify(ctx, payload)
+	return err
+}
+
+// Listener слушает канал NOTIFY и передаёт события в Hub; при обрыве переподключается
+// с растущей паузой, а после переподключения шлёт клиентам Reset (события за время обрыва потеряны)
+type Listener struct {
+	repo   *repo.EventsRepo
+	hub    *Hub
+	logger *log.Logger
+}
+
+func NewListener(r *repo.EventsRepo, hub *Hub, logger *log.Logger) *Listener {
+	return &Listener{repo: r, hub: hub, logger: logger}
+}
+
+// Run работает до отмены ctx
+func (l *Listener) Run(ctx context.Context) {
+	backoff := 250 * time.Millisecond
+	connected := false
+	for {
+		err := l.repo.Listen(ctx, func() {
+			if connected {
+				l.hub.Reset()
+			}
+			connected = true
+			backoff = 250 * time.Millisecond
+			l.logger.Info("events listener connected", "channel", repo.EventsChannel)
+		}, func(payload []byte) {
+			var ev Event
+			if err := json.Unmarshal(payload, &ev); err != nil {
+				l.logger.Warn("invalid event payload", "err", err)
+				return
+			}
+			l.hub.Deliver(ev)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		l.logger.Warn("events listener disconnected, retrying", "retry_in", backoff.String(), "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 5*time.Second)
+	}
+}
@@ -0,0 +1,159 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/log"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/service"
+)
+
+// maxBodyBytes — предел размера запроса
+const maxBodyBytes = 1 << 20
+
+// Handler обслуживает /graphql: POST {"query", "operationName", "variables"} или GET ?query=...
+// Ответ всегда {"data", "errors"}; у ошибок extensions.code — UNAUTHENTICATED, FORBIDDEN,
+// NOT_FOUND, TIMEOUT, QUERY_TOO_COMPLEX или BAD_REQUEST.
+type Handler struct {
+	svc    *service.Service
+	schema graphql.Schema
+	limits Limits
+}
+
+func NewHandler(svc *service.Service, limits Limits) (*Handler, error) {
+	schema, err := newSchema(svc)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{svc: svc, schema: schema, limits: limits}, nil
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&req); err != nil {
+			writeErrors(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeErrors(w, http.StatusBadRequest, "invalid variables")
+				return
+			}
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeErrors(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if req.Query == "" {
+		writeErrors(w, http.StatusBadRequest, "query required")
+		return
+	}
+
+	res, loader := h.execute(r.Context(), req)
+	l := log.FromContext(r.Context())
+	for i := range res.Errors {
+		code := errorCode(res.Errors[i])
+		if res.Errors[i].Extensions == nil {
+			res.Errors[i].Extensions = map[string]any{}
+		}
+		res.Errors[i].Extensions["code"] = code
+		l.Warn("graphql error", "code", code, "path", res.Errors[i].Path, "err", res.Errors[i].Message)
+	}
+	if loader != nil {
+		l.Debug("graphql executed", "operation", req.OperationName, "loader_batches", loader.batches)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// execute: разбор → валидация по схеме → лимиты → выполнение с dataloader в контексте
+func (h *Handler) execute(ctx context.Context, req request) (*graphql.Result, *subsLoader) {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, nil
+	}
+	if v := graphql.ValidateDocument(&h.schema, doc, nil); !v.IsValid {
+		return &graphql.Result{Errors: v.Errors}, nil
+	}
+	if err := checkLimits(h.schema, doc, req.OperationName, req.Variables, h.limits); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(limitError{err})}, nil
+	}
+	loader := newSubsLoader(h.svc)
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoader(ctx, loader),
+	}), loader
+}
+
+type limitError struct{ error }
+
+// errorCode — аналог api.errStatus: код по исходной ошибке резолвера
+func errorCode(fe gqlerrors.FormattedError) string {
+	err := cause(fe)
+	var le limitError
+	switch {
+	case err == nil:
+		return "BAD_REQUEST" // синтаксис и валидация
+	case errors.As(err, &le):
+		return "QUERY_TOO_COMPLEX"
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return "UNAUTHENTICATED"
+	case errors.Is(err, auth.ErrForbidden):
+		return "FORBIDDEN"
+	case errors.Is(err, repo.ErrNotFound):
+		return "NOT_FOUND"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "TIMEOUT" // сработал statement timeout
+	}
+	return "BAD_REQUEST"
+}
+
+// cause снимает обёртки graphql-go (FormattedError ↔ *gqlerrors.Error) до ошибки резолвера
+func cause(err error) error {
+	for err != nil {
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func writeErrors(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]any{{"message": msg, "extensions": map[string]string{"code": "BAD_REQUEST"}}},
+	})
+}
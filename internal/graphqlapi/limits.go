@@ -0,0 +1,167 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits ограничивают запрос до выполнения; 0 — без ограничения
+type Limits struct {
+	MaxDepth      int // вложенность полей, поле верхнего уровня — 1
+	MaxComplexity int // оценка числа резолвов, см. complexity
+}
+
+// checkLimits считает глубину и сложность выбранной операции. Поля интроспекции (__schema,
+// __type, ...) не учитываются, чтобы GraphiQL и генераторы клиентов работали при любых лимитах.
+func checkLimits(schema graphql.Schema, doc *ast.Document, opName string, vars map[string]any, l Limits) error {
+	var op *ast.OperationDefinition
+	frags := map[string]*ast.FragmentDefinition{}
+	for _, d := range doc.Definitions {
+		switch d := d.(type) {
+		case *ast.OperationDefinition:
+			if op == nil && (opName == "" || d.Name != nil && d.Name.Value == opName) {
+				op = d
+			}
+		case *ast.FragmentDefinition:
+			frags[d.Name.Value] = d
+		}
+	}
+	if op == nil {
+		return nil // ошибку вернёт Execute
+	}
+	root := schema.QueryType()
+	if op.Operation != ast.OperationTypeQuery {
+		return fmt.Errorf("only queries are supported")
+	}
+
+	w := walker{schema: schema, frags: frags, vars: vars, visiting: map[string]bool{}}
+	depth, cost := w.selections(root, op.SelectionSet, 1)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds limit %d", depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && cost > l.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds limit %d", cost, l.MaxComplexity)
+	}
+	return nil
+}
+
+type walker struct {
+	schema   graphql.Schema
+	frags    map[string]*ast.FragmentDefinition
+	vars     map[string]any
+	visiting map[string]bool // защита от циклов фрагментов (их отвергает валидация, но лимиты считаются раньше)
+}
+
+// selections возвращает глубину и сложность набора полей, level — уровень его полей.
+// Сложность поля — 1 + сложность вложенных полей, для списков умноженная на ожидаемую длину.
+func (w *walker) selections(parent *graphql.Object, set *ast.SelectionSet, level int) (depth, cost int) {
+	if set == nil || parent == nil {
+		return 0, 0
+	}
+	add := func(d, c int) {
+		depth = max(depth, d)
+		cost += c
+	}
+	for _, sel := range set.Selections {
+		switch s := sel.(type) {
+		case *ast.Field:
+			name := s.Name.Value
+			if strings.HasPrefix(name, "__") {
+				continue
+			}
+			def, ok := parent.Fields()[name]
+			if !ok {
+				continue
+			}
+			child, list := unwrap(def.Type)
+			d, c := w.selections(child, s.SelectionSet, level+1)
+			if list {
+				c = mulCap(c, w.listSize(s))
+			}
+			add(max(level, d), 1+c)
+		case *ast.InlineFragment:
+			t := parent
+			if s.TypeCondition != nil {
+				t, _ = w.schema.Type(s.TypeCondition.Name.Value).(*graphql.Object)
+			}
+			add(w.selections(t, s.SelectionSet, level))
+		case *ast.FragmentSpread:
+			f := w.frags[s.Name.Value]
+			if f == nil || w.visiting[f.Name.Value] {
+				continue
+			}
+			w.visiting[f.Name.Value] = true
+			t, _ := w.schema.Type(f.TypeCondition.Name.Value).(*graphql.Object)
+			add(w.selections(t, f.SelectionSet, level))
+			delete(w.visiting, f.Name.Value)
+		}
+	}
+	return depth, cost
+}
+
+// listSize — ожидаемая длина списка: аргумент limit, число ids или defaultListSize
+func (w *walker) listSize(f *ast.Field) int {
+	for _, a := range f.Arguments {
+		v := a.Value
+		if vr, ok := v.(*ast.Variable); ok {
+			switch x := w.vars[vr.Name.Value].(type) {
+			case float64: // JSON-число
+				if a.Name.Value == "limit" {
+					return int(x)
+				}
+			case []any:
+				if a.Name.Value == "ids" {
+					return len(x)
+				}
+			}
+			continue
+		}
+		switch a.Name.Value {
+		case "limit":
+			if iv, ok := v.(*ast.IntValue); ok {
+				if n, err := strconv.Atoi(iv.Value); err == nil {
+					return n
+				}
+			}
+		case "ids":
+			if lv, ok := v.(*ast.ListValue); ok {
+				return len(lv.Values)
+			}
+		}
+	}
+	return defaultListSize
+}
+
+// unwrap снимает NonNull/List и сообщает, был ли список
+func unwrap(t graphql.Output) (*graphql.Object, bool) {
+	list := false
+	for {
+		switch x := t.(type) {
+		case *graphql.NonNull:
+			t = x.OfType
+		case *graphql.List:
+			list = true
+			t = x.OfType
+		case *graphql.Object:
+			return x, list
+		default:
+			return nil, list
+		}
+	}
+}
+
+// mulCap умножает без переполнения (огромный limit не должен «обнулить» сложность)
+func mulCap(a, b int) int {
+	const limit = 1 << 30
+	if b <= 0 {
+		return 0
+	}
+	if a > limit/b {
+		return limit
+	}
+	return a * b
+}
@@ -0,0 +1,121 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+
+	"subscription-service/internal/service"
+)
+
+func TestCheckLimits(t *testing.T) {
+	schema, err := newSchema(&service.Service{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		query       string
+		op          string
+		vars        map[string]any
+		depth, cost int
+	}{
+		{name: "flat", query: `{ me { id } }`, depth: 2, cost: 2},
+		{name: "list without limit", query: `{ me { subscriptions { id serviceName } } }`, depth: 3, cost: 1 + 1 + 2*defaultListSize},
+		{name: "limit argument", query: `{ subscriptions(limit: 5) { id user { id } } }`, depth: 3, cost: 1 + 5*3},
+		{name: "limit variable", query: `query($n: Int) { subscriptions(limit: $n) { id } }`, vars: map[string]any{"n": float64(100)}, depth: 2, cost: 101},
+		{name: "ids argument", query: `{ users(ids: ["a", "b", "c"]) { id } }`, depth: 2, cost: 4},
+		{name: "ids variable", query: `query($ids: [ID!]!) { users(ids: $ids) { id } }`, vars: map[string]any{"ids": []any{"a", "b"}}, depth: 2, cost: 3},
+		{name: "fragment spread", query: `{ me { ...F } } fragment F on User { id monthlyCost }`, depth: 2, cost: 3},
+		{name: "inline fragment", query: `{ subscription(id: "x") { ... on Subscription { id } } }`, depth: 2, cost: 2},
+		{name: "introspection is free", query: `{ __typename __schema { types { name } } me { id } }`, depth: 2, cost: 2},
+		{
+			name:  "nested lists multiply",
+			query: `{ me { subscriptions { user { subscriptions { user { id } } } } } }`,
+			depth: 6, cost: 1 + 1 + defaultListSize*(1+1+defaultListSize*2),
+		},
+		{name: "huge limit is capped", query: `{ subscriptions(limit: 2000000000) { id } }`, depth: 2, cost: 1<<30 + 1},
+		{name: "selected operation", query: `query A { me { id } } query B { me { subscriptions { id } } }`, op: "B", depth: 3, cost: 2 + defaultListSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			check := func(l Limits) error { return checkLimits(schema, doc, tt.op, tt.vars, l) }
+			if err := check(Limits{MaxDepth: tt.depth, MaxComplexity: tt.cost}); err != nil {
+				t.Fatalf("at limit: %v", err)
+			}
+			want := fmt.Sprintf("query depth %d exceeds limit %d", tt.depth, tt.depth-1)
+			if err := check(Limits{MaxDepth: tt.depth - 1}); err == nil || err.Error() != want {
+				t.Fatalf("depth: err = %v, want %q", err, want)
+			}
+			want = fmt.Sprintf("query complexity %d exceeds limit %d", tt.cost, tt.cost-1)
+			if err := check(Limits{MaxComplexity: tt.cost - 1}); err == nil || err.Error() != want {
+				t.Fatalf("complexity: err = %v, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestCheckLimitsMutation(t *testing.T) {
+	schema, err := newSchema(&service.Service{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := parser.Parse(parser.ParseParams{Source: `mutation { me { id } }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkLimits(schema, doc, "", nil, Limits{}); err == nil {
+		t.Fatal("mutation accepted")
+	}
+}
+
+func TestHandlerLimits(t *testing.T) {
+	h, err := NewHandler(&service.Service{}, Limits{MaxDepth: 2, MaxComplexity: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		query string
+		code  string // "" — без ошибок
+	}{
+		{name: "too deep", query: `{ me { subscriptions { id } } }`, code: "QUERY_TOO_COMPLEX"},
+		{name: "too complex", query: `{ subscriptions(limit: 50) { id } }`, code: "QUERY_TOO_COMPLEX"},
+		{name: "invalid query is checked first", query: `{ me { nope { id } } }`, code: "BAD_REQUEST"},
+		{name: "introspection ignores limits", query: `{ __schema { queryType { fields { name type { name } } } } }`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(request{Query: tt.query})
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+
+			var res struct {
+				Data   map[string]any `json:"data"`
+				Errors []struct {
+					Extensions map[string]any `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tt.code == "" && (len(res.Errors) != 0 || res.Data == nil):
+				t.Fatalf("got errors %+v, want data", res.Errors)
+			case tt.code != "" && (len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != tt.code):
+				t.Fatalf("errors = %+v, want %s", res.Errors, tt.code)
+			case tt.code != "" && res.Data != nil:
+				t.Fatalf("data = %v, want none", res.Data)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+	"subscription-service/internal/service"
+)
+
+// subsLoader — dataloader подписок по пользователю на время одного запроса. Load только
+// запоминает ключ и возвращает thunk; graphql-go вызывает thunks после обхода уровня,
+// поэтому первый вызов загружает подписки всех накопленных пользователей одним запросом.
+type subsLoader struct {
+	svc *service.Service
+
+	mu      sync.Mutex
+	pending []uuid.UUID
+	loaded  map[uuid.UUID][]model.Subscription
+	errs    map[uuid.UUID]error
+	batches int // число запросов к сервису, для лога
+}
+
+func newSubsLoader(svc *service.Service) *subsLoader {
+	return &subsLoader{svc: svc, loaded: map[uuid.UUID][]model.Subscription{}, errs: map[uuid.UUID]error{}}
+}
+
+func (l *subsLoader) Load(ctx context.Context, id uuid.UUID) func() ([]model.Subscription, error) {
+	l.mu.Lock()
+	if !l.done(id) {
+		l.pending = append(l.pending, id)
+	}
+	l.mu.Unlock()
+
+	return func() ([]model.Subscription, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !l.done(id) {
+			l.flush(ctx)
+		}
+		return l.loaded[id], l.errs[id]
+	}
+}
+
+func (l *subsLoader) done(id uuid.UUID) bool {
+	_, ok := l.loaded[id]
+	return ok || l.errs[id] != nil
+}
+
+// flush загружает всех ожидающих пользователей; вызывается под mu
+func (l *subsLoader) flush(ctx context.Context) {
+	seen := make(map[uuid.UUID]bool, len(l.pending))
+	var ids []uuid.UUID
+	for _, id := range l.pending {
+		if !seen[id] && !l.done(id) {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	l.pending = nil
+	if len(ids) == 0 {
+		return
+	}
+	l.batches++
+	res, err := l.svc.ListByUsers(ctx, ids)
+	for _, id := range ids {
+		if err != nil {
+			l.errs[id] = err
+			continue
+		}
+		l.loaded[id] = res[id] // nil — подписок нет
+	}
+}
+
+type loaderKey struct{}
+
+func withLoader(ctx context.Context, l *subsLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+func loaderFrom(ctx context.Context) *subsLoader {
+	return ctx.Value(loaderKey{}).(*subsLoader)
+}
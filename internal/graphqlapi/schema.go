@@ -0,0 +1,414 @@
+// Package graphqlapi — GraphQL-эндпоинт для дашбордов поверх service.Service
+package graphqlapi
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/model"
+	"subscription-service/internal/service"
+)
+
+// defaultListSize — оценка длины списка без limit/ids для расчёта сложности
+const defaultListSize = 20
+
+// user — узел User; данные пользователя (подписки) грузятся через subsLoader
+type user struct {
+	ID uuid.UUID
+}
+
+// serviceCost — строка costByService
+type serviceCost struct {
+	ServiceName string
+	Amount      int64
+}
+
+// newSchema собирает схему:
+//
+//	type Query {
+//	  me: User!
+//	  user(id: ID!): User!
+//	  users(ids: [ID!]!): [User!]!
+//	  subscription(id: ID!): Subscription
+//	  subscriptions(userId: ID, serviceName: String, status: String, category: String, tag: String, limit: Int = 50, offset: Int = 0): [Subscription!]!
+//	}
+//	type User { id, subscriptions(serviceName, status, category, tag), monthlyCost, totalCost(from, to, serviceName), costByService(from, to) }
+//	type Subscription { id, serviceName, price, billingPeriod, priceChanges, userId, user, startDate, endDate, status, category, customCategory, tags, createdAt, updatedAt, monthlyCost, totalCost(from, to) }
+func newSchema(svc *service.Service) (graphql.Schema, error) {
+	periodArgs := graphql.FieldConfigArgument{
+		"from": {Type: graphql.NewNonNull(graphql.String), Description: "YYYY-MM"},
+		"to":   {Type: graphql.NewNonNull(graphql.String), Description: "YYYY-MM"},
+	}
+
+	priceChangeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PriceChange",
+		Fields: graphql.Fields{
+			"month": {Type: graphql.NewNonNull(graphql.String), Description: "YYYY-MM", Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(model.PriceChange).Month.Format("2006-01"), nil
+			}},
+			"price": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(model.PriceChange).Price, nil
+			}},
+		},
+	})
+
+	serviceCostType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ServiceCost",
+		Fields: graphql.Fields{
+			"serviceName": {Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(serviceCost).ServiceName, nil
+			}},
+			"amount": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(serviceCost).Amount, nil
+			}},
+		},
+	})
+
+	var userType *graphql.Object
+	subscriptionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			sub := func(p graphql.ResolveParams) model.Subscription { return p.Source.(model.Subscription) }
+			return graphql.Fields{
+				"id": {Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (any, error) {
+					return sub(p).ID.String(), nil
+				}},
+				"serviceName": {Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+					return sub(p).ServiceName, nil
+				}},
+				"price": {Type: graphql.NewNonNull(graphql.Int), Description: "Стоимость в рублях за период оплаты (до изменений из priceChanges)",
+					Resolve: func(p graphql.ResolveParams) (any, error) { return sub(p).Price, nil }},
+				"billingPeriod": {Type: graphql.NewNonNull(graphql.Int), Description: "Месяцев между списаниями",
+					Resolve: func(p graphql.ResolveParams) (any, error) { return sub(p).BillingPeriod, nil }},
+				"priceChanges": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(priceChangeType))),
+					Description: "Запланированные изменения цены по возрастанию месяца",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						if c := sub(p).PriceChanges; c != nil {
+							return c, nil
+						}
+						return []model.PriceChange{}, nil
+					}},
+				"userId": {Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (any, error) {
+					return sub(p).UserID.String(), nil
+				}},
+				"user": {Type: graphql.NewNonNull(userType), Resolve: func(p graphql.ResolveParams) (any, error) {
+					return user{ID: sub(p).UserID}, nil
+				}},
+				"startDate": {Type: graphql.NewNonNull(graphql.String), Description: "YYYY-MM",
+					Resolve: func(p graphql.ResolveParams) (any, error) { return sub(p).StartDate.Format("2006-01"), nil }},
+				"endDate": {Type: graphql.String, Description: "YYYY-MM",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						if e := sub(p).EndDate; e != nil {
+							return e.Format("2006-01"), nil
+						}
+						return nil, nil
+					}},
+				"status": {Type: graphql.NewNonNull(graphql.String), Description: "upcoming | active | expired | archived",
+					Resolve: func(p graphql.ResolveParams) (any, error) { return sub(p).Status, nil }},
+				"category": {Type: graphql.String, Description: "Своя категория или категория сервиса из каталога",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						if c := sub(p).Category; c != "" {
+							return c, nil
+						}
+						return nil, nil
+					}},
+				"customCategory": {Type: graphql.NewNonNull(graphql.Boolean), Description: "Категория задана для подписки, а не взята из каталога",
+					Resolve: func(p graphql.ResolveParams) (any, error) { return sub(p).CustomCategory, nil }},
+				"tags": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						if t := sub(p).Tags; t != nil {
+							return t, nil
+						}
+						return []string{}, nil
+					}},
+				"createdAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (any, error) {
+					return sub(p).CreatedAt, nil
+				}},
+				"updatedAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (any, error) {
+					return sub(p).UpdatedAt, nil
+				}},
+				"monthlyCost": {Type: graphql.NewNonNull(graphql.Int), Description: "Списание в текущем месяце (0, если подписка не активна или списания нет)",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						now := time.Now()
+						return model.CostInPeriod(sub(p), now, now), nil
+					}},
+				"totalCost": {Type: graphql.NewNonNull(graphql.Int), Description: "Стоимость за месяцы [from..to]", Args: periodArgs,
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						if err := requireScope(p, auth.ScopeReportsRead); err != nil {
+							return nil, err
+						}
+						from, to, err := period(p.Args)
+						if err != nil {
+							return nil, err
+						}
+						return model.CostInPeriod(sub(p), from, to), nil
+					}},
+			}
+		}),
+	})
+
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id": {Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(user).ID.String(), nil
+			}},
+			"subscriptions": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(subscriptionType))),
+				Args: graphql.FieldConfigArgument{
+					"serviceName": {Type: graphql.String},
+					"status":      {Type: graphql.String, Description: "upcoming | active | expired | archived"},
+					"category":    {Type: graphql.String},
+					"tag":         {Type: graphql.String},
+				},
+				Resolve: userSubscriptions("", func(p graphql.ResolveParams, subs []model.Subscription) (any, error) {
+					name, _ := p.Args["serviceName"].(string)
+					name, err := svc.CanonicalServiceName(p.Context, name)
+					if err != nil {
+						return nil, err
+					}
+					status, _ := p.Args["status"].(string)
+					category, _ := p.Args["category"].(string)
+					category = model.NormalizeServiceName(category)
+					tag, _ := p.Args["tag"].(string)
+					tag = model.NormalizeTag(tag)
+					res := []model.Subscription{}
+					for _, s := range subs {
+						if (name == "" || s.ServiceName == name) && (status == "" || s.Status == status) &&
+							(category == "" || strings.EqualFold(s.Category, category)) && (tag == "" || slices.Contains(s.Tags, tag)) {
+							res = append(res, s)
+						}
+					}
+					return res, nil
+				}),
+			},
+			"monthlyCost": {
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Стоимость активных в текущем месяце подписок",
+				Resolve: userSubscriptions("", func(_ graphql.ResolveParams, subs []model.Subscription) (any, error) {
+					now := time.Now()
+					var sum int64
+					for _, s := range subs {
+						sum += model.CostInPeriod(s, now, now)
+					}
+					return sum, nil
+				}),
+			},
+			"totalCost": {
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Стоимость подписок за месяцы [from..to] (как GET /subscriptions/total)",
+				Args: graphql.FieldConfigArgument{
+					"from":        periodArgs["from"],
+					"to":          periodArgs["to"],
+					"serviceName": {Type: graphql.String},
+				},
+				Resolve: userSubscriptions(auth.ScopeReportsRead, func(p graphql.ResolveParams, subs []model.Subscription) (any, error) {
+					from, to, err := period(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					name, _ := p.Args["serviceName"].(string)
+					if name, err = svc.CanonicalServiceName(p.Context, name); err != nil {
+						return nil, err
+					}
+					var sum int64
+					for _, s := range subs {
+						if name == "" || s.ServiceName == name {
+							sum += model.CostInPeriod(s, from, to)
+						}
+					}
+					return sum, nil
+				}),
+			},
+			"costByService": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(serviceCostType))),
+				Description: "Стоимость за месяцы [from..to] по сервисам, по убыванию суммы",
+				Args:        periodArgs,
+				Resolve: userSubscriptions(auth.ScopeReportsRead, func(p graphql.ResolveParams, subs []model.Subscription) (any, error) {
+					from, to, err := period(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					byName := map[string]int64{}
+					for _, s := range subs {
+						if c := model.CostInPeriod(s, from, to); c > 0 {
+							byName[s.ServiceName] += c
+						}
+					}
+					res := make([]serviceCost, 0, len(byName))
+					for name, amount := range byName {
+						res = append(res, serviceCost{ServiceName: name, Amount: amount})
+					}
+					sort.Slice(res, func(i, j int) bool {
+						if res[i].Amount != res[j].Amount {
+							return res[i].Amount > res[j].Amount
+						}
+						return res[i].ServiceName < res[j].ServiceName
+					})
+					return res, nil
+				}),
+			},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": {
+				Type:        graphql.NewNonNull(userType),
+				Description: "Текущий пользователь (только JWT)",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					pr, ok := auth.FromContext(p.Context)
+					if !ok {
+						return nil, auth.ErrUnauthenticated
+					}
+					if pr.UserID == uuid.Nil {
+						return nil, fmt.Errorf("me: caller is not a user")
+					}
+					return user{ID: pr.UserID}, nil
+				},
+			},
+			"user": {
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, err := parseID(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					if err := svc.CheckUserAccess(p.Context, id); err != nil {
+						return nil, err
+					}
+					return user{ID: id}, nil
+				},
+			},
+			"users": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+				Args: graphql.FieldConfigArgument{
+					"ids": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					raw, _ := p.Args["ids"].([]any)
+					res := make([]user, 0, len(raw))
+					for _, v := range raw {
+						id, err := parseID(v)
+						if err != nil {
+							return nil, err
+						}
+						if err := svc.CheckUserAccess(p.Context, id); err != nil {
+							return nil, err
+						}
+						res = append(res, user{ID: id})
+					}
+					return res, nil
+				},
+			},
+			"subscription": {
+				Type: subscriptionType,
+				Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, err := parseID(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					return svc.GetByID(p.Context, id)
+				},
+			},
+			"subscriptions": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(subscriptionType))),
+				Description: "Страница подписок по фильтрам (как GET /subscriptions)",
+				Args: graphql.FieldConfigArgument{
+					"userId":      {Type: graphql.ID, Description: "учитывается только для admin"},
+					"serviceName": {Type: graphql.String},
+					"status":      {Type: graphql.String},
+					"category":    {Type: graphql.String},
+					"tag":         {Type: graphql.String},
+					"limit":       {Type: graphql.Int, DefaultValue: 50},
+					"offset":      {Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					q := model.ListQuery{Limit: p.Args["limit"].(int), Offset: p.Args["offset"].(int)}
+					q.UserID, _ = p.Args["userId"].(string)
+					q.ServiceName, _ = p.Args["serviceName"].(string)
+					q.Status, _ = p.Args["status"].(string)
+					q.Category, _ = p.Args["category"].(string)
+					q.Tag, _ = p.Args["tag"].(string)
+					if q.Limit < 0 || q.Offset < 0 {
+						return nil, fmt.Errorf("limit and offset must be >= 0")
+					}
+					items, err := svc.List(p.Context, q)
+					if items == nil && err == nil {
+						items = []model.Subscription{}
+					}
+					return items, err
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// userSubscriptions — резолвер поля User поверх подписок пользователя из subsLoader.
+// Возвращает thunk, чтобы загрузка всех пользователей уровня ушла одним запросом;
+// scope (если задан) проверяется до загрузки.
+func userSubscriptions(scope string, fn func(graphql.ResolveParams, []model.Subscription) (any, error)) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		if scope != "" {
+			if err := requireScope(p, scope); err != nil {
+				return nil, err
+			}
+		}
+		load := loaderFrom(p.Context).Load(p.Context, p.Source.(user).ID)
+		return func() (any, error) {
+			subs, err := load()
+			if err != nil {
+				return nil, err
+			}
+			return fn(p, subs)
+		}, nil
+	}
+}
+
+// requireScope — scope для полей-отчётов (API-ключам без reports:read недоступны)
+func requireScope(p graphql.ResolveParams, scope string) error {
+	pr, ok := auth.FromContext(p.Context)
+	if !ok {
+		return auth.ErrUnauthenticated
+	}
+	if !pr.HasScope(scope) {
+		return fmt.Errorf("%w: missing scope %s", auth.ErrForbidden, scope)
+	}
+	return nil
+}
+
+func period(args map[string]any) (from, to time.Time, err error) {
+	fromS, _ := args["from"].(string)
+	toS, _ := args["to"].(string)
+	if from, err = model.ParseYearMonth(fromS); err != nil {
+		return from, to, fmt.Errorf("invalid from (YYYY-MM)")
+	}
+	if to, err = model.ParseYearMonth(toS); err != nil {
+		return from, to, fmt.Errorf("invalid to (YYYY-MM)")
+	}
+	if from.After(to) {
+		return from, to, fmt.Errorf("from must be <= to")
+	}
+	return from, to, nil
+}
+
+func parseID(v any) (uuid.UUID, error) {
+	s, _ := v.(string)
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid id %q", s)
+	}
+	return id, nil
+}
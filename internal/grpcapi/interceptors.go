@@ -0,0 +1,174 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/log"
+	pb "subscription-service/pkg/pb/subscriptions/v1"
+)
+
+// KeyAuthenticator проверяет API-ключ (реализуется service.APIKeys)
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (auth.Principal, error)
+}
+
+// methodScopes — scope, который нужен сервисному ключу для метода (как у REST-маршрутов)
+var methodScopes = map[string]string{
+	pb.SubscriptionService_Create_FullMethodName:    auth.ScopeSubscriptionsWrite,
+	pb.SubscriptionService_Update_FullMethodName:    auth.ScopeSubscriptionsWrite,
+	pb.SubscriptionService_Delete_FullMethodName:    auth.ScopeSubscriptionsWrite,
+	pb.SubscriptionService_Get_FullMethodName:       auth.ScopeSubscriptionsRead,
+	pb.SubscriptionService_List_FullMethodName:      auth.ScopeSubscriptionsRead,
+	pb.SubscriptionService_Total_FullMethodName:     auth.ScopeReportsRead,
+	pb.SubscriptionService_Breakdown_FullMethodName: auth.ScopeReportsRead,
+}
+
+// authenticate принимает x-api-key либо authorization: Bearer <jwt> из metadata и проверяет scope метода.
+// Методы вне SubscriptionService (health, reflection) доступны без аутентификации.
+func authenticate(ctx context.Context, method string, secret []byte, keys KeyAuthenticator) (context.Context, error) {
+	scope, ok := methodScopes[method]
+	if !ok {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(k string) string {
+		if v := md.Get(k); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	var (
+		p   auth.Principal
+		err error
+	)
+	if key := first("x-api-key"); key != "" {
+		if p, err = keys.Authenticate(ctx, key); err != nil {
+			return ctx, status.Error(codes.Unauthenticated, "invalid api key")
+		}
+		log.AddAttrs(ctx, "api_key_id", p.KeyID)
+	} else {
+		raw, ok := strings.CutPrefix(first("authorization"), "Bearer ")
+		if !ok || raw == "" {
+			return ctx, status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+		if p, err = auth.ParseToken(secret, raw); err != nil {
+			return ctx, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		log.AddAttrs(ctx, "user_id", p.UserID)
+	}
+	if !p.HasScope(scope) {
+		return ctx, status.Error(codes.PermissionDenied, "missing scope "+scope)
+	}
+	return auth.WithPrincipal(ctx, p), nil
+}
+
+func unaryAuth(secret []byte, keys KeyAuthenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, info.FullMethod, secret, keys)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(secret []byte, keys KeyAuthenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), info.FullMethod, secret, keys)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// requestLogger — логгер вызова (request_id из x-request-id или новый, trace_id); как api.RequestLogger
+func requestLogger(ctx context.Context, logger *log.Logger, method string) context.Context {
+	reqID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("x-request-id"); len(v) > 0 {
+			reqID = v[0]
+		}
+	}
+	if reqID == "" {
+		reqID = uuid.NewString()
+	}
+	l := logger.With("request_id", reqID, "grpc_method", method)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		l = l.With("trace_id", sc.TraceID().String())
+	}
+	return log.NewContext(ctx, l)
+}
+
+func logCall(ctx context.Context, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.InvalidArgument, codes.NotFound, codes.Unauthenticated, codes.PermissionDenied, codes.Canceled:
+	default:
+		level = slog.LevelError
+	}
+	log.FromContext(ctx).Log(ctx, level, "grpc request",
+		"code", code.String(),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+}
+
+func unaryLogger(logger *log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = requestLogger(ctx, logger, info.FullMethod)
+		resp, err := handler(ctx, req)
+		logCall(ctx, start, err)
+		return resp, err
+	}
+}
+
+func streamLogger(logger *log.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := requestLogger(ss.Context(), logger, info.FullMethod)
+		err := handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, start, err)
+		return err
+	}
+}
+
+// unaryRecover/streamRecover превращают панику обработчика в codes.Internal (как middleware.Recoverer)
+func unaryRecover(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer recoverTo(ctx, &err)
+	return handler(ctx, req)
+}
+
+func streamRecover(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recoverTo(ss.Context(), &err)
+	return handler(srv, ss)
+}
+
+func recoverTo(ctx context.Context, err *error) {
+	if r := recover(); r != nil {
+		log.FromContext(ctx).Error("panic", "panic", r, "stack", string(debug.Stack()))
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+// wrappedStream подменяет контекст потока
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context { return w.ctx }
@@ -0,0 +1,278 @@
+// Package grpcapi — gRPC-сервер поверх service.Service (proto: api/proto/subscriptions/v1)
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/service"
+	pb "subscription-service/pkg/pb/subscriptions/v1"
+)
+
+// listPageSize — размер страницы, которой List читает БД для потока
+const listPageSize = 200
+
+type Config struct {
+	Service   *service.Service
+	Keys      KeyAuthenticator
+	JWTSecret []byte
+	Logger    *log.Logger
+}
+
+// NewServer собирает grpc.Server: SubscriptionService, health (grpc.health.v1) и reflection.
+// Возвращённый health.Server переводится в NOT_SERVING при остановке.
+func NewServer(c Config) (*grpc.Server, *health.Server) {
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			unaryLogger(c.Logger), unaryRecover, unaryAuth(c.JWTSecret, c.Keys)),
+		grpc.ChainStreamInterceptor(
+			streamLogger(c.Logger), streamRecover, streamAuth(c.JWTSecret, c.Keys)),
+	)
+	pb.RegisterSubscriptionServiceServer(srv, &subscriptions{svc: c.Service})
+
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus(pb.SubscriptionService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+	return srv, hs
+}
+
+type subscriptions struct {
+	pb.UnimplementedSubscriptionServiceServer
+	svc *service.Service
+}
+
+func (s *subscriptions) Create(ctx context.Context, in *pb.CreateRequest) (*pb.Subscription, error) {
+	sub, err := s.svc.Create(ctx, model.SubscriptionCreate{
+		ServiceName:   in.GetServiceName(),
+		Price:         in.GetPrice(),
+		UserID:        in.GetUserId(),
+		StartYM:       in.GetStartDate(),
+		EndYM:         in.GetEndDate(),
+		Category:      in.GetCategory(),
+		Tags:          in.GetTags(),
+		BillingPeriod: int(in.GetBillingPeriod()),
+	})
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	log.FromContext(ctx).Info("subscription created", "id", sub.ID, "user_id", sub.UserID)
+	return toPB(sub), nil
+}
+
+func (s *subscriptions) Get(ctx context.Context, in *pb.GetRequest) (*pb.Subscription, error) {
+	id, err := uuid.Parse(in.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	sub, err := s.svc.GetByID(ctx, id)
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	return toPB(sub), nil
+}
+
+func (s *subscriptions) Update(ctx context.Context, in *pb.UpdateRequest) (*pb.Subscription, error) {
+	id, err := uuid.Parse(in.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	upd := model.SubscriptionUpdate{
+		ServiceName: in.ServiceName,
+		Price:       in.Price,
+		StartYM:     in.StartDate,
+		EndYM:       in.EndDate,
+		Category:    in.Category,
+	}
+	if in.BillingPeriod != nil {
+		p := int(in.GetBillingPeriod())
+		upd.BillingPeriod = &p
+	}
+	sub, err := s.svc.Update(ctx, id, upd)
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	return toPB(sub), nil
+}
+
+func (s *subscriptions) SetPrice(ctx context.Context, in *pb.SetPriceRequest) (*pb.Subscription, error) {
+	id, err := uuid.Parse(in.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	sub, err := s.svc.SetPrice(ctx, id, model.PriceChangeCreate{Month: in.GetMonth(), Price: in.GetPrice()})
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	return toPB(sub), nil
+}
+
+func (s *subscriptions) RemovePrice(ctx context.Context, in *pb.RemovePriceRequest) (*pb.Subscription, error) {
+	id, err := uuid.Parse(in.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	sub, err := s.svc.RemovePrice(ctx, id, in.GetMonth())
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	return toPB(sub), nil
+}
+
+func (s *subscriptions) Delete(ctx context.Context, in *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	id, err := uuid.Parse(in.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	if err := s.svc.Delete(ctx, id); err != nil {
+		return nil, errStatus(err)
+	}
+	return &pb.DeleteResponse{}, nil
+}
+
+// List отдаёт все подписки по фильтру, читая БД страницами
+func (s *subscriptions) List(in *pb.ListRequest, stream grpc.ServerStreamingServer[pb.Subscription]) error {
+	q := model.ListQuery{
+		UserID:      in.GetUserId(),
+		ServiceName: in.GetServiceName(),
+		Status:      in.GetStatus(),
+		Limit:       listPageSize,
+	}
+	for {
+		page, err := s.svc.List(stream.Context(), q)
+		if err != nil {
+			return errStatus(err)
+		}
+		for _, sub := range page {
+			if err := stream.Send(toPB(sub)); err != nil {
+				return err
+			}
+		}
+		if len(page) < listPageSize {
+			return nil
+		}
+		q.Offset += len(page)
+	}
+}
+
+func (s *subscriptions) Total(ctx context.Context, in *pb.PeriodRequest) (*pb.TotalResponse, error) {
+	q, err := periodQuery(in)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.svc.Total(ctx, q)
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	return &pb.TotalResponse{Total: total}, nil
+}
+
+func (s *subscriptions) Breakdown(ctx context.Context, in *pb.PeriodRequest) (*pb.BreakdownResponse, error) {
+	q, err := periodQuery(in)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.svc.Breakdown(ctx, q)
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	res := &pb.BreakdownResponse{Items: make([]*pb.BreakdownItem, 0, len(items))}
+	for _, it := range items {
+		res.Items = append(res.Items, &pb.BreakdownItem{
+			Month:       it.Month.Format("2006-01"),
+			ServiceName: it.ServiceName,
+			Amount:      it.Amount,
+		})
+		res.Total += it.Amount
+	}
+	return res, nil
+}
+
+func periodQuery(in *pb.PeriodRequest) (model.TotalQuery, error) {
+	from, err := model.ParseYearMonth(in.GetFrom())
+	if err != nil {
+		return model.TotalQuery{}, status.Error(codes.InvalidArgument, "invalid from (YYYY-MM)")
+	}
+	to, err := model.ParseYearMonth(in.GetTo())
+	if err != nil {
+		return model.TotalQuery{}, status.Error(codes.InvalidArgument, "invalid to (YYYY-MM)")
+	}
+	return model.TotalQuery{UserID: in.GetUserId(), ServiceName: in.GetServiceName(), From: from, To: to}, nil
+}
+
+// errStatus — аналог api.errStatus: доменные ошибки в коды gRPC, ошибки валидации — InvalidArgument,
+// всё остальное (сбой БД и т.п.) — Internal
+func errStatus(err error) error {
+	var invalid *service.ValidationError
+	code := codes.Internal
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		code = codes.Unauthenticated
+	case errors.Is(err, auth.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.As(err, &invalid):
+		code = codes.InvalidArgument
+	case errors.Is(err, repo.ErrNotFound), errors.Is(err, repo.ErrJobNotFound), errors.Is(err, repo.ErrUserNotFound),
+		errors.Is(err, repo.ErrServiceNotFound), errors.Is(err, repo.ErrBudgetNotFound):
+		code = codes.NotFound
+	case errors.Is(err, repo.ErrUserExists), errors.Is(err, repo.ErrEmailTaken),
+		errors.Is(err, repo.ErrServiceNameTaken), errors.Is(err, repo.ErrBudgetExists):
+		code = codes.AlreadyExists
+	case errors.Is(err, repo.ErrSubscriptionArchived), errors.Is(err, repo.ErrUserArchived),
+		errors.Is(err, repo.ErrUnknownUser), errors.Is(err, repo.ErrJobState):
+		code = codes.FailedPrecondition
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	}
+	return status.Error(code, err.Error())
+}
+
+func toPB(s model.Subscription) *pb.Subscription {
+	res := &pb.Subscription{
+		Id:             s.ID.String(),
+		ServiceName:    s.ServiceName,
+		Price:          s.Price,
+		UserId:         s.UserID.String(),
+		StartDate:      s.StartDate.Format("2006-01"),
+		Status:         s.Status,
+		CreatedAt:      timestamp(s.CreatedAt),
+		UpdatedAt:      timestamp(s.UpdatedAt),
+		Category:       s.Category,
+		CustomCategory: s.CustomCategory,
+		Tags:           s.Tags,
+		BillingPeriod:  int32(s.BillingPeriod),
+	}
+	if s.EndDate != nil {
+		res.EndDate = s.EndDate.Format("2006-01")
+	}
+	for _, c := range s.PriceChanges {
+		res.PriceChanges = append(res.PriceChanges, &pb.PriceChange{Month: c.Month.Format("2006-01"), Price: c.Price})
+	}
+	return res
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,74 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/service"
+	pb "subscription-service/pkg/pb/subscriptions/v1"
+)
+
+func TestErrStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{auth.ErrUnauthenticated, codes.Unauthenticated},
+		{auth.ErrForbidden, codes.PermissionDenied},
+		{&service.ValidationError{}, codes.InvalidArgument},
+		{repo.ErrNotFound, codes.NotFound},
+		{repo.ErrUserNotFound, codes.NotFound},
+		{fmt.Errorf("resolve: %w", repo.ErrServiceNotFound), codes.NotFound},
+		{repo.ErrUnknownUser, codes.FailedPrecondition},
+		{repo.ErrUserArchived, codes.FailedPrecondition},
+		{repo.ErrSubscriptionArchived, codes.FailedPrecondition},
+		{repo.ErrEmailTaken, codes.AlreadyExists},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{context.Canceled, codes.Canceled},
+		{errors.New("connection reset by peer"), codes.Internal},
+	}
+	for _, tt := range tests {
+		if got := status.Code(errStatus(tt.err)); got != tt.want {
+			t.Errorf("errStatus(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestToPB(t *testing.T) {
+	month := func(s string) time.Time {
+		m, err := model.ParseYearMonth(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	end := month("2026-12")
+	sub := model.Subscription{
+		ID: uuid.New(), ServiceName: "Yandex Plus", Price: 3990, UserID: uuid.New(),
+		StartDate: month("2025-03"), EndDate: &end, Status: model.StatusActive,
+		Category: "music", CustomCategory: true, Tags: []string{"family", "work"},
+		BillingPeriod: model.BillingYearly,
+		PriceChanges:  []model.PriceChange{{Month: month("2026-03"), Price: 4490}},
+	}
+	want := &pb.Subscription{
+		Id: sub.ID.String(), ServiceName: "Yandex Plus", Price: 3990, UserId: sub.UserID.String(),
+		StartDate: "2025-03", EndDate: "2026-12", Status: model.StatusActive,
+		Category: "music", CustomCategory: true, Tags: []string{"family", "work"},
+		BillingPeriod: 12,
+		PriceChanges:  []*pb.PriceChange{{Month: "2026-03", Price: 4490}},
+	}
+	if got := toPB(sub); !proto.Equal(got, want) {
+		t.Fatalf("toPB = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"subscription-service/internal/log"
+)
+
+// Schedule — расписание: ближайший запуск строго после t (UTC)
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule разбирает cron-выражение из 5 полей (минута час день месяц день_недели; *, a-b, */n,
+// a-b/n и списки через запятую; воскресенье — 0 или 7), @hourly, @daily, @weekly, @monthly
+// или @every <duration>. Время — UTC.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || every < time.Second {
+			return nil, fmt.Errorf("invalid schedule %q: @every needs a duration >= 1s", spec)
+		}
+		return everySchedule(every), nil
+	}
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: want 5 fields or @descriptor", spec)
+	}
+	var s cronSchedule
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := [5]*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, f := range fields {
+		set, err := parseField(f, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		*sets[i] = set
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 — тоже воскресенье
+	}
+	s.domAny, s.dowAny = fields[2] == "*", fields[4] == "*"
+	if s.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid schedule %q: never fires", spec)
+	}
+	return s, nil
+}
+
+func parseField(f string, lo, hi int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(f, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			step = n
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("bad value in %q", part)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("bad value in %q", part)
+				}
+			} else if hasStep {
+				to = hi // 5/15 — с 5 до конца
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := s.dom&(1<<t.Day()) != 0, s.dow&(1<<int(t.Weekday())) != 0
+	// как в cron: если заданы и день месяца, и день недели — подходит любой из них
+	if !s.domAny && !s.dowAny {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func (s cronSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// everySchedule выровнен по эпохе: у всех реплик одинаковые моменты запуска
+type everySchedule time.Duration
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	d := time.Duration(e)
+	return t.UTC().Truncate(d).Add(d)
+}
+
+type cronEntry struct {
+	spec     string
+	schedule Schedule
+	kind     string
+	payload  any
+	opts     []Option
+}
+
+// Cron ставит задачи по расписанию. Ключ задачи — вид и время запуска, поэтому при нескольких
+// репликах задача ставится один раз. Запуски, пропущенные пока процесс не работал, не догоняются.
+type Cron struct {
+	queue   *Queue
+	logger  *log.Logger
+	entries []cronEntry
+}
+
+func NewCron(q *Queue, logger *log.Logger) *Cron { return &Cron{queue: q, logger: logger} }
+
+// Add — ставить задачу kind с payload по расписанию spec (см. ParseSchedule)
+func (c *Cron) Add(spec, kind string, payload any, opts ...Option) error {
+	s, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	c.entries = append(c.entries, cronEntry{spec: spec, schedule: s, kind: kind, payload: payload, opts: opts})
+	return nil
+}
+
+// Run работает до отмены ctx
+func (c *Cron) Run(ctx context.Context) {
+	if len(c.entries) == 0 {
+		return
+	}
+	next := make([]time.Time, len(c.entries))
+	for i, e := range c.entries {
+		next[i] = e.schedule.Next(time.Now())
+		c.logger.Info("cron scheduled", "kind", e.kind, "schedule", e.spec, "next", next[i])
+	}
+	for {
+		soonest := next[0]
+		for _, t := range next[1:] {
+			if t.Before(soonest) {
+				soonest = t
+			}
+		}
+		timer := time.NewTimer(time.Until(soonest))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		now := time.Now()
+		for i, e := range c.entries {
+			if next[i].After(now) {
+				continue
+			}
+			key := "cron:" + e.kind + ":" + next[i].Format(time.RFC3339)
+			opts := append([]Option{UniqueKey(key)}, e.opts...)
+			j, inserted, err := c.queue.Enqueue(ctx, e.kind, e.payload, opts...)
+			switch {
+			case err != nil:
+				c.logger.Error("cron enqueue failed", "kind", e.kind, "err", err)
+			case inserted:
+				c.logger.Debug("cron job enqueued", "kind", e.kind, "job_id", j.ID)
+			}
+			next[i] = e.schedule.Next(now)
+		}
+	}
+}
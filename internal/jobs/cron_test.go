@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		spec, from, want string
+	}{
+		{"*/15 * * * *", "2026-01-01 10:07:00", "2026-01-01 10:15:00"},
+		{"*/15 * * * *", "2026-01-01 10:15:00", "2026-01-01 10:30:00"}, // строго после
+		{"5/20 * * * *", "2026-01-01 10:06:00", "2026-01-01 10:25:00"},
+		{"0,30 8-9 * * *", "2026-01-01 09:45:00", "2026-01-02 08:00:00"},
+		{"0 9 * * 1-5", "2026-01-02 10:00:00", "2026-01-05 09:00:00"}, // пятница → понедельник
+		{"0 0 * * 7", "2026-01-01 12:00:00", "2026-01-04 00:00:00"},   // 7 — воскресенье
+		{"0 0 13 * 5", "2026-01-01 12:00:00", "2026-01-02 00:00:00"},  // день месяца или пятница
+		{"0 0 31 * *", "2026-02-01 00:00:00", "2026-03-31 00:00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"@hourly", "2026-01-01 10:00:30", "2026-01-01 11:00:00"},
+		{"@daily", "2026-01-01 00:00:00", "2026-01-02 00:00:00"},
+		{"@weekly", "2026-01-01 00:00:00", "2026-01-04 00:00:00"},
+		{"@monthly", "2026-01-15 08:00:00", "2026-02-01 00:00:00"},
+		{"@every 90s", "2026-01-01 00:00:10", "2026-01-01 00:01:30"},
+		{"@every 1h", "2026-01-01 10:00:00", "2026-01-01 11:00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec+" after "+tt.from, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+				t.Fatalf("Next = %s, want %s", got.Format(time.DateTime), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleNextUsesUTC(t *testing.T) {
+	s, err := ParseSchedule("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msk := time.FixedZone("MSK", 3*60*60)
+	got := s.Next(time.Date(2026, 1, 1, 11, 0, 0, 0, msk)) // 08:00 UTC
+	if want := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC); !got.Equal(want) || got.Location() != time.UTC {
+		t.Fatalf("Next = %s, want %s", got, want)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"0 0 30 2 *", // никогда не наступает
+		"@yearly",
+		"@every 500ms",
+		"@every soon",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want error", spec)
+		}
+	}
+}
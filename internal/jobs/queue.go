@@ -0,0 +1,66 @@
+// Package jobs — очередь фоновых задач в Postgres: постановка (Queue), выполнение
+// типизированными обработчиками (Worker) и запуск по расписанию (Cron)
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+)
+
+// DefaultMaxAttempts — попыток, если при постановке не указано иное
+const DefaultMaxAttempts = 5
+
+// Queue ставит задачи и даёт операции для администрирования очереди
+type Queue struct {
+	repo *repo.JobsRepo
+}
+
+func NewQueue(r *repo.JobsRepo) *Queue { return &Queue{repo: r} }
+
+// Option — параметры постановки задачи
+type Option func(*model.Job)
+
+// RunAt — выполнить не раньше t
+func RunAt(t time.Time) Option { return func(j *model.Job) { j.RunAt = t } }
+
+// MaxAttempts — сколько раз пытаться выполнить задачу, прежде чем она станет failed
+func MaxAttempts(n int) Option { return func(j *model.Job) { j.MaxAttempts = n } }
+
+// UniqueKey — не ставить задачу, если задача с таким ключом уже есть (в любом статусе)
+func UniqueKey(key string) Option { return func(j *model.Job) { j.UniqueKey = &key } }
+
+// Enqueue ставит задачу kind с payload (сериализуется в JSON). При совпадении UniqueKey
+// возвращает inserted=false без ошибки.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any, opts ...Option) (j model.Job, inserted bool, err error) {
+	if payload == nil {
+		payload = struct{}{}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return model.Job{}, false, fmt.Errorf("job payload: %w", err)
+	}
+	j = model.Job{Kind: kind, Payload: data, MaxAttempts: DefaultMaxAttempts}
+	for _, o := range opts {
+		o(&j)
+	}
+	return q.repo.Enqueue(ctx, j)
+}
+
+func (q *Queue) Get(ctx context.Context, id int64) (model.Job, error) { return q.repo.Get(ctx, id) }
+
+func (q *Queue) List(ctx context.Context, f model.JobQuery) ([]model.Job, error) {
+	return q.repo.List(ctx, f)
+}
+
+// Retry — заново поставить failed или cancelled задачу
+func (q *Queue) Retry(ctx context.Context, id int64) (model.Job, error) { return q.repo.Retry(ctx, id) }
+
+// Cancel — отменить queued или running задачу
+func (q *Queue) Cancel(ctx context.Context, id int64) (model.Job, error) {
+	return q.repo.Cancel(ctx, id)
+}
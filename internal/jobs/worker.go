@@ -0,0 +1,241 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/metrics"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/tracing"
+)
+
+// WorkerConfig: Concurrency — сколько задач выполняется одновременно; PollInterval — как часто
+// проверять очередь, когда она пуста; Timeout — дедлайн одной попытки
+type WorkerConfig struct {
+	Concurrency  int
+	PollInterval time.Duration
+	Timeout      time.Duration
+	Logger       *log.Logger
+}
+
+// leaseMargin — запас lease сверх Timeout: задачу не перехватят, пока попытка ещё может закончиться
+const leaseMargin = time.Minute
+
+// abortGrace — сколько после отмены контекстов ждать, пока обработчики вернут задачи в очередь
+const abortGrace = 5 * time.Second
+
+type handler func(ctx context.Context, payload json.RawMessage) error
+
+// Worker выполняет задачи зарегистрированных видов. Несколько воркеров (реплик) работают
+// с одной очередью: задачу забирает один, через FOR UPDATE SKIP LOCKED.
+type Worker struct {
+	repo     *repo.JobsRepo
+	c        WorkerConfig
+	handlers map[string]handler
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	freed    chan struct{}
+	running  sync.WaitGroup
+	// jobCtx — родитель контекстов задач; отменяется, только если Shutdown не дождался их
+	jobCtx context.Context
+	abort  context.CancelFunc
+}
+
+func NewWorker(r *repo.JobsRepo, c WorkerConfig) *Worker {
+	ctx, abort := context.WithCancel(context.Background())
+	return &Worker{
+		repo:     r,
+		c:        c,
+		handlers: map[string]handler{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		freed:    make(chan struct{}, 1),
+		jobCtx:   ctx,
+		abort:    abort,
+	}
+}
+
+// Handle регистрирует обработчик задач kind: payload декодируется в T. Регистрировать до Start.
+func Handle[T any](w *Worker, kind string, fn func(ctx context.Context, payload T) error) {
+	w.handlers[kind] = func(ctx context.Context, raw json.RawMessage) error {
+		var p T
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return Permanent(fmt.Errorf("decode payload: %w", err))
+		}
+		return fn(ctx, p)
+	}
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку как окончательную: задача сразу станет failed, без повторов
+func Permanent(err error) error { return permanentError{err: err} }
+
+// Start запускает цикл выборки задач
+func (w *Worker) Start() {
+	kinds := make([]string, 0, len(w.handlers))
+	for k := range w.handlers {
+		kinds = append(kinds, k)
+	}
+	slices.Sort(kinds)
+	w.c.Logger.Info("jobs worker starting", "kinds", kinds, "concurrency", w.c.Concurrency)
+	go w.loop(kinds)
+}
+
+func (w *Worker) loop(kinds []string) {
+	defer close(w.done)
+	slots := make(chan struct{}, w.c.Concurrency)
+	poll := time.NewTicker(w.c.PollInterval)
+	defer poll.Stop()
+	for {
+		if free := cap(slots) - len(slots); free > 0 {
+			jobs, err := w.repo.Claim(context.Background(), kinds, free, w.c.Timeout+leaseMargin)
+			if err != nil {
+				w.c.Logger.Error("jobs claim failed", "err", err)
+			}
+			for _, j := range jobs {
+				slots <- struct{}{}
+				w.running.Add(1)
+				go func() {
+					defer w.running.Done()
+					w.run(j)
+					<-slots
+					select {
+					case w.freed <- struct{}{}:
+					default:
+					}
+				}()
+			}
+			if err == nil && len(jobs) == free {
+				// очередь могла не опустеть: следующая выборка — как только освободится слот
+				select {
+				case <-w.stop:
+					return
+				case <-w.freed:
+				}
+				continue
+			}
+		}
+		select {
+		case <-w.stop:
+			return
+		case <-w.freed:
+		case <-poll.C:
+		}
+	}
+}
+
+func (w *Worker) run(j model.Job) {
+	l := w.c.Logger.With("job_id", j.ID, "job_kind", j.Kind, "attempt", j.Attempts)
+	start := time.Now()
+	result := "succeeded"
+	defer func() { metrics.ObserveJob(j.Kind, result, time.Since(start)) }()
+
+	// попытки истрачены воркерами, которые упали, не записав результат
+	if j.Attempts > j.MaxAttempts {
+		result = "failed"
+		l.Error("job failed", "err", "lease expired on last attempt")
+		if err := w.repo.Fail(context.Background(), j.ID, j.Attempts, "lease expired on last attempt", 0); err != nil {
+			l.Error("job status update failed", "err", err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(w.jobCtx, w.c.Timeout)
+	defer cancel()
+	ctx = log.NewContext(ctx, l)
+	ctx, span := tracing.Start(ctx, "job "+j.Kind)
+	err := w.call(ctx, j)
+	tracing.RecordError(span, err)
+	span.End()
+
+	// результат пишем и после отмены jobCtx: он нужен именно при остановке
+	bg := context.Background()
+	switch {
+	case err == nil:
+		l.Debug("job succeeded", "duration", time.Since(start).String())
+		err = w.repo.Complete(bg, j.ID, j.Attempts)
+	case w.jobCtx.Err() != nil:
+		result = "released"
+		l.Warn("job interrupted by shutdown, released", "err", err)
+		err = w.repo.Release(bg, j.ID, j.Attempts)
+	default:
+		retryIn := retryDelay(j, err)
+		if retryIn > 0 {
+			result = "retry"
+			l.Warn("job failed, retrying", "retry_in", retryIn.String(), "err", err)
+		} else {
+			result = "failed"
+			l.Error("job failed", "err", err)
+		}
+		err = w.repo.Fail(bg, j.ID, j.Attempts, err.Error(), retryIn)
+	}
+	if err != nil {
+		l.Error("job status update failed", "err", err)
+	}
+}
+
+// call выполняет обработчик; паника — ошибка попытки, а не падение процесса
+func (w *Worker) call(ctx context.Context, j model.Job) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.FromContext(ctx).Error("job panic", "panic", v, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+	return w.handlers[j.Kind](ctx, j.Payload)
+}
+
+// retryDelay — пауза перед следующей попыткой после ошибки err; 0 — больше не пытаемся
+func retryDelay(j model.Job, err error) time.Duration {
+	if errors.As(err, &permanentError{}) || j.Attempts >= j.MaxAttempts {
+		return 0
+	}
+	return backoff(j.Attempts)
+}
+
+// backoff — пауза перед попыткой attempt+1: 10s, 20s, 40s, … до 1h
+func backoff(attempt int) time.Duration {
+	d := 10 * time.Second
+	for i := 1; i < attempt && d < time.Hour; i++ {
+		d *= 2
+	}
+	return min(d, time.Hour)
+}
+
+// Shutdown перестаёт брать задачи и ждёт выполняемые. Если ctx истёк раньше, отменяет их контексты:
+// прерванные задачи возвращаются в очередь без траты попытки.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+	idle := make(chan struct{})
+	go func() {
+		w.running.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+	}
+	w.c.Logger.Warn("jobs drain timed out, interrupting running jobs")
+	w.abort()
+	select {
+	case <-idle:
+	case <-time.After(abortGrace):
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"subscription-service/internal/model"
+)
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{
+		1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 5: 160 * time.Second,
+		9: 2560 * time.Second, 10: time.Hour, 30: time.Hour,
+	} {
+		if got := backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	failure := errors.New("smtp timeout")
+	tests := []struct {
+		name     string
+		attempts int
+		max      int
+		err      error
+		want     time.Duration
+	}{
+		{name: "first failure", attempts: 1, max: 5, err: failure, want: 10 * time.Second},
+		{name: "third failure", attempts: 3, max: 5, err: failure, want: 40 * time.Second},
+		{name: "last attempt", attempts: 5, max: 5, err: failure},
+		{name: "single attempt", attempts: 1, max: 1, err: failure},
+		{name: "permanent", attempts: 1, max: 5, err: Permanent(failure)},
+		{name: "wrapped permanent", attempts: 1, max: 5, err: fmt.Errorf("send: %w", Permanent(failure))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := model.Job{Attempts: tt.attempts, MaxAttempts: tt.max}
+			if got := retryDelay(j, tt.err); got != tt.want {
+				t.Fatalf("retryDelay = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkerCall(t *testing.T) {
+	type payload struct {
+		N int `json:"n"`
+	}
+	w := NewWorker(nil, WorkerConfig{})
+	var got int
+	Handle(w, "count", func(_ context.Context, p payload) error {
+		got = p.N
+		return nil
+	})
+	Handle(w, "panic", func(context.Context, payload) error { panic("boom") })
+
+	job := func(kind, raw string) model.Job {
+		return model.Job{Kind: kind, Payload: json.RawMessage(raw), Attempts: 1, MaxAttempts: 5}
+	}
+	ctx := context.Background()
+
+	if err := w.call(ctx, job("count", `{"n":3}`)); err != nil || got != 3 {
+		t.Fatalf("call = %v, payload %d, want nil and 3", err, got)
+	}
+	// payload не декодируется — повторять бессмысленно
+	err := w.call(ctx, job("count", `{"n":"three"}`))
+	if err == nil || retryDelay(job("count", ""), err) != 0 {
+		t.Fatalf("bad payload: err %v must be permanent", err)
+	}
+	// паника — обычная ошибка попытки, задача повторяется
+	err = w.call(ctx, job("panic", `{}`))
+	if err == nil || err.Error() != "panic: boom" || retryDelay(job("panic", ""), err) == 0 {
+		t.Fatalf("panic: err %v, want retried %q", err, "panic: boom")
+	}
+}
@@ -0,0 +1,122 @@
+package log
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceHandler добавляет trace_id/span_id из контекста (для *Context-методов),
+// если trace_id ещё не привязан к логгеру через With
+type traceHandler struct {
+	slog.Handler
+	hasTrace bool
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && !h.hasTrace {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	has := h.hasTrace
+	for _, a := range attrs {
+		if a.Key == "trace_id" {
+			has = true
+		}
+	}
+	return traceHandler{Handler: h.Handler.WithAttrs(attrs), hasTrace: has}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{Handler: h.Handler.WithGroup(name), hasTrace: h.hasTrace}
+}
+
+// sampleHandler пропускает только каждую N-ю debug-запись (N из Controls)
+type sampleHandler struct {
+	slog.Handler
+	ctl *Controls
+}
+
+func (h sampleHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level <= slog.LevelDebug {
+		if every := h.ctl.debugEvery.Load(); every > 1 && h.ctl.debugSeq.Add(1)%every != 0 {
+			return nil
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h sampleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return sampleHandler{Handler: h.Handler.WithAttrs(attrs), ctl: h.ctl}
+}
+
+func (h sampleHandler) WithGroup(name string) slog.Handler {
+	return sampleHandler{Handler: h.Handler.WithGroup(name), ctl: h.ctl}
+}
+
+// redactHandler заменяет значения чувствительных атрибутов (в т.ч. внутри групп и добавленных через With)
+// на короткий хэш: значения не видны, но записи одного пользователя можно сопоставить
+type redactHandler struct {
+	slog.Handler
+	keys map[string]bool
+}
+
+func newRedactHandler(h slog.Handler, keys []string) redactHandler {
+	m := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		m[k] = true
+	}
+	return redactHandler{Handler: h, keys: m}
+}
+
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	red := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		red[i] = h.redact(a)
+	}
+	return redactHandler{Handler: h.Handler.WithAttrs(red), keys: h.keys}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{Handler: h.Handler.WithGroup(name), keys: h.keys}
+}
+
+func (h redactHandler) redact(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		red := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			red[i] = h.redact(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(red...)}
+	}
+	if h.keys[a.Key] {
+		return slog.String(a.Key, Mask(a.Value.String()))
+	}
+	return a
+}
+
+// Mask — необратимая маска значения: "sha256:" + первые 12 hex-символов
+func Mask(v string) string {
+	if v == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(v))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
@@ -1,18 +1,151 @@
 package log
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-type Logger struct{ *slog.Logger }
+type Logger struct {
+	*slog.Logger
+	ctl *Controls
+}
+
+// Options — настройки логгера (config.Config → log.Options в main)
+type Options struct {
+	Level  string // debug | info | warn | error
+	Format string // text | json
+	Output string // stdout, stderr или путь к файлу (с ротацией)
+
+	// Ротация файла (только для Output-файла)
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+
+	DebugSampleEvery int      // писать 1 из N debug-записей; <=1 — все
+	RedactKeys       []string // ключи атрибутов, значения которых маскируются
+}
+
+func New(o Options) (*Logger, error) {
+	ctl := &Controls{level: new(slog.LevelVar)}
+	if err := ctl.SetLevel(o.Level); err != nil {
+		return nil, err
+	}
+	ctl.SetDebugSampleEvery(o.DebugSampleEvery)
+
+	var w io.Writer = os.Stdout
+	switch o.Output {
+	case "", "stdout":
+	case "stderr":
+		w = os.Stderr
+	default:
+		lj := &lumberjack.Logger{
+			Filename:   o.Output,
+			MaxSize:    o.MaxSizeMB,
+			MaxBackups: o.MaxBackups,
+			MaxAge:     o.MaxAgeDays,
+			Compress:   true,
+		}
+		w, ctl.closer = lj, lj
+	}
 
-func New(env string) *Logger {
+	hopts := &slog.HandlerOptions{Level: ctl.level}
 	var h slog.Handler
-	if env == "prod" {
-		h = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})
-	} else {
-		h = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
+	switch o.Format {
+	case "json":
+		h = slog.NewJSONHandler(w, hopts)
+	case "text", "":
+		h = slog.NewTextHandler(w, hopts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", o.Format)
+	}
+	if len(o.RedactKeys) > 0 {
+		h = newRedactHandler(h, o.RedactKeys)
+	}
+	h = sampleHandler{Handler: traceHandler{Handler: h}, ctl: ctl}
+	return &Logger{Logger: slog.New(h), ctl: ctl}, nil
+}
+
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{Logger: l.Logger.With(args...), ctl: l.ctl}
+}
+
+// Controls — параметры, меняющиеся на лету (admin-эндпоинт)
+func (l *Logger) Controls() *Controls { return l.ctl }
+
+// Close закрывает файл лога, если вывод в файл
+func (l *Logger) Close() error {
+	if l.ctl == nil || l.ctl.closer == nil {
+		return nil
+	}
+	return l.ctl.closer.Close()
+}
+
+type Controls struct {
+	level      *slog.LevelVar
+	debugEvery atomic.Int64
+	debugSeq   atomic.Int64
+	closer     io.Closer
+}
+
+func (c *Controls) Level() string { return strings.ToLower(c.level.Level().String()) }
+
+func (c *Controls) SetLevel(s string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(s)); err != nil {
+		return fmt.Errorf("invalid log level %q", s)
+	}
+	c.level.Set(lvl)
+	return nil
+}
+
+func (c *Controls) DebugSampleEvery() int { return int(c.debugEvery.Load()) }
+
+func (c *Controls) SetDebugSampleEvery(n int) {
+	if n < 1 {
+		n = 1
+	}
+	c.debugEvery.Store(int64(n))
+}
+
+// --- логгер запроса в контексте ---
+
+// scope — логгер запроса; AddAttrs дополняет его на месте, чтобы атрибуты,
+// добавленные глубже по цепочке (user_id, route), видел и access-log
+type scope struct {
+	mu sync.RWMutex
+	l  *Logger
+}
+
+type ctxKey struct{}
+
+// NewContext создаёт в контексте логгер запроса
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &scope{l: l})
+}
+
+// FromContext возвращает логгер запроса, а вне запроса — slog.Default()
+func FromContext(ctx context.Context) *Logger {
+	if s, ok := ctx.Value(ctxKey{}).(*scope); ok {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.l
+	}
+	return &Logger{Logger: slog.Default()}
+}
+
+// AddAttrs добавляет атрибуты к логгеру запроса; без логгера в контексте ничего не делает
+func AddAttrs(ctx context.Context, args ...any) {
+	if s, ok := ctx.Value(ctxKey{}).(*scope); ok {
+		s.mu.Lock()
+		s.l = s.l.With(args...)
+		s.mu.Unlock()
 	}
-	return &Logger{slog.New(h)}
 }
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "subscriptions"
+
+// Registry — собственный реестр сервиса (без глобального состояния prometheus.DefaultRegisterer)
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route pattern, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repo_query_duration_seconds",
+		Help:      "Repository query latency by method.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method"})
+
+	remindersDelivered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reminders_delivered_total",
+		Help:      "Reminder delivery attempts by channel and result (sent, retry, failed, cancelled).",
+	}, []string{"channel", "result"})
+
+	jobsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_processed_total",
+		Help:      "Background job attempts by kind and result (succeeded, retry, failed, released).",
+	}, []string{"kind", "result"})
+
+	jobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Background job attempt duration by kind.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"kind"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, queryDuration, remindersDelivered, jobsProcessed, jobDuration,
+	)
+}
+
+func ObserveHTTP(route, method string, status int, d time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(route, method, code).Inc()
+	httpDuration.WithLabelValues(route, method, code).Observe(d.Seconds())
+}
+
+func ObserveReminder(channel, result string) {
+	remindersDelivered.WithLabelValues(channel, result).Inc()
+}
+
+func ObserveJob(kind, result string, d time.Duration) {
+	jobsProcessed.WithLabelValues(kind, result).Inc()
+	jobDuration.WithLabelValues(kind).Observe(d.Seconds())
+}
+
+// ObserveQuery удобно вызывать через defer: defer metrics.ObserveQuery("List", time.Now())
+func ObserveQuery(method string, start time.Time) {
+	queryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// RegisterDB добавляет статистику пула соединений (sql.DB.Stats)
+func RegisterDB(db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// BusinessStats — бизнес-показатели на текущий месяц
+type BusinessStats struct {
+	ActiveSubscriptions int64
+	MRR                 int64 // monthly recurring revenue, руб.
+}
+
+// RegisterBusiness регистрирует gauges, которые считаются запросом к БД при каждом scrape
+func RegisterBusiness(fn func(ctx context.Context) (BusinessStats, error)) {
+	Registry.MustRegister(&businessCollector{fn: fn})
+}
+
+var (
+	activeDesc = prometheus.NewDesc(namespace+"_active", "Subscriptions active in the current month.", nil, nil)
+	mrrDesc    = prometheus.NewDesc(namespace+"_mrr_rubles", "Monthly recurring revenue of active subscriptions.", nil, nil)
+)
+
+type businessCollector struct {
+	fn func(ctx context.Context) (BusinessStats, error)
+}
+
+func (c *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeDesc
+	ch <- mrrDesc
+}
+
+func (c *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := c.fn(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(activeDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(activeDesc, prometheus.GaugeValue, float64(st.ActiveSubscriptions))
+	ch <- prometheus.MustNewConstMetric(mrrDesc, prometheus.GaugeValue, float64(st.MRR))
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Budget — месячный бюджет пользователя: общий, на категорию (Category) или на сервис (ServiceName)
+type Budget struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Category    string    `json:"category,omitempty"`
+	ServiceName string    `json:"service_name,omitempty"`
+	Amount      int64     `json:"amount"` // руб. в месяц
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BudgetCreate: category и service_name не задаются вместе; оба пусты — общий бюджет
+type BudgetCreate struct {
+	UserID      string `json:"user_id,omitempty"` // для user — всегда он сам
+	Category    string `json:"category,omitempty"`
+	ServiceName string `json:"service_name,omitempty"`
+	Amount      int64  `json:"amount"`
+}
+
+// BudgetUpdate — область бюджета не меняется, только сумма
+type BudgetUpdate struct {
+	Amount int64 `json:"amount"`
+}
+
+// BudgetMonth — траты за месяц в сравнении с бюджетом (как Total за этот месяц)
+type BudgetMonth struct {
+	Month     time.Time `json:"month"` // первый день месяца
+	Spent     int64     `json:"spent"`
+	Remaining int64     `json:"remaining"` // сколько ещё можно потратить (0 при превышении)
+	Overspent int64     `json:"overspent"` // на сколько бюджет превышен
+	// Forecast — месяц не закончился (текущий или будущий по часовому поясу пользователя):
+	// spent — прогноз по текущим подпискам
+	Forecast bool `json:"forecast"`
+}
+
+// BudgetStatus — бюджет и траты по месяцам периода
+type BudgetStatus struct {
+	Budget Budget        `json:"budget"`
+	Months []BudgetMonth `json:"months"`
+}
+
+// BudgetAlert — прогноз трат за текущий месяц превысил бюджет
+type BudgetAlert struct {
+	Budget     Budget    `json:"budget"`
+	Month      time.Time `json:"month"` // первый день месяца
+	Spent      int64     `json:"spent"`
+	Email      string    `json:"-"`
+	WebhookURL string    `json:"-"`
+}
+
+// MonthAmount — стоимость подписок за месяц
+type MonthAmount struct {
+	Month  time.Time `json:"month"` // первый день месяца
+	Amount int64     `json:"amount"`
+}
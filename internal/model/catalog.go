@@ -0,0 +1,68 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CatalogService — сервис из каталога; service_name подписок приводится к его Name
+type CatalogService struct {
+	ID        uuid.UUID     `json:"id"`
+	Name      string        `json:"name"`    // каноническое название
+	Aliases   []string      `json:"aliases"` // другие написания, например "Netflix Premium"
+	Category  string        `json:"category,omitempty"`
+	Website   string        `json:"website,omitempty"`
+	Plans     []ServicePlan `json:"plans"` // первый — тариф по умолчанию
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type ServicePlan struct {
+	Name  string `json:"name"`
+	Price int64  `json:"price"` // рублей в месяц
+}
+
+type CatalogServiceCreate struct {
+	Name     string        `json:"name"`
+	Aliases  []string      `json:"aliases,omitempty"`
+	Category string        `json:"category,omitempty"`
+	Website  string        `json:"website,omitempty"`
+	Plans    []ServicePlan `json:"plans,omitempty"`
+}
+
+// CatalogServiceUpdate — меняются только переданные поля; aliases и plans заменяются целиком
+type CatalogServiceUpdate struct {
+	Name     *string        `json:"name,omitempty"`
+	Aliases  *[]string      `json:"aliases,omitempty"`
+	Category *string        `json:"category,omitempty"`
+	Website  *string        `json:"website,omitempty"`
+	Plans    *[]ServicePlan `json:"plans,omitempty"`
+}
+
+// CatalogMerge — что влить в сервис: другие записи каталога и/или названия не из каталога
+type CatalogMerge struct {
+	ServiceIDs []string `json:"service_ids,omitempty"`
+	Names      []string `json:"names,omitempty"`
+}
+
+type CatalogMergeResult struct {
+	Service CatalogService `json:"service"`
+	// SubscriptionsUpdated — сколько подписок переименовано в каноническое название
+	SubscriptionsUpdated int64 `json:"subscriptions_updated"`
+}
+
+type CatalogQuery struct {
+	Category string
+	Search   string // подстрока названия или псевдонима
+	Limit    int
+	Offset   int
+}
+
+// NormalizeServiceName убирает лишние пробелы: "  Netflix   Premium " → "Netflix Premium"
+func NormalizeServiceName(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+// ServiceKey — ключ поиска в каталоге: название без лишних пробелов в нижнем регистре
+// (та же логика, что в repo: lower(btrim(regexp_replace(name, '\s+', ' ', 'g'))))
+func ServiceKey(s string) string { return strings.ToLower(NormalizeServiceName(s)) }
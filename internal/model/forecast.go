@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Прогноз: по умолчанию и максимум месяцев (GET /subscriptions/forecast?months=)
+const (
+	DefaultForecastMonths = 12
+	MaxForecastMonths     = 60
+)
+
+// ForecastQuery — прогноз на Months месяцев начиная с From (фильтры — как у TotalQuery)
+type ForecastQuery struct {
+	UserID      string
+	ServiceName string
+	Category    string
+	Tag         string
+	From        time.Time // первый день месяца
+	Months      int
+}
+
+// Forecast — сколько будут стоить текущие подписки, если ничего не менять: подписка действует до
+// end_date (без неё — бессрочно), списывается раз в billing_period месяцев по цене с учётом
+// запланированных изменений (price_changes)
+type Forecast struct {
+	From   time.Time       `json:"from"`
+	To     time.Time       `json:"to"`
+	Total  int64           `json:"total"`
+	Months []ForecastMonth `json:"months"`
+}
+
+type ForecastMonth struct {
+	Month         time.Time      `json:"month"` // первый день месяца
+	Amount        int64          `json:"amount"`
+	Subscriptions []ForecastItem `json:"subscriptions"` // по убыванию стоимости
+}
+
+// ForecastItem — подписка со списанием в месяце прогноза; Amount — цена, действующая в этом месяце
+type ForecastItem struct {
+	ID          uuid.UUID `json:"id"`
+	ServiceName string    `json:"service_name"`
+	Amount      int64     `json:"amount"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статус фоновой задачи
+const (
+	JobQueued    = "queued"  // ждёт run_at
+	JobRunning   = "running" // взята воркером до locked_until
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed" // попытки исчерпаны или ошибка без повтора
+	JobCancelled = "cancelled"
+)
+
+type Job struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload" swaggertype:"object"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedUntil *time.Time      `json:"locked_until,omitempty"`
+	LastError   *string         `json:"last_error,omitempty"`
+	UniqueKey   *string         `json:"unique_key,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+type JobQuery struct {
+	Kind   string
+	Status string
+	Limit  int
+	Offset int
+}
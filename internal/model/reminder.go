@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Каналы доставки напоминаний
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelLog     = "log"
+)
+
+// KnownChannel — канал из списка поддерживаемых
+func KnownChannel(c string) bool {
+	return c == ChannelEmail || c == ChannelWebhook || c == ChannelLog
+}
+
+// Вид напоминания
+const (
+	ReminderRenewal = "renewal" // подписка продлится (оплата за следующий месяц)
+	ReminderEnding  = "ending"  // подписка закончится: end_date — последний оплаченный месяц
+)
+
+// Статус доставки напоминания
+const (
+	ReminderPending   = "pending"
+	ReminderSent      = "sent"
+	ReminderFailed    = "failed"    // попытки исчерпаны
+	ReminderCancelled = "cancelled" // подписка или настройки изменились, напоминание не актуально
+)
+
+// NotificationPreferences — настройки напоминаний пользователя
+type NotificationPreferences struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Enabled    bool      `json:"enabled"`
+	LeadDays   int       `json:"lead_days"` // за сколько дней до события напоминать
+	Channels   []string  `json:"channels"`  // email | webhook | log
+	Email      string    `json:"email,omitempty"`
+	WebhookURL string    `json:"webhook_url,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+}
+
+// NotificationPreferencesUpdate — частичное изменение настроек
+type NotificationPreferencesUpdate struct {
+	Enabled    *bool     `json:"enabled,omitempty"`
+	LeadDays   *int      `json:"lead_days,omitempty"`
+	Channels   *[]string `json:"channels,omitempty"`
+	Email      *string   `json:"email,omitempty"`
+	WebhookURL *string   `json:"webhook_url,omitempty"`
+}
+
+// Reminder — напоминание к доставке вместе с тем, что нужно для текста и адресата
+type Reminder struct {
+	ID           int64        `json:"id"`
+	Kind         string       `json:"kind"`     // renewal | ending
+	DueDate      time.Time    `json:"due_date"` // дата продления или первый день после окончания
+	Channel      string       `json:"channel"`
+	Attempts     int          `json:"attempts"`
+	Subscription Subscription `json:"subscription"`
+	Email        string       `json:"-"`
+	WebhookURL   string       `json:"-"`
+	Wanted       bool         `json:"-"` // канал всё ещё включён в настройках пользователя
+}
@@ -2,40 +2,97 @@ package model
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Статус подписки относительно текущего месяца; хранится в БД и пересчитывается
+// (repo.RecalculateStatuses), когда месяц сменился
+const (
+	StatusUpcoming = "upcoming" // ещё не началась
+	StatusActive   = "active"
+	StatusExpired  = "expired" // end_date в прошлом месяце или раньше
+	// StatusArchived — подписка архивированного пользователя; не пересчитывается и не напоминается
+	StatusArchived = "archived"
+)
+
+// Периоды оплаты, месяцев
+const (
+	BillingMonthly   = 1
+	BillingQuarterly = 3
+	BillingHalfYear  = 6
+	BillingYearly    = 12
+)
+
+// ValidBillingPeriod — период из поддерживаемых (см. CHECK в миграции 011)
+func ValidBillingPeriod(p int) bool {
+	return p == BillingMonthly || p == BillingQuarterly || p == BillingHalfYear || p == BillingYearly
+}
+
 type Subscription struct {
 	ID          uuid.UUID  `json:"id"`
 	ServiceName string     `json:"service_name"`
-	Price       int64      `json:"price"` // стоимость в рублях за месяц
+	Price       int64      `json:"price"` // стоимость в рублях за период оплаты (до изменений из PriceChanges)
 	UserID      uuid.UUID  `json:"user_id"`
 	StartDate   time.Time  `json:"start_date"`         // первый день месяца
 	EndDate     *time.Time `json:"end_date,omitempty"` // опционально, первый день месяца
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	Status      string     `json:"status"`             // upcoming | active | expired | archived
+	// Category — заданная для подписки (CustomCategory) или категория сервиса из каталога
+	Category       string        `json:"category,omitempty"`
+	CustomCategory bool          `json:"custom_category,omitempty"`
+	Tags           []string      `json:"tags"`
+	BillingPeriod  int           `json:"billing_period"` // месяцев между списаниями; первое — в месяц start_date
+	PriceChanges   []PriceChange `json:"price_changes"`  // запланированные изменения цены по возрастанию месяца
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// PriceChange — с месяца Month подписка стоит Price за период оплаты
+type PriceChange struct {
+	Month time.Time `json:"month"` // первый день месяца
+	Price int64     `json:"price"`
+}
+
+// PriceChangeCreate — запланировать цену; изменение на тот же месяц заменяется
+type PriceChangeCreate struct {
+	Month string `json:"month"` // YYYY-MM, позже start_date
+	Price int64  `json:"price"`
 }
 
 type SubscriptionCreate struct {
-	ServiceName string `json:"service_name"`
-	Price       int64  `json:"price"`
-	UserID      string `json:"user_id"`    // UUID строкой
-	StartYM     string `json:"start_date"` // YYYY-MM
-	EndYM       string `json:"end_date,omitempty"`
+	ServiceName   string   `json:"service_name"`   // приводится к названию из каталога сервисов
+	Price         int64    `json:"price"`          // 0 — цена тарифа Plan из каталога
+	Plan          string   `json:"plan,omitempty"` // тариф из каталога; пусто — первый
+	UserID        string   `json:"user_id"`        // UUID строкой
+	StartYM       string   `json:"start_date"`     // YYYY-MM
+	EndYM         string   `json:"end_date,omitempty"`
+	Category      string   `json:"category,omitempty"` // пусто — категория сервиса из каталога
+	Tags          []string `json:"tags,omitempty"`
+	BillingPeriod int      `json:"billing_period,omitempty"` // месяцев между списаниями (1, 3, 6, 12); 0 — ежемесячно
 }
 
 type SubscriptionUpdate struct {
-	ServiceName *string `json:"service_name,omitempty"`
-	Price       *int64  `json:"price,omitempty"`
-	StartYM     *string `json:"start_date,omitempty"`
-	EndYM       *string `json:"end_date,omitempty"`
+	ServiceName   *string `json:"service_name,omitempty"`
+	Price         *int64  `json:"price,omitempty"`
+	BillingPeriod *int    `json:"billing_period,omitempty"`
+	StartYM       *string `json:"start_date,omitempty"`
+	EndYM         *string `json:"end_date,omitempty"`
+	Category      *string `json:"category,omitempty"` // "" — вернуть категорию сервиса из каталога
+}
+
+// TagsUpdate — метки, добавляемые к подписке
+type TagsUpdate struct {
+	Tags []string `json:"tags"`
 }
 
 type ListQuery struct {
 	UserID      string
 	ServiceName string
+	Status      string
+	Category    string
+	Tag         string
 	Limit       int
 	Offset      int
 }
@@ -43,10 +100,42 @@ type ListQuery struct {
 type TotalQuery struct {
 	UserID      string
 	ServiceName string
+	Category    string
+	Tag         string
 	From        time.Time
 	To          time.Time
 }
 
+// BreakdownItem — стоимость подписок на сервис за месяц
+type BreakdownItem struct {
+	Month       time.Time `json:"month"` // первый день месяца
+	ServiceName string    `json:"service_name"`
+	Amount      int64     `json:"amount"`
+}
+
+// Группировка стоимости за период (GET /subscriptions/total?group_by=)
+const (
+	GroupByCategory = "category"
+	GroupByTag      = "tag" // подписка с несколькими метками входит в каждую группу
+)
+
+// GroupAmount — стоимость подписок группы за период; Key "" — без категории или без меток
+type GroupAmount struct {
+	Key    string `json:"key"`
+	Amount int64  `json:"amount"`
+}
+
+// GroupedTotal — ответ total с group_by: общая стоимость и стоимость по группам
+// (при группировке по меткам сумма групп может быть больше total)
+type GroupedTotal struct {
+	Total   int64         `json:"total"`
+	GroupBy string        `json:"group_by"`
+	Groups  []GroupAmount `json:"groups"`
+}
+
+// NormalizeTag: метки без учёта регистра и лишних пробелов
+func NormalizeTag(s string) string { return strings.ToLower(strings.Join(strings.Fields(s), " ")) }
+
 func ParseInt(s string, def int) int {
 	if s == "" {
 		return def
@@ -62,3 +151,57 @@ func ParseInt(s string, def int) int {
 func ParseYearMonth(s string) (time.Time, error) {
 	return time.Parse("2006-01", s)
 }
+
+// StatusAt — статус подписки на месяц now (та же логика, что в repo.RecalculateStatuses)
+func StatusAt(start time.Time, end *time.Time, now time.Time) string {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	switch {
+	case start.After(month):
+		return StatusUpcoming
+	case end != nil && end.Before(month):
+		return StatusExpired
+	}
+	return StatusActive
+}
+
+// CostInPeriod — стоимость подписки за месяцы [from..to] включительно: списания раз в BillingPeriod
+// месяцев начиная со start_date по цене, действующей в месяце списания (та же логика, что repo.Total)
+func CostInPeriod(s Subscription, from, to time.Time) int64 {
+	start, last := monthIndex(s.StartDate), monthIndex(to)
+	if s.EndDate != nil {
+		last = min(last, monthIndex(*s.EndDate))
+	}
+	period := max(s.BillingPeriod, BillingMonthly)
+	first := max(start, monthIndex(from))
+	first += (period - (first-start)%period) % period // первый месяц списания не раньше from
+	var sum int64
+	for m := first; m <= last; m += period {
+		sum += priceAt(s, m)
+	}
+	return sum
+}
+
+// Charged — подписка идёт в месяце month, и в нём списание
+func Charged(s Subscription, month time.Time) bool {
+	m, start := monthIndex(month), monthIndex(s.StartDate)
+	if m < start || s.EndDate != nil && m > monthIndex(*s.EndDate) {
+		return false
+	}
+	return (m-start)%max(s.BillingPeriod, BillingMonthly) == 0
+}
+
+// PriceAt — цена за период оплаты, действующая в месяце month
+func PriceAt(s Subscription, month time.Time) int64 { return priceAt(s, monthIndex(month)) }
+
+func priceAt(s Subscription, m int) int64 {
+	price := s.Price
+	for _, c := range s.PriceChanges {
+		if monthIndex(c.Month) > m {
+			break
+		}
+		price = c.Price
+	}
+	return price
+}
+
+func monthIndex(t time.Time) int { return t.Year()*12 + int(t.Month()) - 1 }
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func ym(s string) time.Time {
+	t, err := ParseYearMonth(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCostInPeriod(t *testing.T) {
+	end := ym("2026-06")
+	tests := []struct {
+		name     string
+		sub      Subscription
+		from, to string
+		want     int64
+	}{
+		{name: "monthly", sub: Subscription{Price: 100, StartDate: ym("2025-01")}, from: "2025-03", to: "2025-05", want: 300},
+		{name: "zero period is monthly", sub: Subscription{Price: 100, StartDate: ym("2025-01"), BillingPeriod: 0}, from: "2025-01", to: "2025-12", want: 1200},
+		{name: "before start", sub: Subscription{Price: 100, StartDate: ym("2025-06")}, from: "2025-01", to: "2025-05"},
+		{name: "after end", sub: Subscription{Price: 100, StartDate: ym("2025-01"), EndDate: &end}, from: "2026-07", to: "2026-12"},
+		{name: "end inside period", sub: Subscription{Price: 100, StartDate: ym("2026-01"), EndDate: &end}, from: "2026-01", to: "2026-12", want: 600},
+		{name: "yearly in start month", sub: Subscription{Price: 1200, BillingPeriod: BillingYearly, StartDate: ym("2025-03")}, from: "2025-01", to: "2025-12", want: 1200},
+		{name: "yearly between charges", sub: Subscription{Price: 1200, BillingPeriod: BillingYearly, StartDate: ym("2025-03")}, from: "2025-04", to: "2026-02"},
+		{name: "yearly over two years", sub: Subscription{Price: 1200, BillingPeriod: BillingYearly, StartDate: ym("2025-03")}, from: "2025-01", to: "2026-12", want: 2400},
+		{name: "quarterly from mid-period", sub: Subscription{Price: 300, BillingPeriod: BillingQuarterly, StartDate: ym("2025-01")}, from: "2025-02", to: "2025-12", want: 900},
+		{name: "yearly ended before next charge", sub: Subscription{Price: 1200, BillingPeriod: BillingYearly, StartDate: ym("2025-07"), EndDate: &end}, from: "2025-01", to: "2026-12", want: 1200},
+		{
+			name: "price change from month",
+			sub: Subscription{Price: 100, StartDate: ym("2025-01"), PriceChanges: []PriceChange{
+				{Month: ym("2025-04"), Price: 150}, {Month: ym("2025-06"), Price: 200},
+			}},
+			from: "2025-02", to: "2025-07", want: 100 + 100 + 150 + 150 + 200 + 200,
+		},
+		{
+			name: "price change between yearly charges",
+			sub: Subscription{Price: 1200, BillingPeriod: BillingYearly, StartDate: ym("2025-03"), PriceChanges: []PriceChange{
+				{Month: ym("2025-09"), Price: 1500},
+			}},
+			from: "2025-01", to: "2026-12", want: 1200 + 1500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CostInPeriod(tt.sub, ym(tt.from), ym(tt.to)); got != tt.want {
+				t.Fatalf("CostInPeriod = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCharged(t *testing.T) {
+	end := ym("2026-06")
+	sub := Subscription{Price: 300, BillingPeriod: BillingQuarterly, StartDate: ym("2025-01"), EndDate: &end}
+	for month, want := range map[string]bool{
+		"2024-10": false, "2025-01": true, "2025-02": false, "2025-04": true, "2026-04": true, "2026-05": false, "2026-07": false,
+	} {
+		if got := Charged(sub, ym(month)); got != want {
+			t.Errorf("Charged(%s) = %v, want %v", month, got, want)
+		}
+	}
+}
+
+func TestPriceAt(t *testing.T) {
+	sub := Subscription{Price: 100, StartDate: ym("2025-01"), PriceChanges: []PriceChange{
+		{Month: ym("2025-04"), Price: 150}, {Month: ym("2026-01"), Price: 200},
+	}}
+	for month, want := range map[string]int64{"2025-01": 100, "2025-03": 100, "2025-04": 150, "2025-12": 150, "2026-01": 200, "2030-01": 200} {
+		if got := PriceAt(sub, ym(month)); got != want {
+			t.Errorf("PriceAt(%s) = %d, want %d", month, got, want)
+		}
+	}
+}
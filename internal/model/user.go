@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Значения по умолчанию для нового пользователя
+const (
+	DefaultTimezone = "UTC"
+	DefaultCurrency = "RUB"
+)
+
+// Способ удаления пользователя (DELETE /users/{id}?mode=)
+const (
+	UserDeleteArchive = "archive" // пользователь и его подписки остаются в истории, подписки закрываются
+	UserDeleteCascade = "cascade" // пользователь удаляется вместе с подписками и настройками
+)
+
+type User struct {
+	ID          uuid.UUID  `json:"id"`
+	DisplayName string     `json:"display_name"`
+	Email       string     `json:"email,omitempty"`
+	Timezone    string     `json:"timezone"` // IANA, например Europe/Moscow
+	Currency    string     `json:"currency"` // ISO 4217, валюта по умолчанию
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type UserCreate struct {
+	ID          string `json:"id,omitempty"` // UUID строкой; пусто — сгенерировать (для user — всегда он сам)
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email,omitempty"`
+	Timezone    string `json:"timezone,omitempty"` // по умолчанию UTC
+	Currency    string `json:"currency,omitempty"` // по умолчанию RUB
+}
+
+// UserUpdate — меняются только переданные поля; email "" — удалить
+type UserUpdate struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	Email       *string `json:"email,omitempty"`
+	Timezone    *string `json:"timezone,omitempty"`
+	Currency    *string `json:"currency,omitempty"`
+}
+
+type UserQuery struct {
+	Archived bool // true — включая архивированных
+	Limit    int
+	Offset   int
+}
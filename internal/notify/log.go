@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"context"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+)
+
+// Log пишет напоминание в лог приложения (для разработки и как запасной канал)
+type Log struct {
+	logger *log.Logger
+}
+
+func NewLog(l *log.Logger) *Log { return &Log{logger: l} }
+
+func (n *Log) Notify(ctx context.Context, r model.Reminder) error {
+	subject, _ := Text(r)
+	n.logger.InfoContext(ctx, "reminder", "reminder_id", r.ID, "kind", r.Kind,
+		"user_id", r.Subscription.UserID, "subscription_id", r.Subscription.ID,
+		"due_date", r.DueDate.Format("2006-01-02"), "subject", subject)
+	return nil
+}
+
+func (n *Log) NotifyBudget(ctx context.Context, a model.BudgetAlert) error {
+	subject, _ := BudgetText(a)
+	n.logger.InfoContext(ctx, "budget alert", "budget_id", a.Budget.ID, "user_id", a.Budget.UserID,
+		"month", a.Month.Format("2006-01"), "amount", a.Budget.Amount, "spent", a.Spent, "subject", subject)
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package notify — доставка напоминаний о подписках и предупреждений о превышении бюджета
+// по каналам: email (SMTP), webhook, лог
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"subscription-service/internal/model"
+)
+
+// ErrNoAddress — у пользователя не задан адрес для канала; повтор не поможет
+var ErrNoAddress = errors.New("no address for channel")
+
+// Notifier доставляет напоминания и предупреждения о бюджете по своему каналу
+type Notifier interface {
+	Notify(ctx context.Context, r model.Reminder) error
+	NotifyBudget(ctx context.Context, a model.BudgetAlert) error
+}
+
+// Text — тема и текст напоминания
+func Text(r model.Reminder) (subject, body string) {
+	s := r.Subscription
+	day := r.DueDate.Format("02.01.2006")
+	switch r.Kind {
+	case model.ReminderEnding:
+		subject = fmt.Sprintf("Подписка %s заканчивается", s.ServiceName)
+		body = fmt.Sprintf("Подписка %s заканчивается: последний оплаченный месяц — %s, с %s она не продлится.",
+			s.ServiceName, s.EndDate.Format("01.2006"), day)
+	default:
+		subject = fmt.Sprintf("Подписка %s продлится %s", s.ServiceName, day)
+		body = fmt.Sprintf("%s подписка %s продлится, к оплате %d руб.", day, s.ServiceName, s.Price)
+	}
+	return subject, body
+}
+
+// BudgetText — тема и текст предупреждения о превышении бюджета
+func BudgetText(a model.BudgetAlert) (subject, body string) {
+	month := a.Month.Format("01.2006")
+	scope := "Общий бюджет"
+	switch {
+	case a.Budget.Category != "":
+		scope = fmt.Sprintf("Бюджет на категорию %s", a.Budget.Category)
+	case a.Budget.ServiceName != "":
+		scope = fmt.Sprintf("Бюджет на %s", a.Budget.ServiceName)
+	}
+	subject = fmt.Sprintf("%s за %s превышен", scope, month)
+	body = fmt.Sprintf("%s — %d руб. в месяц, а подписки за %s обойдутся в %d руб. (на %d руб. больше).",
+		scope, a.Budget.Amount, month, a.Spent, a.Spent-a.Budget.Amount)
+	return subject, body
+}
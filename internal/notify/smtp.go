@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"time"
+
+	"subscription-service/internal/model"
+)
+
+// SMTPConfig — Addr host:port; Username пусто — без авторизации.
+// STARTTLS используется, если сервер его предлагает.
+type SMTPConfig struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// SMTP отправляет напоминание или предупреждение письмом на email из настроек пользователя
+type SMTP struct {
+	c SMTPConfig
+}
+
+func NewSMTP(c SMTPConfig) *SMTP { return &SMTP{c: c} }
+
+func (n *SMTP) Notify(ctx context.Context, r model.Reminder) error {
+	if r.Email == "" {
+		return ErrNoAddress
+	}
+	subject, body := Text(r)
+	return n.send(ctx, r.Email, n.message(r.Email, subject, body))
+}
+
+func (n *SMTP) NotifyBudget(ctx context.Context, a model.BudgetAlert) error {
+	if a.Email == "" {
+		return ErrNoAddress
+	}
+	subject, body := BudgetText(a)
+	return n.send(ctx, a.Email, n.message(a.Email, subject, body))
+}
+
+func (n *SMTP) message(to, subject, body string) string {
+	return "From: " + n.c.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: 8bit\r\n\r\n" +
+		body + "\r\n"
+}
+
+func (n *SMTP) send(ctx context.Context, to, msg string) error {
+	if n.c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.c.Timeout)
+		defer cancel()
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", n.c.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp dial: %w", err)
+	}
+	// net/smtp не принимает ctx: ограничиваем весь диалог дедлайном соединения
+	if d, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(d)
+	}
+	host, _, _ := net.SplitHostPort(n.c.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if n.c.Username != "" {
+		// PlainAuth сам откажется слать пароль без TLS (кроме localhost)
+		if err := c.Auth(smtp.PlainAuth("", n.c.Username, n.c.Password, host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := c.Mail(n.c.From); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return fmt.Errorf("smtp rcpt to: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	return c.Quit()
+}
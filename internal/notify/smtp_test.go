@@ -0,0 +1,207 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+)
+
+// smtpMessage — то, что получил stub за одну сессию
+type smtpMessage struct {
+	from, rcpt, data string
+}
+
+// smtpStub — минимальный SMTP-сервер на net.Listen: принимает одну сессию без TLS и авторизации.
+// rcptReply — ответ на RCPT TO (пусто — 250).
+func smtpStub(t *testing.T, rcptReply string) (addr string, got <-chan smtpMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	if rcptReply == "" {
+		rcptReply = "250 OK"
+	}
+
+	ch := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		var m smtpMessage
+		defer func() { ch <- m }()
+		reply("220 stub ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			cmd := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250-stub")
+				reply("250 8BITMIME")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				m.from = envelopeAddr(line)
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				m.rcpt = envelopeAddr(line)
+				reply(rcptReply)
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var sb strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					sb.WriteString(l)
+				}
+				m.data = sb.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+// envelopeAddr — адрес из "MAIL FROM:<a> BODY=8BITMIME" / "RCPT TO:<a>"
+func envelopeAddr(line string) string {
+	_, rest, _ := strings.Cut(line, "<")
+	addr, _, _ := strings.Cut(rest, ">")
+	return addr
+}
+
+func testReminder() model.Reminder {
+	return model.Reminder{
+		ID:      42,
+		Kind:    model.ReminderRenewal,
+		DueDate: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+		Channel: model.ChannelEmail,
+		Email:   "user@example.com",
+		Subscription: model.Subscription{
+			ID:          uuid.New(),
+			ServiceName: "Yandex Plus",
+			Price:       400,
+			StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestSMTPNotify(t *testing.T) {
+	addr, got := smtpStub(t, "")
+	n := NewSMTP(SMTPConfig{Addr: addr, From: "noreply@subs.local", Timeout: 5 * time.Second})
+
+	if err := n.Notify(context.Background(), testReminder()); err != nil {
+		t.Fatal(err)
+	}
+	m := <-got
+	if m.from != "noreply@subs.local" || m.rcpt != "user@example.com" {
+		t.Fatalf("envelope: from %q, rcpt %q", m.from, m.rcpt)
+	}
+	subject, body := Text(testReminder())
+	for _, want := range []string{
+		"From: noreply@subs.local\r\n",
+		"To: user@example.com\r\n",
+		"Subject: =?utf-8?q?",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\n" + body + "\r\n",
+	} {
+		if !strings.Contains(m.data, want) {
+			t.Errorf("message has no %q:\n%s", want, m.data)
+		}
+	}
+	if !strings.Contains(subject, "Yandex Plus") || !strings.Contains(body, "400 руб.") {
+		t.Errorf("unexpected text: %q / %q", subject, body)
+	}
+}
+
+func TestSMTPNotifyBudget(t *testing.T) {
+	addr, got := smtpStub(t, "")
+	n := NewSMTP(SMTPConfig{Addr: addr, From: "noreply@subs.local", Timeout: 5 * time.Second})
+	a := model.BudgetAlert{
+		Budget: model.Budget{Amount: 1000, Category: "Музыка"},
+		Month:  time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC),
+		Spent:  1798,
+		Email:  "user@example.com",
+	}
+
+	if err := n.NotifyBudget(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+	m := <-got
+	_, body := BudgetText(a)
+	if m.rcpt != "user@example.com" || !strings.Contains(m.data, body) {
+		t.Fatalf("rcpt %q, message:\n%s", m.rcpt, m.data)
+	}
+}
+
+func TestSMTPRejectedRecipient(t *testing.T) {
+	addr, got := smtpStub(t, "550 no such user")
+	n := NewSMTP(SMTPConfig{Addr: addr, From: "noreply@subs.local", Timeout: 5 * time.Second})
+
+	err := n.Notify(context.Background(), testReminder())
+	if err == nil || !strings.Contains(err.Error(), "smtp rcpt to") {
+		t.Fatalf("err = %v, want rcpt error", err)
+	}
+	if m := <-got; m.data != "" {
+		t.Fatalf("message delivered after rejected rcpt:\n%s", m.data)
+	}
+}
+
+func TestSMTPNoAddress(t *testing.T) {
+	n := NewSMTP(SMTPConfig{Addr: "127.0.0.1:1", From: "noreply@subs.local"})
+	r := testReminder()
+	r.Email = ""
+	if err := n.Notify(context.Background(), r); err != ErrNoAddress {
+		t.Fatalf("err = %v, want ErrNoAddress", err)
+	}
+}
+
+func TestSMTPTimeout(t *testing.T) {
+	// сервер принимает соединение, но молчит: дедлайн соединения должен прервать диалог
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	n := NewSMTP(SMTPConfig{Addr: ln.Addr().String(), From: "noreply@subs.local", Timeout: 100 * time.Millisecond})
+	start := time.Now()
+	if err := n.Notify(context.Background(), testReminder()); err == nil {
+		t.Fatal("no error from silent server")
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("timeout not applied: took %s", time.Since(start))
+	}
+}
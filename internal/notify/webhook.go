@@ -0,0 +1,187 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"subscription-service/internal/model"
+)
+
+// ErrPrivateAddress — webhook_url ведёт на внутренний адрес: loopback, частные сети, link-local
+var ErrPrivateAddress = errors.New("webhook address is not public")
+
+// sharedAddressSpace — 100.64.0.0/10 (CGNAT), netip.Addr.IsPrivate его не покрывает
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr: webhook_url задаёт пользователь, поэтому ходим только на публичные unicast-адреса —
+// иначе через webhook можно обращаться к внутренним сервисам и метаданным облака (SSRF)
+func publicAddr(a netip.Addr) bool {
+	a = a.Unmap()
+	return a.IsGlobalUnicast() && !a.IsPrivate() && !sharedAddressSpace.Contains(a)
+}
+
+// CheckWebhookURL проверяет webhook_url при сохранении: http(s) и хост с публичными адресами.
+// Окончательная проверка — при каждом соединении: DNS-запись может измениться после сохранения.
+func CheckWebhookURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("must be an http(s) URL")
+	}
+	if a, err := netip.ParseAddr(u.Hostname()); err == nil {
+		if !publicAddr(a) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("cannot resolve host %q", u.Hostname())
+	}
+	for _, a := range addrs {
+		if !publicAddr(a) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// Webhook шлёт напоминание или предупреждение POST-запросом с JSON на webhook_url из настроек пользователя.
+// Если задан secret, тело подписывается: X-Signature: sha256=<hex HMAC-SHA256(secret, body)>.
+// Соединения на внутренние адреса и редиректы запрещены.
+type Webhook struct {
+	client *http.Client
+	secret []byte
+}
+
+func NewWebhook(secret string, timeout time.Duration) *Webhook {
+	return newWebhook(secret, timeout, publicAddr)
+}
+
+// newWebhook: allow решает, можно ли соединяться с адресом (в тестах — разрешает loopback)
+func newWebhook(secret string, timeout time.Duration, allow func(netip.Addr) bool) *Webhook {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// Control вызывается для уже разрешённого адреса, так что подмена DNS после проверки не поможет
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !allow(ap.Addr()) {
+				return fmt.Errorf("%s: %w", ap.Addr(), ErrPrivateAddress)
+			}
+			return nil
+		},
+	}
+	// без Proxy: через прокси проверялся бы адрес прокси, а не получателя
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &Webhook{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			// редирект увёл бы запрос на другой хост в обход CheckWebhookURL; 3xx — ошибка доставки
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		secret: []byte(secret),
+	}
+}
+
+// WebhookPayload — тело напоминания
+type WebhookPayload struct {
+	ReminderID   int64              `json:"reminder_id"` // одинаковый при повторах — для дедупликации у получателя
+	Kind         string             `json:"kind"`
+	DueDate      string             `json:"due_date"` // YYYY-MM-DD
+	Subject      string             `json:"subject"`
+	Text         string             `json:"text"`
+	Subscription model.Subscription `json:"subscription"`
+}
+
+func (n *Webhook) Notify(ctx context.Context, r model.Reminder) error {
+	if r.WebhookURL == "" {
+		return ErrNoAddress
+	}
+	subject, text := Text(r)
+	return n.post(ctx, r.WebhookURL, WebhookPayload{
+		ReminderID:   r.ID,
+		Kind:         r.Kind,
+		DueDate:      r.DueDate.Format("2006-01-02"),
+		Subject:      subject,
+		Text:         text,
+		Subscription: r.Subscription,
+	})
+}
+
+// BudgetKind — kind в теле предупреждения о бюджете
+const BudgetKind = "budget_exceeded"
+
+// BudgetWebhookPayload — тело предупреждения о превышении бюджета
+type BudgetWebhookPayload struct {
+	Kind    string       `json:"kind"`  // budget_exceeded
+	Month   string       `json:"month"` // YYYY-MM; пара budget.id и month — ключ для дедупликации
+	Subject string       `json:"subject"`
+	Text    string       `json:"text"`
+	Budget  model.Budget `json:"budget"`
+	Spent   int64        `json:"spent"`
+}
+
+func (n *Webhook) NotifyBudget(ctx context.Context, a model.BudgetAlert) error {
+	if a.WebhookURL == "" {
+		return ErrNoAddress
+	}
+	subject, text := BudgetText(a)
+	return n.post(ctx, a.WebhookURL, BudgetWebhookPayload{
+		Kind:    BudgetKind,
+		Month:   a.Month.Format("2006-01"),
+		Subject: subject,
+		Text:    text,
+		Budget:  a.Budget,
+		Spent:   a.Spent,
+	})
+}
+
+func (n *Webhook) post(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		mac := hmac.New(sha256.New, n.secret)
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
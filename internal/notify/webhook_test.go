@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+	}
+	for _, tt := range tests {
+		if got := publicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("publicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		private bool
+		wantErr bool
+	}{
+		{url: "https://93.184.216.34/hook"},
+		{url: "http://[2606:2800:220:1:248:1893:25c8:1946]:8080/hook"},
+		{url: "ftp://example.com/hook", wantErr: true},
+		{url: "https:///hook", wantErr: true},
+		{url: "not a url", wantErr: true},
+		{url: "http://127.0.0.1:8080/hook", private: true, wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data/", private: true, wantErr: true},
+		{url: "http://10.0.0.5/hook", private: true, wantErr: true},
+		{url: "http://[::1]/hook", private: true, wantErr: true},
+		{url: "http://localhost:9000/hook", private: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := CheckWebhookURL(context.Background(), tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckWebhookURL(%q) = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+		if errors.Is(err, ErrPrivateAddress) != tt.private {
+			t.Errorf("CheckWebhookURL(%q) = %v, want private %v", tt.url, err, tt.private)
+		}
+	}
+}
+
+func allowAll(netip.Addr) bool { return true }
+
+func TestWebhookRejectsPrivateAddress(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits.Add(1) }))
+	defer srv.Close()
+
+	r := testReminder()
+	r.WebhookURL = srv.URL // 127.0.0.1
+	err := NewWebhook("", time.Second).Notify(context.Background(), r)
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("err = %v, want ErrPrivateAddress", err)
+	}
+	if hits.Load() != 0 {
+		t.Fatal("request reached loopback server")
+	}
+}
+
+func TestWebhookDoesNotFollowRedirects(t *testing.T) {
+	var internalHits atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { internalHits.Add(1) }))
+	defer internal.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+	}))
+	defer redirect.Close()
+
+	r := testReminder()
+	r.WebhookURL = redirect.URL
+	err := newWebhook("", time.Second, allowAll).Notify(context.Background(), r)
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 307") {
+		t.Fatalf("err = %v, want unexpected status 307", err)
+	}
+	if internalHits.Load() != 0 {
+		t.Fatal("redirect was followed")
+	}
+}
+
+func TestWebhookPayloadAndSignature(t *testing.T) {
+	type request struct {
+		body      []byte
+		signature string
+	}
+	got := make(chan request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- request{body: body, signature: r.Header.Get("X-Signature")}
+	}))
+	defer srv.Close()
+
+	r := testReminder()
+	r.WebhookURL = srv.URL
+	if err := newWebhook("secret", time.Second, allowAll).Notify(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	req := <-got
+	var p WebhookPayload
+	if err := json.Unmarshal(req.body, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ReminderID != 42 || p.Kind != r.Kind || p.DueDate != "2025-08-01" || p.Subscription.ID != r.Subscription.ID {
+		t.Fatalf("payload %+v", p)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(req.body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); req.signature != want {
+		t.Fatalf("X-Signature = %q, want %q", req.signature, want)
+	}
+}
@@ -0,0 +1,145 @@
+// Package reminders — фоновый планировщик напоминаний о продлении и окончании подписок
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/metrics"
+	"subscription-service/internal/model"
+	"subscription-service/internal/notify"
+)
+
+// lease — на сколько напоминание скрыто от других проходов, пока идёт доставка
+const lease = 5 * time.Minute
+
+// TickJob — вид задачи очереди, запускающей Tick
+const TickJob = "reminders.tick"
+
+// Config: LeadDays и Channels — значения для пользователей без своих настроек;
+// MaxAttempts — попыток доставки до статуса failed; Notifiers — по каналам
+type Config struct {
+	LeadDays    int
+	Channels    []string
+	BatchSize   int
+	MaxAttempts int
+	Notifiers   map[string]notify.Notifier
+	Logger      *log.Logger
+}
+
+// Store — хранилище напоминаний (repo.RemindersRepo): Generate не создаёт дублей по
+// (subscription, kind, due_date, channel), Claim не отдаёт одно напоминание двум проходам
+type Store interface {
+	Generate(ctx context.Context, today time.Time, defLead int, defChannels []string) (int64, error)
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]model.Reminder, error)
+	MarkSent(ctx context.Context, id int64) error
+	Cancel(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, msg string, retryIn time.Duration) error
+}
+
+// Scheduler создаёт напоминания о событиях в ближайшие дни и доставляет накопившиеся.
+// Несколько реплик могут работать одновременно: создание идемпотентно, доставку разбирают
+// через SKIP LOCKED.
+type Scheduler struct {
+	repo Store
+	c    Config
+}
+
+func New(r Store, c Config) *Scheduler { return &Scheduler{repo: r, c: c} }
+
+// Tick — один проход: создание и доставка. Запускается задачей очереди по расписанию
+// (TickJob), ошибка — повод повторить задачу; ошибки доставки отдельных напоминаний
+// повторяются самими напоминаниями.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	n, err := s.repo.Generate(ctx, time.Now().UTC(), s.c.LeadDays, s.c.Channels)
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+	if n > 0 {
+		s.c.Logger.Info("reminders generated", "count", n)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch, err := s.repo.Claim(ctx, s.c.BatchSize, lease)
+		if err != nil {
+			return fmt.Errorf("claim: %w", err)
+		}
+		for _, r := range batch {
+			s.deliver(ctx, r)
+		}
+		if len(batch) < s.c.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *Scheduler) deliver(ctx context.Context, r model.Reminder) {
+	l := s.c.Logger.With("reminder_id", r.ID, "channel", r.Channel, "kind", r.Kind, "attempt", r.Attempts)
+	result := "sent"
+	defer func() { metrics.ObserveReminder(r.Channel, result) }()
+
+	if !r.Wanted || stale(r) {
+		result = "cancelled"
+		l.Debug("reminder cancelled")
+		if err := s.repo.Cancel(ctx, r.ID); err != nil {
+			l.Error("reminder cancel failed", "err", err)
+		}
+		return
+	}
+
+	n, ok := s.c.Notifiers[r.Channel]
+	var err error
+	if !ok {
+		err = errors.New("channel not configured")
+	} else {
+		err = n.Notify(ctx, r)
+	}
+	if err == nil {
+		if err := s.repo.MarkSent(ctx, r.ID); err != nil {
+			l.Error("reminder status update failed", "err", err)
+		}
+		l.Debug("reminder sent")
+		return
+	}
+
+	var retryIn time.Duration // 0 — больше не пытаемся
+	if ok && !errors.Is(err, notify.ErrNoAddress) && r.Attempts < s.c.MaxAttempts {
+		retryIn = backoff(r.Attempts)
+	}
+	if retryIn > 0 {
+		result = "retry"
+		l.Warn("reminder delivery failed, retrying", "retry_in", retryIn.String(), "err", err)
+	} else {
+		result = "failed"
+		l.Error("reminder delivery failed", "err", err)
+	}
+	if err := s.repo.MarkFailed(ctx, r.ID, err.Error(), retryIn); err != nil {
+		l.Error("reminder status update failed", "err", err)
+	}
+}
+
+// backoff — пауза перед попыткой attempt+1: 1m, 2m, 4m, … до 6h
+func backoff(attempt int) time.Duration {
+	d := time.Minute
+	for i := 1; i < attempt && d < 6*time.Hour; i++ {
+		d *= 2
+	}
+	return min(d, 6*time.Hour)
+}
+
+// stale — подписку изменили или архивировали после создания напоминания, и события уже не будет
+func stale(r model.Reminder) bool {
+	s := r.Subscription
+	if s.Status == model.StatusArchived {
+		return true
+	}
+	if r.Kind == model.ReminderEnding {
+		return s.EndDate == nil || !s.EndDate.AddDate(0, 1, 0).Equal(r.DueDate)
+	}
+	return !s.StartDate.Before(r.DueDate) || !model.Charged(s, r.DueDate)
+}
@@ -0,0 +1,345 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/notify"
+)
+
+func month(y int, m time.Month) time.Time { return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC) }
+
+func ptr[T any](v T) *T { return &v }
+
+func TestStale(t *testing.T) {
+	aug := month(2025, 8)
+	tests := []struct {
+		name   string
+		kind   string
+		due    time.Time
+		start  time.Time
+		end    *time.Time
+		period int
+		arch   bool
+		want   bool
+	}{
+		{name: "renewal of running subscription", kind: model.ReminderRenewal, due: aug, start: month(2025, 1)},
+		{name: "renewal within end date", kind: model.ReminderRenewal, due: aug, start: month(2025, 1), end: ptr(aug)},
+		{name: "renewal after end moved earlier", kind: model.ReminderRenewal, due: aug, start: month(2025, 1), end: ptr(month(2025, 7)), want: true},
+		{name: "renewal before start moved later", kind: model.ReminderRenewal, due: aug, start: aug, want: true},
+		{name: "renewal of archived", kind: model.ReminderRenewal, due: aug, start: month(2025, 1), arch: true, want: true},
+		{name: "renewal in yearly billing month", kind: model.ReminderRenewal, due: aug, start: month(2024, 8), period: model.BillingYearly},
+		{name: "renewal between yearly charges", kind: model.ReminderRenewal, due: aug, start: month(2025, 1), period: model.BillingYearly, want: true},
+		{name: "ending matches end date", kind: model.ReminderEnding, due: aug, start: month(2025, 1), end: ptr(month(2025, 7))},
+		{name: "ending after end date changed", kind: model.ReminderEnding, due: aug, start: month(2025, 1), end: ptr(month(2025, 9)), want: true},
+		{name: "ending after end date removed", kind: model.ReminderEnding, due: aug, start: month(2025, 1), want: true},
+		{name: "ending of archived", kind: model.ReminderEnding, due: aug, start: month(2025, 1), end: ptr(month(2025, 7)), arch: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := model.Reminder{Kind: tt.kind, DueDate: tt.due, Subscription: model.Subscription{
+				StartDate: tt.start, EndDate: tt.end, BillingPeriod: tt.period,
+			}}
+			if tt.arch {
+				r.Subscription.Status = model.StatusArchived
+			}
+			if got := stale(r); got != tt.want {
+				t.Fatalf("stale = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{
+		1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 10: 6 * time.Hour, 100: 6 * time.Hour,
+	} {
+		if got := backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+// reminderKey — ключ уникальности напоминания в таблице reminders
+type reminderKey struct {
+	sub     uuid.UUID
+	kind    string
+	due     time.Time
+	channel string
+}
+
+// memStore — Store в памяти с тем же контрактом, что у repo.RemindersRepo: Generate добавляет
+// planned, пропуская уже существующие ключи; Claim отдаёт pending-напоминания без активной аренды
+type memStore struct {
+	mu      sync.Mutex
+	planned []model.Reminder
+	items   map[reminderKey]*storedReminder
+	nextID  int64
+}
+
+type storedReminder struct {
+	model.Reminder
+	status  string
+	retryIn time.Duration
+	leased  bool
+}
+
+func newMemStore(planned ...model.Reminder) *memStore {
+	return &memStore{planned: planned, items: map[reminderKey]*storedReminder{}}
+}
+
+func (s *memStore) Generate(context.Context, time.Time, int, []string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, r := range s.planned {
+		k := reminderKey{r.Subscription.ID, r.Kind, r.DueDate, r.Channel}
+		if _, ok := s.items[k]; ok {
+			continue
+		}
+		s.nextID++
+		r.ID = s.nextID
+		s.items[k] = &storedReminder{Reminder: r, status: model.ReminderPending}
+		n++
+	}
+	return n, nil
+}
+
+func (s *memStore) Claim(_ context.Context, limit int, _ time.Duration) ([]model.Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []model.Reminder
+	for _, it := range s.items {
+		if len(res) == limit {
+			break
+		}
+		if it.status != model.ReminderPending || it.leased {
+			continue
+		}
+		it.Attempts++
+		it.leased = true
+		res = append(res, it.Reminder)
+	}
+	return res, nil
+}
+
+func (s *memStore) find(id int64) *storedReminder {
+	for _, it := range s.items {
+		if it.ID == id {
+			return it
+		}
+	}
+	panic(fmt.Sprintf("reminder %d not found", id))
+}
+
+func (s *memStore) MarkSent(_ context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.find(id).status = model.ReminderSent
+	return nil
+}
+
+func (s *memStore) Cancel(_ context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.find(id).status = model.ReminderCancelled
+	return nil
+}
+
+func (s *memStore) MarkFailed(_ context.Context, id int64, _ string, retryIn time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it := s.find(id)
+	it.retryIn = retryIn
+	if retryIn <= 0 {
+		it.status = model.ReminderFailed
+	}
+	return nil
+}
+
+func (s *memStore) status(r model.Reminder) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.items[reminderKey{r.Subscription.ID, r.Kind, r.DueDate, r.Channel}].status
+}
+
+// recorder — Notifier, запоминающий доставленные напоминания; err — ответ на каждую доставку
+type recorder struct {
+	mu   sync.Mutex
+	sent []model.Reminder
+	err  error
+}
+
+func (n *recorder) Notify(_ context.Context, r model.Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, r)
+	return nil
+}
+
+func (n *recorder) NotifyBudget(context.Context, model.BudgetAlert) error { return nil }
+
+func (n *recorder) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.sent)
+}
+
+func renewal(sub model.Subscription, channel string) model.Reminder {
+	return model.Reminder{
+		Kind:         model.ReminderRenewal,
+		DueDate:      month(2025, 8),
+		Channel:      channel,
+		Subscription: sub,
+		Email:        "user@example.com",
+		Wanted:       true,
+	}
+}
+
+func newTestScheduler(store Store, notifiers map[string]notify.Notifier) *Scheduler {
+	return New(store, Config{
+		LeadDays:    3,
+		Channels:    []string{model.ChannelLog},
+		BatchSize:   2,
+		MaxAttempts: 3,
+		Notifiers:   notifiers,
+		Logger:      log.FromContext(context.Background()),
+	})
+}
+
+func TestTickDedupe(t *testing.T) {
+	subA := model.Subscription{ID: uuid.New(), ServiceName: "A", Price: 100, StartDate: month(2025, 1)}
+	subB := model.Subscription{ID: uuid.New(), ServiceName: "B", Price: 200, StartDate: month(2025, 1)}
+	ending := renewal(subA, model.ChannelEmail)
+	ending.Kind = model.ReminderEnding
+	ending.Subscription.EndDate = ptr(month(2025, 7))
+
+	store := newMemStore(
+		renewal(subA, model.ChannelEmail),
+		renewal(subA, model.ChannelEmail), // повтор того же ключа
+		renewal(subA, model.ChannelWebhook),
+		renewal(subB, model.ChannelEmail),
+		ending,
+	)
+	email, webhook := &recorder{}, &recorder{}
+	s := newTestScheduler(store, map[string]notify.Notifier{model.ChannelEmail: email, model.ChannelWebhook: webhook})
+
+	// повторные проходы — как при запуске по расписанию или на нескольких репликах
+	for i := 0; i < 3; i++ {
+		if err := s.Tick(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// подписка A: renewal по email и webhook отдельно, ending — отдельно; подписка B — своё
+	if got := email.count(); got != 3 {
+		t.Errorf("email deliveries = %d, want 3", got)
+	}
+	if got := webhook.count(); got != 1 {
+		t.Errorf("webhook deliveries = %d, want 1", got)
+	}
+	seen := map[reminderKey]bool{}
+	for _, r := range append(email.sent, webhook.sent...) {
+		k := reminderKey{r.Subscription.ID, r.Kind, r.DueDate, r.Channel}
+		if seen[k] {
+			t.Errorf("reminder %+v delivered twice", k)
+		}
+		seen[k] = true
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	sub := model.Subscription{ID: uuid.New(), ServiceName: "A", Price: 100, StartDate: month(2025, 1)}
+	tests := []struct {
+		name       string
+		reminder   func() model.Reminder
+		err        error
+		attempts   int
+		wantStatus string
+		wantSent   int
+		wantRetry  bool
+	}{
+		{
+			name:       "sent",
+			reminder:   func() model.Reminder { return renewal(sub, model.ChannelEmail) },
+			wantStatus: model.ReminderSent, wantSent: 1,
+		},
+		{
+			name: "channel disabled by user",
+			reminder: func() model.Reminder {
+				r := renewal(sub, model.ChannelEmail)
+				r.Wanted = false
+				return r
+			},
+			wantStatus: model.ReminderCancelled,
+		},
+		{
+			name: "stale after end date change",
+			reminder: func() model.Reminder {
+				r := renewal(sub, model.ChannelEmail)
+				r.Subscription.EndDate = ptr(month(2025, 6))
+				return r
+			},
+			wantStatus: model.ReminderCancelled,
+		},
+		{
+			name:       "transient error retried",
+			reminder:   func() model.Reminder { return renewal(sub, model.ChannelEmail) },
+			err:        errors.New("connection refused"),
+			wantStatus: model.ReminderPending, wantRetry: true,
+		},
+		{
+			name:       "no address is final",
+			reminder:   func() model.Reminder { return renewal(sub, model.ChannelEmail) },
+			err:        notify.ErrNoAddress,
+			wantStatus: model.ReminderFailed,
+		},
+		{
+			name:       "attempts exhausted",
+			reminder:   func() model.Reminder { return renewal(sub, model.ChannelEmail) },
+			err:        errors.New("connection refused"),
+			attempts:   3,
+			wantStatus: model.ReminderFailed,
+		},
+		{
+			name:       "channel not configured",
+			reminder:   func() model.Reminder { return renewal(sub, model.ChannelWebhook) },
+			wantStatus: model.ReminderFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.reminder()
+			store := newMemStore(r)
+			if _, err := store.Generate(context.Background(), time.Time{}, 0, nil); err != nil {
+				t.Fatal(err)
+			}
+			claimed, _ := store.Claim(context.Background(), 1, lease)
+			claimed[0].Attempts += tt.attempts
+
+			email := &recorder{err: tt.err}
+			newTestScheduler(store, map[string]notify.Notifier{model.ChannelEmail: email}).deliver(context.Background(), claimed[0])
+
+			if got := store.status(r); got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+			if got := email.count(); got != tt.wantSent {
+				t.Errorf("sent = %d, want %d", got, tt.wantSent)
+			}
+			if got := store.find(claimed[0].ID).retryIn > 0; got != tt.wantRetry {
+				t.Errorf("retry scheduled = %v, want %v", got, tt.wantRetry)
+			}
+		})
+	}
+}
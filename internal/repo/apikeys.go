@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type APIKeysRepo struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewAPIKeysRepo(db *sql.DB, timeout time.Duration) *APIKeysRepo {
+	return &APIKeysRepo{db: db, timeout: timeout}
+}
+
+// scopes храним как TEXT[], через database/sql передаём строкой через запятую
+
+func (r *APIKeysRepo) Create(ctx context.Context, k model.APIKey, hash string) (_ model.APIKey, err error) {
+	q := `INSERT INTO api_keys (id, name, prefix, key_hash, scopes, expires_at)
+	      VALUES ($1,$2,$3,$4,string_to_array($5, ','),$6)
+	      RETURNING created_at`
+	ctx, end := track(ctx, r.timeout, "api_keys.Create", q)
+	defer func() { end(err) }()
+	err = r.db.QueryRowContext(ctx, q,
+		k.ID, k.Name, k.Prefix, hash, strings.Join(k.Scopes, ","), k.ExpiresAt).Scan(&k.CreatedAt)
+	return k, err
+}
+
+func (r *APIKeysRepo) List(ctx context.Context) (_ []model.APIKey, err error) {
+	q := `SELECT id, name, prefix, array_to_string(scopes, ','), expires_at, last_used_at, revoked_at, created_at
+	      FROM api_keys ORDER BY created_at DESC`
+	ctx, end := track(ctx, r.timeout, "api_keys.List", q)
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.APIKey
+	for rows.Next() {
+		var k model.APIKey
+		var scopes string
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &scopes, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt); err != nil {
+			return nil, err
+		}
+		k.Scopes = splitScopes(scopes)
+		res = append(res, k)
+	}
+	return res, rows.Err()
+}
+
+func (r *APIKeysRepo) Revoke(ctx context.Context, id uuid.UUID) (err error) {
+	q := `UPDATE api_keys SET revoked_at=now() WHERE id=$1 AND revoked_at IS NULL`
+	ctx, end := track(ctx, r.timeout, "api_keys.Revoke", q)
+	defer func() { end(err) }()
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Use находит действующий ключ по хэшу и отмечает last_used_at
+func (r *APIKeysRepo) Use(ctx context.Context, hash string) (k model.APIKey, err error) {
+	var scopes string
+	q := `UPDATE api_keys SET last_used_at=now()
+	      WHERE key_hash=$1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())
+	      RETURNING id, name, prefix, array_to_string(scopes, ','), expires_at, last_used_at, created_at`
+	ctx, end := track(ctx, r.timeout, "api_keys.Use", q)
+	defer func() { end(err) }()
+	err = r.db.QueryRowContext(ctx, q, hash).Scan(
+		&k.ID, &k.Name, &k.Prefix, &scopes, &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return k, ErrAPIKeyNotFound
+	}
+	k.Scopes = splitScopes(scopes)
+	return k, err
+}
+
+func splitScopes(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
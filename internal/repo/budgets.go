@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"subscription-service/internal/model"
+)
+
+var (
+	ErrBudgetNotFound = errors.New("budget not found")
+	// ErrBudgetExists — у пользователя уже есть бюджет на эту категорию, сервис или общий
+	ErrBudgetExists = errors.New("budget for this scope already exists")
+)
+
+// BudgetsRepo — бюджеты пользователей и отметки об отправленных предупреждениях о превышении
+type BudgetsRepo struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewBudgetsRepo(db *sql.DB, timeout time.Duration) *BudgetsRepo {
+	return &BudgetsRepo{db: db, timeout: timeout}
+}
+
+const budgetColumns = `b.id, b.user_id, COALESCE(b.category, ''), COALESCE(b.service_name, ''), b.amount, b.created_at, b.updated_at`
+
+func scanBudget(s scanner) (b model.Budget, err error) {
+	err = s.Scan(&b.ID, &b.UserID, &b.Category, &b.ServiceName, &b.Amount, &b.CreatedAt, &b.UpdatedAt)
+	return b, err
+}
+
+func budgetError(err error) error {
+	var pg *pgconn.PgError
+	if errors.As(err, &pg) && pg.Code == "23505" {
+		return ErrBudgetExists
+	}
+	return err
+}
+
+// Create: пользователь должен существовать и не быть архивированным (иначе ErrUnknownUser)
+func (r *BudgetsRepo) Create(ctx context.Context, b model.Budget) (_ model.Budget, err error) {
+	q := `INSERT INTO budgets AS b (id, user_id, category, service_name, amount)
+	      SELECT $1::uuid, u.id, NULLIF($3::text, ''), NULLIF($4::text, ''), $5::bigint
+	      FROM users u WHERE u.id=$2 AND u.archived_at IS NULL
+	      RETURNING ` + budgetColumns
+	ctx, end := track(ctx, r.timeout, "budgets.Create", q)
+	defer func() { end(err) }()
+	b, err = scanBudget(r.db.QueryRowContext(ctx, q, b.ID, b.UserID, b.Category, b.ServiceName, b.Amount))
+	if errors.Is(err, sql.ErrNoRows) {
+		return b, ErrUnknownUser
+	}
+	return b, budgetError(err)
+}
+
+// owner == nil — без ограничения по владельцу (admin), иначе чужие бюджеты не видны
+func (r *BudgetsRepo) Get(ctx context.Context, id uuid.UUID, owner *uuid.UUID) (b model.Budget, err error) {
+	q := `SELECT ` + budgetColumns + ` FROM budgets b WHERE b.id=$1 AND ($2::uuid IS NULL OR b.user_id = $2)`
+	ctx, end := track(ctx, r.timeout, "budgets.Get", q)
+	defer func() { end(err) }()
+	b, err = scanBudget(r.db.QueryRowContext(ctx, q, id, owner))
+	if errors.Is(err, sql.ErrNoRows) {
+		return b, ErrBudgetNotFound
+	}
+	return b, err
+}
+
+// List — бюджеты пользователя: общий первым, затем по категориям и сервисам
+func (r *BudgetsRepo) List(ctx context.Context, userID uuid.UUID) (_ []model.Budget, err error) {
+	q := `SELECT ` + budgetColumns + ` FROM budgets b WHERE b.user_id=$1
+	      ORDER BY (b.category IS NOT NULL OR b.service_name IS NOT NULL), b.category, b.service_name`
+	ctx, end := track(ctx, r.timeout, "budgets.List", q)
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.Budget{}
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, b)
+	}
+	return res, rows.Err()
+}
+
+// Update меняет сумму; отметки о предупреждениях сбрасываются, чтобы превышение новой суммы
+// в этом же месяце снова вызвало предупреждение
+func (r *BudgetsRepo) Update(ctx context.Context, id uuid.UUID, owner *uuid.UUID, amount int64) (b model.Budget, err error) {
+	q := `WITH reset AS (
+	          DELETE FROM budget_alerts a USING budgets b
+	          WHERE a.budget_id = b.id AND b.id=$1 AND ($2::uuid IS NULL OR b.user_id = $2))
+	      UPDATE budgets b SET amount=$3, updated_at=now()
+	      WHERE b.id=$1 AND ($2::uuid IS NULL OR b.user_id = $2)
+	      RETURNING ` + budgetColumns
+	ctx, end := track(ctx, r.timeout, "budgets.Update", q)
+	defer func() { end(err) }()
+	b, err = scanBudget(r.db.QueryRowContext(ctx, q, id, owner, amount))
+	if errors.Is(err, sql.ErrNoRows) {
+		return b, ErrBudgetNotFound
+	}
+	return b, err
+}
+
+func (r *BudgetsRepo) Delete(ctx context.Context, id uuid.UUID, owner *uuid.UUID) (err error) {
+	q := `DELETE FROM budgets WHERE id=$1 AND ($2::uuid IS NULL OR user_id = $2)`
+	ctx, end := track(ctx, r.timeout, "budgets.Delete", q)
+	defer func() { end(err) }()
+	res, err := r.db.ExecContext(ctx, q, id, owner)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// Exceeded — бюджеты активных пользователей, у которых прогноз трат за текущий месяц (по часовому
+// поясу пользователя, как Monthly за этот месяц: списания по billing_period и цене месяца) больше
+// бюджета и о превышении ещё не предупреждали
+func (r *BudgetsRepo) Exceeded(ctx context.Context) (_ []model.BudgetAlert, err error) {
+	q := `WITH cur AS (
+	          SELECT b.*, date_trunc('month', now() AT TIME ZONE u.timezone)::date AS month
+	          FROM budgets b JOIN users u ON u.id = b.user_id AND u.archived_at IS NULL)
+	      SELECT ` + budgetColumns + `, b.month, x.spent
+	      FROM cur b
+	      CROSS JOIN LATERAL (
+	          SELECT COALESCE(SUM(` + priceAt("s", "b.month") + `), 0) AS spent FROM subscriptions s
+	          WHERE s.user_id = b.user_id
+	            AND ` + chargedIn("s", "b.month") + `
+	            AND (b.service_name IS NULL OR s.service_name = b.service_name)
+	            AND (b.category IS NULL OR lower(` + categoryExpr + `) = lower(b.category))) x
+	      WHERE x.spent > b.amount
+	        AND NOT EXISTS (SELECT 1 FROM budget_alerts a WHERE a.budget_id = b.id AND a.month = b.month)`
+	ctx, end := track(ctx, r.timeout, "budgets.Exceeded", q)
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.BudgetAlert
+	for rows.Next() {
+		var a model.BudgetAlert
+		b := &a.Budget
+		if err := rows.Scan(&b.ID, &b.UserID, &b.Category, &b.ServiceName, &b.Amount, &b.CreatedAt, &b.UpdatedAt,
+			&a.Month, &a.Spent); err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	return res, rows.Err()
+}
+
+// MarkAlerted отмечает, что о превышении бюджета в месяце предупредили
+func (r *BudgetsRepo) MarkAlerted(ctx context.Context, a model.BudgetAlert) (err error) {
+	q := `INSERT INTO budget_alerts (budget_id, month, spent) VALUES ($1,$2,$3) ON CONFLICT DO NOTHING`
+	ctx, end := track(ctx, r.timeout, "budgets.MarkAlerted", q)
+	defer func() { end(err) }()
+	_, err = r.db.ExecContext(ctx, q, a.Budget.ID, a.Month, a.Spent)
+	return err
+}
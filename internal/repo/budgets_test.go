@@ -0,0 +1,198 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+)
+
+func TestBudgetsExceededBillingPeriodAndPrices(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	cur := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	user, err := NewUsersRepo(db, 0).Create(ctx, model.User{ID: uuid.New(), Timezone: "UTC", Currency: "RUB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM budgets WHERE user_id=$1`, user.ID)
+		db.Exec(`DELETE FROM subscriptions WHERE user_id=$1`, user.ID)
+		db.Exec(`DELETE FROM users WHERE id=$1`, user.ID)
+	})
+
+	subs := NewSubscriptionsRepo(db, 0)
+	create := func(name string, price int64, period int, start time.Time) model.Subscription {
+		t.Helper()
+		s, err := subs.Create(ctx, model.Subscription{
+			ID: uuid.New(), ServiceName: name, Price: price, BillingPeriod: period,
+			UserID: user.ID, StartDate: start, Status: model.StatusActive,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	budgets := NewBudgetsRepo(db, 0)
+	budget, err := budgets.Create(ctx, model.Budget{ID: uuid.New(), UserID: user.ID, Amount: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exceeded := func() (spent int64, ok bool) {
+		t.Helper()
+		alerts, err := budgets.Exceeded(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range alerts {
+			if a.Budget.ID == budget.ID {
+				if !a.Month.Equal(cur) {
+					t.Fatalf("alert month %s, want %s", a.Month, cur)
+				}
+				return a.Spent, true
+			}
+		}
+		return 0, false
+	}
+	monthly := func() int64 {
+		t.Helper()
+		ms, err := subs.Monthly(ctx, model.TotalQuery{UserID: user.ID.String(), From: cur, To: cur})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ms) == 0 {
+			return 0
+		}
+		return ms[0].Amount
+	}
+
+	// годовая подписка списалась в прошлом месяце: в текущем её цена не тратится
+	create("Yearly Last Month", 5000, model.BillingYearly, cur.AddDate(0, -1, 0))
+	// помесячная с запланированным с текущего месяца повышением 500 → 800
+	m := create("Monthly", 500, model.BillingMonthly, cur.AddDate(0, -3, 0))
+	if _, err := subs.SetPrice(ctx, m.ID, nil, model.PriceChange{Month: cur, Price: 800}); err != nil {
+		t.Fatal(err)
+	}
+	if spent, ok := exceeded(); ok {
+		t.Fatalf("exceeded with spent %d, want 800 within budget", spent)
+	}
+	if got := monthly(); got != 800 {
+		t.Fatalf("Monthly = %d, want 800", got)
+	}
+
+	// годовая подписка, продлевающаяся в текущем месяце
+	create("Yearly Renewal", 300, model.BillingYearly, cur.AddDate(-1, 0, 0))
+	spent, ok := exceeded()
+	if !ok || spent != 1100 {
+		t.Fatalf("exceeded = %v, spent %d, want 1100", ok, spent)
+	}
+	if got := monthly(); got != spent {
+		t.Fatalf("Monthly = %d, Exceeded spent = %d: must agree", got, spent)
+	}
+}
+
+func TestBudgetsExceededScopes(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	cur := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	user, err := NewUsersRepo(db, 0).Create(ctx, model.User{ID: uuid.New(), Timezone: "UTC", Currency: "RUB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM budgets WHERE user_id=$1`, user.ID)
+		db.Exec(`DELETE FROM subscriptions WHERE user_id=$1`, user.ID)
+		db.Exec(`DELETE FROM users WHERE id=$1`, user.ID)
+	})
+
+	subs := NewSubscriptionsRepo(db, 0)
+	for _, s := range []model.Subscription{
+		{ServiceName: "Netflix", Price: 800, Category: "video", CustomCategory: true},
+		{ServiceName: "Kinopoisk", Price: 400, Category: "Video", CustomCategory: true},
+		{ServiceName: "Spotify", Price: 300, Category: "music", CustomCategory: true},
+	} {
+		s.ID, s.UserID, s.StartDate, s.Status, s.BillingPeriod = uuid.New(), user.ID, cur, model.StatusActive, model.BillingMonthly
+		if _, err := subs.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	budgets := NewBudgetsRepo(db, 0)
+	tests := []struct {
+		name   string
+		budget model.Budget
+		spent  int64 // 0 — не превышен
+	}{
+		{name: "overall exceeded", budget: model.Budget{Amount: 1000}, spent: 1500},
+		{name: "category case-insensitive", budget: model.Budget{Category: "VIDEO", Amount: 1000}, spent: 1200},
+		{name: "category within", budget: model.Budget{Category: "music", Amount: 300}},
+		{name: "service exceeded", budget: model.Budget{ServiceName: "Netflix", Amount: 500}, spent: 800},
+		{name: "service within", budget: model.Budget{ServiceName: "Spotify", Amount: 1000}},
+		{name: "unknown category", budget: model.Budget{Category: "games", Amount: 1}},
+	}
+	ids := map[uuid.UUID]int{}
+	for i, tt := range tests {
+		tt.budget.ID, tt.budget.UserID = uuid.New(), user.ID
+		if _, err := budgets.Create(ctx, tt.budget); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		ids[tt.budget.ID] = i
+	}
+	exceeded := func() map[int]model.BudgetAlert {
+		t.Helper()
+		alerts, err := budgets.Exceeded(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := map[int]model.BudgetAlert{}
+		for _, a := range alerts {
+			if i, ok := ids[a.Budget.ID]; ok {
+				res[i] = a
+			}
+		}
+		return res
+	}
+
+	got := exceeded()
+	for i, tt := range tests {
+		a, ok := got[i]
+		if ok != (tt.spent > 0) || a.Spent != tt.spent {
+			t.Errorf("%s: exceeded %v, spent %d; want spent %d", tt.name, ok, a.Spent, tt.spent)
+		}
+	}
+
+	// о превышении в месяце предупреждают один раз
+	for _, a := range got {
+		if err := budgets.MarkAlerted(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+		if err := budgets.MarkAlerted(ctx, a); err != nil {
+			t.Fatalf("repeated MarkAlerted: %v", err)
+		}
+	}
+	if again := exceeded(); len(again) != 0 {
+		t.Fatalf("exceeded after MarkAlerted: %+v", again)
+	}
+	// новая сумма — новое предупреждение
+	overall := got[0].Budget
+	if _, err := budgets.Update(ctx, overall.ID, &user.ID, 1100); err != nil {
+		t.Fatal(err)
+	}
+	if again := exceeded(); len(again) != 1 || again[0].Spent != 1500 {
+		t.Fatalf("exceeded after Update = %+v, want overall budget again", again)
+	}
+
+	// второй бюджет на ту же область
+	_, err = budgets.Create(ctx, model.Budget{ID: uuid.New(), UserID: user.ID, Category: "video", Amount: 1})
+	if !errors.Is(err, ErrBudgetExists) {
+		t.Fatalf("duplicate scope: err %v, want ErrBudgetExists", err)
+	}
+}
@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// EventsChannel — канал LISTEN/NOTIFY событий подписок
+const EventsChannel = "subscription_events"
+
+// EventsRepo — доставка событий между репликами через LISTEN/NOTIFY
+type EventsRepo struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewEventsRepo(db *sql.DB, timeout time.Duration) *EventsRepo {
+	return &EventsRepo{db: db, timeout: timeout}
+}
+
+// Notify присваивает событию ID из общей последовательности, подставляет его в payload (JSON-объект,
+// поле id) и отправляет в канал; возвращает ID
+func (r *EventsRepo) Notify(ctx context.Context, payload []byte) (id int64, err error) {
+	q := `WITH e AS (SELECT nextval('subscription_events_seq') AS id)
+	      SELECT e.id, pg_notify('` + EventsChannel + `', jsonb_set($1::jsonb, '{id}', to_jsonb(e.id))::text) FROM e`
+	ctx, end := track(ctx, r.timeout, "events.Notify", q)
+	defer func() { end(err) }()
+	var ignored string
+	err = r.db.QueryRowContext(ctx, q, string(payload)).Scan(&id, &ignored)
+	return id, err
+}
+
+// Listen держит отдельное соединение в LISTEN и вызывает fn на каждое уведомление,
+// пока не отменён ctx или не оборвалось соединение. Соединение после этого не возвращается в пул.
+func (r *EventsRepo) Listen(ctx context.Context, ready func(), fn func(payload []byte)) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var listenErr error
+	_ = conn.Raw(func(dc any) error {
+		pc := dc.(*stdlib.Conn).Conn()
+		if _, listenErr = pc.Exec(ctx, "LISTEN "+EventsChannel); listenErr != nil {
+			return driver.ErrBadConn
+		}
+		ready()
+		for {
+			n, err := pc.WaitForNotification(ctx)
+			if err != nil {
+				listenErr = err
+				return driver.ErrBadConn // соединение в состоянии LISTEN в пул не отдаём
+			}
+			fn([]byte(n.Payload))
+		}
+	})
+	return listenErr
+}
@@ -0,0 +1,244 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"subscription-service/internal/model"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobState — операция недопустима в текущем статусе задачи (retry для выполняемой и т.п.)
+	ErrJobState = errors.New("job state does not allow this operation")
+)
+
+type JobsRepo struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewJobsRepo(db *sql.DB, timeout time.Duration) *JobsRepo {
+	return &JobsRepo{db: db, timeout: timeout}
+}
+
+const jobColumns = `id, kind, payload::text, status, attempts, max_attempts, run_at, locked_until, last_error,
+	unique_key, created_at, updated_at, finished_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(s scanner) (j model.Job, err error) {
+	var payload string
+	err = s.Scan(&j.ID, &j.Kind, &payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.RunAt, &j.LockedUntil, &j.LastError,
+		&j.UniqueKey, &j.CreatedAt, &j.UpdatedAt, &j.FinishedAt)
+	j.Payload = json.RawMessage(payload)
+	return j, err
+}
+
+// Enqueue добавляет задачу к выполнению не раньше j.RunAt (нулевое — сразу). Задача с уже
+// существующим unique_key не создаётся: inserted=false.
+func (r *JobsRepo) Enqueue(ctx context.Context, j model.Job) (_ model.Job, inserted bool, err error) {
+	q := `INSERT INTO jobs (kind, payload, max_attempts, run_at, unique_key)
+	      VALUES ($1, $2::jsonb, $3, now() + make_interval(secs => $4::float8), $5)
+	      ON CONFLICT (unique_key) WHERE unique_key IS NOT NULL DO NOTHING
+	      RETURNING ` + jobColumns
+	ctx, end := track(ctx, r.timeout, "jobs.Enqueue", q)
+	defer func() { end(err) }()
+	// время — через задержку от now() БД: колонки без часового пояса, часы реплик могут расходиться
+	var delay time.Duration
+	if !j.RunAt.IsZero() {
+		delay = max(time.Until(j.RunAt), 0)
+	}
+	j, err = scanJob(r.db.QueryRowContext(ctx, q, j.Kind, string(j.Payload), j.MaxAttempts, delay.Seconds(), j.UniqueKey))
+	if errors.Is(err, sql.ErrNoRows) {
+		return j, false, nil
+	}
+	return j, err == nil, err
+}
+
+// Claim забирает до limit готовых задач указанных видов и помечает их running до now()+lease.
+// Задачи running с истёкшим locked_until (воркер упал) забираются повторно.
+func (r *JobsRepo) Claim(ctx context.Context, kinds []string, limit int, lease time.Duration) (_ []model.Job, err error) {
+	q := `WITH c AS (
+	          SELECT id FROM jobs
+	          WHERE kind = ANY($1::text[])
+	            AND ((status = '` + model.JobQueued + `' AND run_at <= now())
+	              OR (status = '` + model.JobRunning + `' AND locked_until < now()))
+	          ORDER BY run_at LIMIT $2
+	          FOR UPDATE SKIP LOCKED)
+	      UPDATE jobs j
+	      SET status = '` + model.JobRunning + `', attempts = j.attempts + 1,
+	          locked_until = now() + make_interval(secs => $3::float8), updated_at = now()
+	      FROM c WHERE j.id = c.id
+	      RETURNING ` + prefixed("j", jobColumns)
+	ctx, end := track(ctx, r.timeout, "jobs.Claim", q)
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, q, kinds, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, j)
+	}
+	return res, rows.Err()
+}
+
+// prefixed добавляет алиас таблицы к списку колонок (в UPDATE ... FROM имена неоднозначны)
+func prefixed(alias, cols string) string {
+	parts := strings.Split(cols, ",")
+	for i, p := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(p)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Ниже attempt — номер попытки из Claim: результат устаревшей попытки (задачу уже
+// перехватил другой воркер или отменили) не записывается
+
+func (r *JobsRepo) Complete(ctx context.Context, id int64, attempt int) (err error) {
+	q := `UPDATE jobs SET status='` + model.JobSucceeded + `', locked_until=NULL, finished_at=now(), updated_at=now()
+	      WHERE id=$1 AND attempts=$2 AND status='` + model.JobRunning + `'`
+	ctx, end := track(ctx, r.timeout, "jobs.Complete", q)
+	defer func() { end(err) }()
+	_, err = r.db.ExecContext(ctx, q, id, attempt)
+	return err
+}
+
+// Fail записывает ошибку: retryIn > 0 — задача вернётся в очередь через retryIn, иначе failed
+func (r *JobsRepo) Fail(ctx context.Context, id int64, attempt int, msg string, retryIn time.Duration) (err error) {
+	q := `UPDATE jobs
+	      SET last_error=$3, locked_until=NULL, updated_at=now(),
+	          status = CASE WHEN $4::float8 > 0 THEN '` + model.JobQueued + `' ELSE '` + model.JobFailed + `' END,
+	          run_at = CASE WHEN $4::float8 > 0 THEN now() + make_interval(secs => $4::float8) ELSE run_at END,
+	          finished_at = CASE WHEN $4::float8 > 0 THEN NULL ELSE now() END
+	      WHERE id=$1 AND attempts=$2 AND status='` + model.JobRunning + `'`
+	ctx, end := track(ctx, r.timeout, "jobs.Fail", q)
+	defer func() { end(err) }()
+	_, err = r.db.ExecContext(ctx, q, id, attempt, msg, retryIn.Seconds())
+	return err
+}
+
+// Release возвращает прерванную задачу в очередь, не засчитывая попытку (остановка воркера)
+func (r *JobsRepo) Release(ctx context.Context, id int64, attempt int) (err error) {
+	q := `UPDATE jobs SET status='` + model.JobQueued + `', attempts=attempts-1, locked_until=NULL, run_at=now(), updated_at=now()
+	      WHERE id=$1 AND attempts=$2 AND status='` + model.JobRunning + `'`
+	ctx, end := track(ctx, r.timeout, "jobs.Release", q)
+	defer func() { end(err) }()
+	_, err = r.db.ExecContext(ctx, q, id, attempt)
+	return err
+}
+
+func (r *JobsRepo) Get(ctx context.Context, id int64) (j model.Job, err error) {
+	q := `SELECT ` + jobColumns + ` FROM jobs WHERE id=$1`
+	ctx, end := track(ctx, r.timeout, "jobs.Get", q)
+	defer func() { end(err) }()
+	j, err = scanJob(r.db.QueryRowContext(ctx, q, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return j, ErrJobNotFound
+	}
+	return j, err
+}
+
+func (r *JobsRepo) List(ctx context.Context, q model.JobQuery) (_ []model.Job, err error) {
+	sb := strings.Builder{}
+	sb.WriteString(`SELECT ` + jobColumns + ` FROM jobs WHERE 1=1`)
+	var args []any
+	if q.Kind != "" {
+		sb.WriteString(fmt.Sprintf(` AND kind = $%d`, len(args)+1))
+		args = append(args, q.Kind)
+	}
+	if q.Status != "" {
+		sb.WriteString(fmt.Sprintf(` AND status = $%d`, len(args)+1))
+		args = append(args, q.Status)
+	}
+	sb.WriteString(` ORDER BY id DESC`)
+	sb.WriteString(fmt.Sprintf(` LIMIT %d OFFSET %d`, q.Limit, q.Offset))
+
+	ctx, end := track(ctx, r.timeout, "jobs.List", sb.String())
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, j)
+	}
+	return res, rows.Err()
+}
+
+// Retry ставит завершившуюся неуспешно или отменённую задачу в очередь заново, с нулём попыток
+func (r *JobsRepo) Retry(ctx context.Context, id int64) (j model.Job, err error) {
+	q := `UPDATE jobs SET status='` + model.JobQueued + `', attempts=0, run_at=now(), locked_until=NULL,
+	          finished_at=NULL, updated_at=now()
+	      WHERE id=$1 AND status IN ('` + model.JobFailed + `', '` + model.JobCancelled + `')
+	      RETURNING ` + jobColumns
+	ctx, end := track(ctx, r.timeout, "jobs.Retry", q)
+	defer func() { end(err) }()
+	j, err = scanJob(r.db.QueryRowContext(ctx, q, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return j, r.stateError(ctx, id)
+	}
+	return j, err
+}
+
+// Cancel отменяет ожидающую или выполняемую задачу. Уже запущенный обработчик не прерывается,
+// но его результат не записывается и повторов не будет.
+func (r *JobsRepo) Cancel(ctx context.Context, id int64) (j model.Job, err error) {
+	q := `UPDATE jobs SET status='` + model.JobCancelled + `', locked_until=NULL, finished_at=now(), updated_at=now()
+	      WHERE id=$1 AND status IN ('` + model.JobQueued + `', '` + model.JobRunning + `')
+	      RETURNING ` + jobColumns
+	ctx, end := track(ctx, r.timeout, "jobs.Cancel", q)
+	defer func() { end(err) }()
+	j, err = scanJob(r.db.QueryRowContext(ctx, q, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return j, r.stateError(ctx, id)
+	}
+	return j, err
+}
+
+// stateError различает «нет такой задачи» и «не тот статус»
+func (r *JobsRepo) stateError(ctx context.Context, id int64) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM jobs WHERE id=$1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrJobNotFound
+	}
+	return ErrJobState
+}
+
+// Purge удаляет задачи, завершённые больше olderThan назад
+func (r *JobsRepo) Purge(ctx context.Context, olderThan time.Duration) (n int64, err error) {
+	q := `DELETE FROM jobs
+	      WHERE status IN ('` + model.JobSucceeded + `', '` + model.JobFailed + `', '` + model.JobCancelled + `')
+	        AND finished_at < now() - make_interval(secs => $1::float8)`
+	ctx, end := track(ctx, r.timeout, "jobs.Purge", q)
+	defer func() { end(err) }()
+	res, err := r.db.ExecContext(ctx, q, olderThan.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
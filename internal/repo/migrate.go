@@ -0,0 +1,463 @@
+package repo
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"subscription-service/internal/log"
+)
+
+// migrationLockID — ключ pg_advisory_lock: одновременно миграции гоняет только одна реплика
+const migrationLockID int64 = 0x5375_6273_4d69_6772 // "SubsMigr"
+
+// Migration — пара NNN_name.up.sql / NNN_name.down.sql (файл NNN_name.sql считается up без down)
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // sha256 up-файла
+}
+
+// MigrationStatus — строка для status: применена ли версия и совпадает ли файл с применённым
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Modified  bool // файл изменён после применения
+	Missing   bool // версия применена, но файла нет
+}
+
+var (
+	ErrChecksumMismatch = errors.New("migration file changed after it was applied")
+	ErrNoDownMigration  = errors.New("no down migration")
+
+	migrationFile = regexp.MustCompile(`^(\d+)_(.+?)(?:\.(up|down))?\.sql$`)
+)
+
+// LoadMigrations читает миграции из fsys (embed.FS или os.DirFS) в порядке версий
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVer := map[int64]*Migration{}
+	hasUp := map[int64]bool{} // up-файл может быть пустым, наличие — отдельно от содержимого
+	for _, e := range entries {
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		ver, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mg := byVer[ver]
+		if mg == nil {
+			mg = &Migration{Version: ver, Name: m[2]}
+			byVer[ver] = mg
+		} else if mg.Name != m[2] {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", ver, mg.Name, m[2])
+		}
+		if m[3] == "down" {
+			mg.Down = string(body)
+			continue
+		}
+		if hasUp[ver] {
+			return nil, fmt.Errorf("migration %d: duplicate up file %s", ver, e.Name())
+		}
+		hasUp[ver] = true
+		sum := sha256.Sum256(body)
+		mg.Up, mg.Checksum = string(body), hex.EncodeToString(sum[:])
+	}
+	res := make([]Migration, 0, len(byVer))
+	for _, mg := range byVer {
+		if !hasUp[mg.Version] {
+			return nil, fmt.Errorf("migration %d_%s: down file without up", mg.Version, mg.Name)
+		}
+		res = append(res, *mg)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res, nil
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator применяет и откатывает миграции: каждая в своей транзакции, под advisory lock,
+// с проверкой контрольных сумм уже применённых файлов
+type Migrator struct {
+	db     *sql.DB
+	fsys   fs.FS
+	logger *log.Logger
+}
+
+func NewMigrator(db *sql.DB, fsys fs.FS, logger *log.Logger) *Migrator {
+	return &Migrator{db: db, fsys: fsys, logger: logger}
+}
+
+// Up применяет до n ожидающих миграций (n <= 0 — все) и возвращает число применённых
+func (m *Migrator) Up(ctx context.Context, n int) (count int, err error) {
+	err = m.locked(ctx, func(conn *sql.Conn, files []Migration, applied map[int64]appliedMigration) error {
+		if err := m.verify(files, applied, false); err != nil {
+			return err
+		}
+		for _, mg := range pendingMigrations(files, applied, n) {
+			err := m.inTx(ctx, conn, mg.Up,
+				`INSERT INTO schema_migrations(version, checksum) VALUES($1, $2)`, mg.Version, mg.Checksum)
+			if err != nil {
+				return fmt.Errorf("apply %d_%s: %w", mg.Version, mg.Name, err)
+			}
+			m.logger.Info("migration applied", "version", mg.Version, "name", mg.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down откатывает n последних применённых миграций (n <= 0 — одну)
+func (m *Migrator) Down(ctx context.Context, n int) (count int, err error) {
+	if n <= 0 {
+		n = 1
+	}
+	err = m.locked(ctx, func(conn *sql.Conn, files []Migration, applied map[int64]appliedMigration) error {
+		if err := m.verify(files, applied, false); err != nil {
+			return err
+		}
+		revert, err := revertMigrations(files, applied, n)
+		if err != nil {
+			return err
+		}
+		for _, mg := range revert {
+			err := m.inTx(ctx, conn, mg.Down, `DELETE FROM schema_migrations WHERE version=$1`, mg.Version)
+			if err != nil {
+				return fmt.Errorf("revert %d_%s: %w", mg.Version, mg.Name, err)
+			}
+			m.logger.Info("migration reverted", "version", mg.Version, "name", mg.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// pendingMigrations — неприменённые миграции по возрастанию версий, не больше n (n <= 0 — все)
+func pendingMigrations(files []Migration, applied map[int64]appliedMigration, n int) []Migration {
+	var res []Migration
+	for _, mg := range files {
+		if n > 0 && len(res) == n {
+			break
+		}
+		if _, ok := applied[mg.Version]; !ok {
+			res = append(res, mg)
+		}
+	}
+	return res
+}
+
+// revertMigrations — n последних применённых миграций от новой к старой; если у какой-то
+// из них нет down-файла, ошибка возвращается до отката первой
+func revertMigrations(files []Migration, applied map[int64]appliedMigration, n int) ([]Migration, error) {
+	var res []Migration
+	for i := len(files) - 1; i >= 0 && len(res) < n; i-- {
+		mg := files[i]
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+		if mg.Down == "" {
+			return nil, fmt.Errorf("revert %d_%s: %w", mg.Version, mg.Name, ErrNoDownMigration)
+		}
+		res = append(res, mg)
+	}
+	return res, nil
+}
+
+// Force помечает применёнными все версии <= version (с текущими контрольными суммами)
+// и снимает отметку с более новых, не выполняя SQL — для ручного восстановления после сбоя
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	return m.locked(ctx, func(conn *sql.Conn, files []Migration, _ map[int64]appliedMigration) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version::bigint > $1`, version); err != nil {
+			return err
+		}
+		for _, mg := range files {
+			if mg.Version > version {
+				break
+			}
+			if _, err := tx.ExecContext(ctx, `
+INSERT INTO schema_migrations(version, checksum) VALUES($1, $2)
+ON CONFLICT (version) DO UPDATE SET checksum = EXCLUDED.checksum`, strconv.FormatInt(mg.Version, 10), mg.Checksum); err != nil {
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		m.logger.Warn("migration version forced", "version", version)
+		return nil
+	})
+}
+
+// Status — все известные версии: из файлов и из schema_migrations
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	files, err := LoadMigrations(m.fsys)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	var res []MigrationStatus
+	for _, mg := range files {
+		st := MigrationStatus{Version: mg.Version, Name: mg.Name}
+		if a, ok := applied[mg.Version]; ok {
+			st.Applied, st.AppliedAt = true, &a.appliedAt
+			st.Modified = a.checksum != "" && a.checksum != mg.Checksum
+			delete(applied, mg.Version)
+		}
+		res = append(res, st)
+	}
+	for ver, a := range applied {
+		res = append(res, MigrationStatus{Version: ver, Applied: true, AppliedAt: &a.appliedAt, Missing: true})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res, nil
+}
+
+// Pending — число неприменённых миграций
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	st, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, s := range st {
+		if !s.Applied {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Validate проверяет файлы и БД: контрольные суммы, применённые версии без файлов
+func (m *Migrator) Validate(ctx context.Context) error {
+	files, err := LoadMigrations(m.fsys)
+	if err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return err
+	}
+	return m.verify(files, applied, true)
+}
+
+// verify: изменённый после применения файл — всегда ошибка; применённая версия без файла —
+// ошибка только в strict (при раскатке старая версия приложения может увидеть новые миграции)
+func (m *Migrator) verify(files []Migration, applied map[int64]appliedMigration, strict bool) error {
+	var errs []error
+	known := map[int64]bool{}
+	for _, mg := range files {
+		known[mg.Version] = true
+		if a, ok := applied[mg.Version]; ok && a.checksum != "" && a.checksum != mg.Checksum {
+			errs = append(errs, fmt.Errorf("%d_%s: %w", mg.Version, mg.Name, ErrChecksumMismatch))
+		}
+	}
+	for ver := range applied {
+		if known[ver] {
+			continue
+		}
+		if strict {
+			errs = append(errs, fmt.Errorf("version %d is applied but its file is missing", ver))
+		} else {
+			m.logger.Warn("applied migration has no file", "version", ver)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// locked выполняет fn на отдельном соединении под pg_advisory_lock: lock сессионный,
+// поэтому все запросы должны идти через одно соединение
+func (m *Migrator) locked(ctx context.Context, fn func(*sql.Conn, []Migration, map[int64]appliedMigration) error) error {
+	files, err := LoadMigrations(m.fsys)
+	if err != nil {
+		return err
+	}
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			m.logger.Error("release migration lock failed", "err", err)
+		}
+	}()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if err := m.adopt(ctx, conn, files, applied); err != nil {
+		return err
+	}
+	return fn(conn, files, applied)
+}
+
+// ensureTable создаёт schema_migrations и переводит старый формат (version = имя файла, без checksum)
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	for _, q := range []string{
+		`CREATE TABLE IF NOT EXISTS schema_migrations (version TEXT PRIMARY KEY, applied_at TIMESTAMP NOT NULL DEFAULT now())`,
+		`ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum TEXT`,
+		`UPDATE schema_migrations SET version = (split_part(version, '_', 1)::bigint)::text WHERE version LIKE '%.sql'`,
+	} {
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			return fmt.Errorf("prepare schema_migrations: %w", err)
+		}
+	}
+	return nil
+}
+
+// adopt записывает контрольные суммы версиям, применённым старым раннером
+func (m *Migrator) adopt(ctx context.Context, conn *sql.Conn, files []Migration, applied map[int64]appliedMigration) error {
+	for _, mg := range files {
+		a, ok := applied[mg.Version]
+		if !ok || a.checksum != "" {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, `UPDATE schema_migrations SET checksum=$2 WHERE version=$1`,
+			strconv.FormatInt(mg.Version, 10), mg.Checksum); err != nil {
+			return err
+		}
+		a.checksum = mg.Checksum
+		applied[mg.Version] = a
+		m.logger.Info("migration checksum recorded", "version", mg.Version)
+	}
+	return nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// applied читает schema_migrations без DDL (status/readyz): таблицы может ещё не быть,
+// а в старом формате нет checksum и version — имя файла
+func (m *Migrator) applied(ctx context.Context, q queryer) (map[int64]appliedMigration, error) {
+	res := map[int64]appliedMigration{}
+	var exists bool
+	if err := queryRow(ctx, q, `SELECT to_regclass('schema_migrations') IS NOT NULL`, &exists); err != nil || !exists {
+		return res, err
+	}
+	rows, err := q.QueryContext(ctx,
+		`SELECT version, COALESCE(to_jsonb(s)->>'checksum', ''), applied_at FROM schema_migrations s`)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			ver string
+			a   appliedMigration
+		)
+		if err := rows.Scan(&ver, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		num, _, _ := strings.Cut(ver, "_")
+		v, err := strconv.ParseInt(num, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("schema_migrations: bad version %q", ver)
+		}
+		res[v] = a
+	}
+	return res, rows.Err()
+}
+
+func queryRow(ctx context.Context, q queryer, query string, dest ...any) error {
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return sql.ErrNoRows
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// inTx выполняет SQL миграции и запись в schema_migrations одной транзакцией
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, body, record string, version int64, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, append([]any{strconv.FormatInt(version, 10)}, args...)...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CreateMigration создаёт в dir пару up/down (только комментарий-заголовок) со следующим номером версии
+func CreateMigration(dir, name string) (up, down string, err error) {
+	slug := strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return "", "", fmt.Errorf("invalid migration name %q", name)
+	}
+	files, err := LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	next := int64(1)
+	if len(files) > 0 {
+		next = files[len(files)-1].Version + 1
+	}
+	base := filepath.Join(dir, fmt.Sprintf("%03d_%s", next, slug))
+	up, down = base+".up.sql", base+".down.sql"
+	for f, kind := range map[string]string{up: "up", down: "down"} {
+		header := fmt.Sprintf("-- %03d_%s: %s\n", next, slug, kind)
+		if err := os.WriteFile(f, []byte(header), 0o644); err != nil {
+			return "", "", err
+		}
+	}
+	return up, down, nil
+}
@@ -0,0 +1,316 @@
+package repo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"subscription-service/internal/log"
+	"subscription-service/migrations"
+)
+
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func versions(ms []Migration) []int64 {
+	res := make([]int64, len(ms))
+	for i, m := range ms {
+		res[i] = m.Version
+	}
+	return res
+}
+
+func equalVersions(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_tenth.up.sql":     {Data: []byte("CREATE TABLE ten();")},
+		"010_tenth.down.sql":   {Data: []byte("DROP TABLE ten;")},
+		"002_second.up.sql":    {Data: []byte("CREATE TABLE two();")},
+		"002_second.down.sql":  {Data: []byte("DROP TABLE two;")},
+		"001_legacy.sql":       {Data: []byte("CREATE TABLE one();")},
+		"README.md":            {Data: []byte("not a migration")},
+		"notes.sql":            {Data: []byte("-- no version")},
+		"003_dir.up.sql/x.sql": {Data: []byte("nested")},
+	}
+	got, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// порядок числовой, а не лексикографический по имени
+	if v := versions(got); !equalVersions(v, []int64{1, 2, 10}) {
+		t.Fatalf("versions = %v", v)
+	}
+
+	legacy := got[0]
+	if legacy.Name != "legacy" || legacy.Up != "CREATE TABLE one();" || legacy.Down != "" {
+		t.Errorf("legacy file parsed as %+v", legacy)
+	}
+	second := got[1]
+	if second.Name != "second" || second.Up != "CREATE TABLE two();" || second.Down != "DROP TABLE two;" {
+		t.Errorf("up/down pair parsed as %+v", second)
+	}
+	for _, m := range got {
+		if m.Checksum != checksum(m.Up) {
+			t.Errorf("%d: checksum %s is not sha256 of up file", m.Version, m.Checksum)
+		}
+	}
+}
+
+func TestLoadMigrationsChecksumIgnoresDown(t *testing.T) {
+	a, err := LoadMigrations(fstest.MapFS{
+		"001_x.up.sql":   {Data: []byte("CREATE TABLE x();")},
+		"001_x.down.sql": {Data: []byte("DROP TABLE x;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadMigrations(fstest.MapFS{
+		"001_x.up.sql":   {Data: []byte("CREATE TABLE x();")},
+		"001_x.down.sql": {Data: []byte("DROP TABLE IF EXISTS x;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a[0].Checksum != b[0].Checksum {
+		t.Fatal("changing down file changed checksum")
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{
+			name: "conflicting names",
+			fsys: fstest.MapFS{
+				"001_a.up.sql":   {Data: []byte("SELECT 1;")},
+				"001_b.down.sql": {Data: []byte("SELECT 1;")},
+			},
+			want: "conflicting names",
+		},
+		{
+			name: "duplicate up",
+			fsys: fstest.MapFS{
+				"001_a.sql":    {Data: []byte("SELECT 1;")},
+				"001_a.up.sql": {Data: []byte("SELECT 2;")},
+			},
+			want: "duplicate up file",
+		},
+		{
+			name: "down without up",
+			fsys: fstest.MapFS{
+				"001_a.down.sql": {Data: []byte("SELECT 1;")},
+			},
+			want: "down file without up",
+		},
+		{
+			name: "version overflow",
+			fsys: fstest.MapFS{
+				"99999999999999999999_a.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			want: "out of range",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	files, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range files {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s: versions must be contiguous, want %d", m.Version, m.Name, i+1)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
+
+func testMigrations() []Migration {
+	return []Migration{
+		{Version: 1, Name: "one", Up: "u1", Down: "d1", Checksum: checksum("u1")},
+		{Version: 2, Name: "two", Up: "u2", Down: "d2", Checksum: checksum("u2")},
+		{Version: 3, Name: "three", Up: "u3", Checksum: checksum("u3")},
+		{Version: 4, Name: "four", Up: "u4", Down: "d4", Checksum: checksum("u4")},
+	}
+}
+
+func appliedSet(vers ...int64) map[int64]appliedMigration {
+	res := map[int64]appliedMigration{}
+	for _, v := range vers {
+		res[v] = appliedMigration{checksum: checksum("u" + strconv.FormatInt(v, 10))}
+	}
+	return res
+}
+
+func TestPendingMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		applied map[int64]appliedMigration
+		n       int
+		want    []int64
+	}{
+		{name: "fresh database", applied: appliedSet(), want: []int64{1, 2, 3, 4}},
+		{name: "limit", applied: appliedSet(), n: 2, want: []int64{1, 2}},
+		{name: "partially applied", applied: appliedSet(1, 2), want: []int64{3, 4}},
+		{name: "gap is filled in order", applied: appliedSet(1, 3), want: []int64{2, 4}},
+		{name: "gap with limit", applied: appliedSet(1, 3), n: 1, want: []int64{2}},
+		{name: "up to date", applied: appliedSet(1, 2, 3, 4), want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := versions(pendingMigrations(testMigrations(), tt.applied, tt.n))
+			if !equalVersions(got, tt.want) {
+				t.Fatalf("pending = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevertMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		applied map[int64]appliedMigration
+		n       int
+		want    []int64
+		wantErr error
+	}{
+		{name: "latest first", applied: appliedSet(1, 2), n: 1, want: []int64{2}},
+		{name: "several newest to oldest", applied: appliedSet(1, 2), n: 5, want: []int64{2, 1}},
+		{name: "skips unapplied", applied: appliedSet(1, 2, 4), n: 2, want: []int64{4, 2}},
+		{name: "nothing applied", applied: appliedSet(), n: 1, want: nil},
+		{name: "missing down stops before any revert", applied: appliedSet(1, 2, 3, 4), n: 2, wantErr: ErrNoDownMigration},
+		{name: "missing down beyond n is fine", applied: appliedSet(1, 2, 3, 4), n: 1, want: []int64{4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := revertMigrations(testMigrations(), tt.applied, tt.n)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if v := versions(got); !equalVersions(v, tt.want) {
+				t.Fatalf("revert = %v, want %v", v, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	m := &Migrator{logger: log.FromContext(context.Background())}
+	files := testMigrations()
+	tests := []struct {
+		name     string
+		applied  map[int64]appliedMigration
+		strict   bool
+		mismatch bool
+		wantErr  bool
+	}{
+		{name: "clean", applied: appliedSet(1, 2)},
+		{name: "legacy row without checksum", applied: map[int64]appliedMigration{1: {}}},
+		{
+			name:     "modified after apply",
+			applied:  map[int64]appliedMigration{1: {checksum: checksum("u1")}, 2: {checksum: checksum("edited")}},
+			mismatch: true, wantErr: true,
+		},
+		{name: "applied without file tolerated", applied: appliedSet(1, 9)},
+		{name: "applied without file strict", applied: appliedSet(1, 9), strict: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.verify(files, tt.applied, tt.strict)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrChecksumMismatch) != tt.mismatch {
+				t.Fatalf("err = %v, want checksum mismatch %v", err, tt.mismatch)
+			}
+		})
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "007_existing.up.sql"), []byte("SELECT 1;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	up, down, err := CreateMigration(dir, "Add  Users-Table!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(up) != "008_add_users_table.up.sql" || filepath.Base(down) != "008_add_users_table.down.sql" {
+		t.Fatalf("created %s, %s", up, down)
+	}
+	if _, _, err := CreateMigration(dir, "!!!"); err == nil {
+		t.Fatal("empty slug accepted")
+	}
+}
+
+func TestCreateMigrationTwice(t *testing.T) {
+	dir := t.TempDir()
+	for i, name := range []string{"first", "second", "third"} {
+		up, _, err := CreateMigration(dir, name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if want := fmt.Sprintf("%03d_%s.up.sql", i+1, name); filepath.Base(up) != want {
+			t.Fatalf("created %s, want %s", filepath.Base(up), want)
+		}
+	}
+	files, err := LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || files[2].Name != "third" || files[2].Up == "" || files[2].Checksum == "" {
+		t.Fatalf("loaded %+v", files)
+	}
+}
+
+func TestLoadMigrationsEmptyUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_empty.up.sql":   {Data: nil},
+		"001_empty.down.sql": {Data: nil},
+	}
+	files, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Checksum != checksum("") {
+		t.Fatalf("loaded %+v", files)
+	}
+}
@@ -5,10 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"io/fs"
-	"os"
-	"path/filepath"
-	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,132 +13,324 @@ import (
 	_ "github.com/jackc/pgx/v5/stdlib"
 
 	"subscription-service/internal/log"
+	"subscription-service/internal/metrics"
 	"subscription-service/internal/model"
+	"subscription-service/internal/tracing"
 )
 
-func NewPostgres(dsn string, logger *log.Logger) (*sql.DB, error) {
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	// ConnectTimeout — сколько повторять подключение при старте (0 — одна попытка)
+	ConnectTimeout time.Duration
+}
+
+func NewPostgres(dsn string, pool PoolOptions, logger *log.Logger) (*sql.DB, error) {
 	db, err := sql.Open("pgx", dsn)
 	if err != nil {
 		return nil, err
 	}
-	db.SetMaxOpenConns(16)
-	db.SetMaxIdleConns(16)
-	db.SetConnMaxLifetime(30 * time.Minute)
-	if err := db.Ping(); err != nil {
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	if err := ping(db, pool.ConnectTimeout, logger); err != nil {
+		db.Close()
 		return nil, err
 	}
 	logger.Info("db connected")
 	return db, nil
 }
 
-// ApplyMigrations — простой раннер .sql миграций
-func ApplyMigrations(db *sql.DB, dir string, logger *log.Logger) error {
-	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version TEXT PRIMARY KEY, applied_at TIMESTAMP NOT NULL DEFAULT now())`)
-	applied := map[string]bool{}
-	rows, err := db.Query(`SELECT version FROM schema_migrations`)
-	if err == nil {
-		for rows.Next() {
-			var v string
-			_ = rows.Scan(&v)
-			applied[v] = true
+// ping ждёт готовности БД (в docker-compose Postgres поднимается дольше приложения):
+// повторяет попытки с удваивающейся паузой до 5s, пока не истечёт timeout
+func ping(db *sql.DB, timeout time.Duration, logger *log.Logger) error {
+	deadline := time.Now().Add(timeout)
+	backoff := 250 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
 		}
-		rows.Close()
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("db not ready after %d attempts: %w", attempt, err)
+		}
+		logger.Warn("db not ready, retrying", "attempt", attempt, "retry_in", backoff.String(), "err", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, 5*time.Second)
 	}
+}
+
+// --- Repository ---
+
+var (
+	ErrNotFound = errors.New("subscription not found")
+	// ErrSubscriptionArchived — архивную подписку нельзя изменить, только удалить
+	ErrSubscriptionArchived = errors.New("subscription archived")
+)
 
-	var files []string
-	if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-		if d != nil && !d.IsDir() && strings.HasSuffix(d.Name(), ".sql") {
-			files = append(files, path)
+// track открывает span запроса (SQL без значений), ограничивает запрос по времени (timeout > 0)
+// и возвращает завершение, которое пишет длительность в метрики и лог запроса, а ошибки — в span:
+// defer func() { end(err) }()
+func track(ctx context.Context, timeout time.Duration, method, query string) (context.Context, func(error)) {
+	start := time.Now()
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	ctx, span := tracing.StartQuery(ctx, method, query)
+	return ctx, func(err error) {
+		cancel()
+		l := log.FromContext(ctx)
+		if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrAPIKeyNotFound) && !errors.Is(err, ErrUserNotFound) {
+			tracing.RecordError(span, err)
+			l.Error("query failed", "method", method, "err", err)
 		}
-		return nil
-	}); err != nil {
-		return err
+		span.End()
+		metrics.ObserveQuery(method, start)
+		l.Debug("query", "method", method, "duration_ms", time.Since(start).Milliseconds())
 	}
-	sort.Strings(files)
+}
 
-	for _, f := range files {
-		ver := filepath.Base(f)
-		if applied[ver] {
-			continue
-		}
-		sqlBytes, err := os.ReadFile(f)
+type SubscriptionsRepo struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// timeout — statement timeout каждого запроса (0 — без ограничения)
+func NewSubscriptionsRepo(db *sql.DB, timeout time.Duration) *SubscriptionsRepo {
+	return &SubscriptionsRepo{db: db, timeout: timeout}
+}
+
+// subscriptionColumns — порядок колонок для scanSubscription (таблица — под псевдонимом s).
+// Категория — своя или сервиса из каталога, метки и изменения цены ("YYYY-MM:price") собираются
+// строками через запятую.
+const subscriptionColumns = `s.id, s.service_name, s.price, s.user_id, s.start_date, s.end_date, s.status,
+	` + categoryExpr + `, s.category IS NOT NULL,
+	COALESCE((SELECT string_agg(t.tag, ',' ORDER BY t.tag) FROM subscription_tags t WHERE t.subscription_id = s.id), ''),
+	s.billing_period,
+	COALESCE((SELECT string_agg(to_char(p.month, 'YYYY-MM') || ':' || p.price, ',' ORDER BY p.month)
+	          FROM subscription_prices p WHERE p.subscription_id = s.id), ''),
+	s.created_at, s.updated_at`
+
+// categoryExpr — категория подписки s: своя или сервиса из каталога (ключ — model.ServiceKey)
+const categoryExpr = `COALESCE(s.category, (SELECT c.category FROM service_names n JOIN services c ON c.id = n.service_id
+	WHERE n.key = lower(btrim(regexp_replace(s.service_name, '\s+', ' ', 'g')))), '')`
+
+// priceAt — цена подписки alias, действующая в месяце month: последнее изменение не позже него или price
+func priceAt(alias, month string) string {
+	return `COALESCE((SELECT pc.price FROM subscription_prices pc WHERE pc.subscription_id = ` + alias + `.id
+	          AND pc.month <= ` + month + ` ORDER BY pc.month DESC LIMIT 1), ` + alias + `.price)`
+}
+
+// chargedIn — подписка alias идёт в месяце month (первое число) и в нём списание: раз в billing_period
+// месяцев от start_date (та же логика, что generate_series в periodMonths и model.Charged)
+func chargedIn(alias, month string) string {
+	return `(date_trunc('month', ` + alias + `.start_date) <= ` + month + `
+	          AND (` + alias + `.end_date IS NULL OR date_trunc('month', ` + alias + `.end_date) >= ` + month + `)
+	          AND mod(((extract(year FROM ` + month + `) - extract(year FROM ` + alias + `.start_date)) * 12
+	                   + extract(month FROM ` + month + `) - extract(month FROM ` + alias + `.start_date))::int,
+	                  ` + alias + `.billing_period) = 0)`
+}
+
+func scanSubscription(sc scanner) (s model.Subscription, err error) {
+	var tags, prices string
+	err = sc.Scan(&s.ID, &s.ServiceName, &s.Price, &s.UserID, &s.StartDate, &s.EndDate, &s.Status,
+		&s.Category, &s.CustomCategory, &tags, &s.BillingPeriod, &prices, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return s, err
+	}
+	s.Tags = splitTags(tags)
+	s.PriceChanges, err = splitPrices(prices)
+	return s, err
+}
+
+// splitTags: метки не содержат запятых (см. service.normalizeTags)
+func splitTags(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// splitPrices разбирает изменения цены из subscriptionColumns
+func splitPrices(s string) ([]model.PriceChange, error) {
+	res := []model.PriceChange{}
+	if s == "" {
+		return res, nil
+	}
+	for _, it := range strings.Split(s, ",") {
+		ym, price, _ := strings.Cut(it, ":")
+		month, err := model.ParseYearMonth(ym)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("price change %q: %w", it, err)
 		}
-		if _, err := db.Exec(string(sqlBytes)); err != nil {
-			return fmt.Errorf("apply %s: %w", ver, err)
-		}
-		if _, err := db.Exec(`INSERT INTO schema_migrations(version) VALUES($1)`, ver); err != nil {
-			return err
+		p, err := strconv.ParseInt(price, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("price change %q: %w", it, err)
 		}
-		logger.Info("migration applied", "file", ver)
+		res = append(res, model.PriceChange{Month: month, Price: p})
 	}
-	return nil
+	return res, nil
 }
 
-// --- Repository ---
-
-type SubscriptionsRepo struct{ db *sql.DB }
-
-func NewSubscriptionsRepo(db *sql.DB) *SubscriptionsRepo { return &SubscriptionsRepo{db: db} }
+// customCategory — значение колонки category: NULL, если категория не задана для подписки
+func customCategory(s model.Subscription) *string {
+	if !s.CustomCategory {
+		return nil
+	}
+	return &s.Category
+}
 
-func (r *SubscriptionsRepo) Create(ctx context.Context, s model.Subscription) (model.Subscription, error) {
-	q := `INSERT INTO subscriptions (id, service_name, price, user_id, start_date, end_date)
-	      VALUES ($1,$2,$3,$4,$5,$6)
-	      RETURNING created_at, updated_at`
-	err := r.db.QueryRowContext(ctx, q,
-		s.ID, s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate).Scan(&s.CreatedAt, &s.UpdatedAt)
+// Create: пользователь должен существовать и не быть архивированным (иначе ErrUnknownUser);
+// FOR SHARE не даёт архивировать или удалить его, пока подписка создаётся
+func (r *SubscriptionsRepo) Create(ctx context.Context, s model.Subscription) (_ model.Subscription, err error) {
+	q := `WITH ins AS (
+	          INSERT INTO subscriptions AS s (id, service_name, price, user_id, start_date, end_date, status, category, billing_period)
+	          SELECT $1::uuid,$2::text,$3::bigint,u.id,$5::date,$6::date,$7::text,$8::text,$10::smallint
+	          FROM users u WHERE u.id=$4 AND u.archived_at IS NULL FOR SHARE
+	          RETURNING ` + categoryExpr + `, created_at, updated_at),
+	      tags AS (
+	          INSERT INTO subscription_tags (subscription_id, tag)
+	          SELECT $1::uuid, unnest(string_to_array($9, ',')) FROM ins)
+	      SELECT * FROM ins`
+	ctx, end := track(ctx, r.timeout, "subscriptions.Create", q)
+	defer func() { end(err) }()
+	err = r.db.QueryRowContext(ctx, q,
+		s.ID, s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate, s.Status, customCategory(s), strings.Join(s.Tags, ","),
+		s.BillingPeriod).
+		Scan(&s.Category, &s.CreatedAt, &s.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrUnknownUser
+	}
 	return s, err
 }
 
-func (r *SubscriptionsRepo) GetByID(ctx context.Context, id uuid.UUID) (model.Subscription, error) {
-	var s model.Subscription
-	q := `SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
-	      FROM subscriptions WHERE id=$1`
-	err := r.db.QueryRowContext(ctx, q, id).Scan(
-		&s.ID, &s.ServiceName, &s.Price, &s.UserID, &s.StartDate, &s.EndDate, &s.CreatedAt, &s.UpdatedAt)
+// owner == nil — без ограничения по владельцу (admin), иначе чужие подписки не видны
+func (r *SubscriptionsRepo) GetByID(ctx context.Context, id uuid.UUID, owner *uuid.UUID) (s model.Subscription, err error) {
+	q := `SELECT ` + subscriptionColumns + `
+	      FROM subscriptions s WHERE s.id=$1 AND ($2::uuid IS NULL OR s.user_id = $2)`
+	ctx, end := track(ctx, r.timeout, "subscriptions.GetByID", q)
+	defer func() { end(err) }()
+	s, err = scanSubscription(r.db.QueryRowContext(ctx, q, id, owner))
 	if errors.Is(err, sql.ErrNoRows) {
-		return s, fmt.Errorf("subscription not found")
+		return s, ErrNotFound
 	}
 	return s, err
 }
 
-func (r *SubscriptionsRepo) Update(ctx context.Context, s model.Subscription) (model.Subscription, error) {
-	q := `UPDATE subscriptions SET service_name=$2, price=$3, start_date=$4, end_date=$5, updated_at=now()
-	      WHERE id=$1 RETURNING updated_at`
-	err := r.db.QueryRowContext(ctx, q, s.ID, s.ServiceName, s.Price, s.StartDate, s.EndDate).
-		Scan(&s.UpdatedAt)
+// Update записывает поля подписки (кроме меток и изменений цены) и возвращает её с категорией после изменения
+func (r *SubscriptionsRepo) Update(ctx context.Context, s model.Subscription, owner *uuid.UUID) (_ model.Subscription, err error) {
+	q := `UPDATE subscriptions s SET service_name=$2, price=$3, start_date=$4, end_date=$5, status=$7, category=$8,
+	          billing_period=$9, updated_at=now()
+	      WHERE s.id=$1 AND ($6::uuid IS NULL OR s.user_id = $6) RETURNING ` + subscriptionColumns
+	ctx, end := track(ctx, r.timeout, "subscriptions.Update", q)
+	defer func() { end(err) }()
+	s, err = scanSubscription(r.db.QueryRowContext(ctx, q,
+		s.ID, s.ServiceName, s.Price, s.StartDate, s.EndDate, owner, s.Status, customCategory(s), s.BillingPeriod))
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrNotFound
+	}
 	return s, err
 }
 
-func (r *SubscriptionsRepo) Delete(ctx context.Context, id uuid.UUID) error {
-	res, err := r.db.ExecContext(ctx, `DELETE FROM subscriptions WHERE id=$1`, id)
+// AddTags добавляет метки (уже имеющиеся пропускаются) и возвращает подписку
+func (r *SubscriptionsRepo) AddTags(ctx context.Context, id uuid.UUID, owner *uuid.UUID, tags []string) (_ model.Subscription, err error) {
+	q := `WITH sub AS (
+	          UPDATE subscriptions s SET updated_at=now()
+	          WHERE s.id=$1 AND ($2::uuid IS NULL OR s.user_id = $2) RETURNING s.id)
+	      INSERT INTO subscription_tags (subscription_id, tag)
+	      SELECT sub.id, unnest(string_to_array($3, ',')) FROM sub
+	      ON CONFLICT DO NOTHING`
+	return r.change(ctx, "subscriptions.AddTags", q, id, owner, strings.Join(tags, ","))
+}
+
+// RemoveTag снимает метку (если её нет — не ошибка) и возвращает подписку
+func (r *SubscriptionsRepo) RemoveTag(ctx context.Context, id uuid.UUID, owner *uuid.UUID, tag string) (_ model.Subscription, err error) {
+	q := `WITH sub AS (
+	          UPDATE subscriptions s SET updated_at=now()
+	          WHERE s.id=$1 AND ($2::uuid IS NULL OR s.user_id = $2) RETURNING s.id)
+	      DELETE FROM subscription_tags t USING sub WHERE t.subscription_id = sub.id AND t.tag = $3`
+	return r.change(ctx, "subscriptions.RemoveTag", q, id, owner, tag)
+}
+
+// SetPrice планирует цену с месяца c.Month (изменение на тот же месяц заменяется) и возвращает подписку
+func (r *SubscriptionsRepo) SetPrice(ctx context.Context, id uuid.UUID, owner *uuid.UUID, c model.PriceChange) (_ model.Subscription, err error) {
+	q := `WITH sub AS (
+	          UPDATE subscriptions s SET updated_at=now()
+	          WHERE s.id=$1 AND ($2::uuid IS NULL OR s.user_id = $2) RETURNING s.id)
+	      INSERT INTO subscription_prices (subscription_id, month, price)
+	      SELECT sub.id, $3::date, $4::bigint FROM sub
+	      ON CONFLICT (subscription_id, month) DO UPDATE SET price = EXCLUDED.price`
+	return r.change(ctx, "subscriptions.SetPrice", q, id, owner, c.Month, c.Price)
+}
+
+// RemovePrice отменяет изменение цены с месяца month (если его нет — не ошибка) и возвращает подписку
+func (r *SubscriptionsRepo) RemovePrice(ctx context.Context, id uuid.UUID, owner *uuid.UUID, month time.Time) (_ model.Subscription, err error) {
+	q := `WITH sub AS (
+	          UPDATE subscriptions s SET updated_at=now()
+	          WHERE s.id=$1 AND ($2::uuid IS NULL OR s.user_id = $2) RETURNING s.id)
+	      DELETE FROM subscription_prices p USING sub WHERE p.subscription_id = sub.id AND p.month = $3::date`
+	return r.change(ctx, "subscriptions.RemovePrice", q, id, owner, month)
+}
+
+// change выполняет запрос q, меняющий метки или цены подписки ($1 — id, $2 — owner, далее args),
+// и возвращает подписку
+func (r *SubscriptionsRepo) change(ctx context.Context, method, q string, id uuid.UUID, owner *uuid.UUID, args ...any) (_ model.Subscription, err error) {
+	tctx, end := track(ctx, r.timeout, method, q)
+	_, err = r.db.ExecContext(tctx, q, append([]any{id, owner}, args...)...)
+	end(err)
 	if err != nil {
-		return err
+		return model.Subscription{}, err
 	}
-	n, _ := res.RowsAffected()
-	if n == 0 {
-		return fmt.Errorf("subscription not found")
+	return r.GetByID(ctx, id, owner)
+}
+
+// Delete возвращает удалённую подписку
+func (r *SubscriptionsRepo) Delete(ctx context.Context, id uuid.UUID, owner *uuid.UUID) (s model.Subscription, err error) {
+	q := `DELETE FROM subscriptions s WHERE s.id=$1 AND ($2::uuid IS NULL OR s.user_id = $2)
+	      RETURNING ` + subscriptionColumns
+	ctx, end := track(ctx, r.timeout, "subscriptions.Delete", q)
+	defer func() { end(err) }()
+	s, err = scanSubscription(r.db.QueryRowContext(ctx, q, id, owner))
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrNotFound
 	}
-	return nil
+	return s, err
 }
 
-func (r *SubscriptionsRepo) List(ctx context.Context, q model.ListQuery) ([]model.Subscription, error) {
+func (r *SubscriptionsRepo) List(ctx context.Context, q model.ListQuery) (_ []model.Subscription, err error) {
 	sb := strings.Builder{}
-	sb.WriteString(`SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
-	                FROM subscriptions WHERE 1=1`)
+	sb.WriteString(`SELECT ` + subscriptionColumns + `
+	                FROM subscriptions s WHERE 1=1`)
 	var args []any
 	if q.UserID != "" {
-		sb.WriteString(fmt.Sprintf(` AND user_id = $%d`, len(args)+1))
+		sb.WriteString(fmt.Sprintf(` AND s.user_id = $%d`, len(args)+1))
 		args = append(args, q.UserID)
 	}
 	if q.ServiceName != "" {
-		sb.WriteString(fmt.Sprintf(` AND service_name = $%d`, len(args)+1))
+		sb.WriteString(fmt.Sprintf(` AND s.service_name = $%d`, len(args)+1))
 		args = append(args, q.ServiceName)
 	}
-	sb.WriteString(` ORDER BY created_at DESC`)
+	if q.Status != "" {
+		sb.WriteString(fmt.Sprintf(` AND s.status = $%d`, len(args)+1))
+		args = append(args, q.Status)
+	}
+	if q.Category != "" {
+		sb.WriteString(fmt.Sprintf(` AND lower(`+categoryExpr+`) = lower($%d)`, len(args)+1))
+		args = append(args, q.Category)
+	}
+	if q.Tag != "" {
+		sb.WriteString(fmt.Sprintf(` AND EXISTS (SELECT 1 FROM subscription_tags t WHERE t.subscription_id = s.id AND t.tag = $%d)`, len(args)+1))
+		args = append(args, q.Tag)
+	}
+	sb.WriteString(` ORDER BY s.created_at DESC`)
 	sb.WriteString(fmt.Sprintf(` LIMIT %d OFFSET %d`, q.Limit, q.Offset))
 
+	ctx, end := track(ctx, r.timeout, "subscriptions.List", sb.String())
+	defer func() { end(err) }()
 	rows, err := r.db.QueryContext(ctx, sb.String(), args...)
 	if err != nil {
 		return nil, err
@@ -150,8 +339,8 @@ func (r *SubscriptionsRepo) List(ctx context.Context, q model.ListQuery) ([]mode
 
 	var res []model.Subscription
 	for rows.Next() {
-		var s model.Subscription
-		if err := rows.Scan(&s.ID, &s.ServiceName, &s.Price, &s.UserID, &s.StartDate, &s.EndDate, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		s, err := scanSubscription(rows)
+		if err != nil {
 			return nil, err
 		}
 		res = append(res, s)
@@ -159,33 +348,203 @@ func (r *SubscriptionsRepo) List(ctx context.Context, q model.ListQuery) ([]mode
 	return res, nil
 }
 
-// Total: суммарная стоимость подписок по месяцам в интервале [From..To] (YYYY-MM)
-func (r *SubscriptionsRepo) Total(ctx context.Context, q model.TotalQuery) (int64, error) {
-	sqlQ := `
+// ListByUsers — все подписки пользователей ids (без пагинации), новые первыми
+func (r *SubscriptionsRepo) ListByUsers(ctx context.Context, ids []uuid.UUID) (_ []model.Subscription, err error) {
+	q := `SELECT ` + subscriptionColumns + `
+	      FROM subscriptions s WHERE s.user_id = ANY($1::uuid[]) ORDER BY s.created_at DESC`
+	ctx, end := track(ctx, r.timeout, "subscriptions.ListByUsers", q)
+	defer func() { end(err) }()
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	rows, err := r.db.QueryContext(ctx, q, strs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+// periodMonths — CTE "months": по строке на каждый месяц списания в интервале [$1..$2] (от start_date
+// раз в billing_period месяцев) с ценой, действующей в этом месяце
+// ($3 — user_id, $4 — service_name, $5 — категория, $6 — метка; пустая строка — без фильтра)
+var periodMonths = `
 WITH bounds AS (
   SELECT date_trunc('month', $1::date) AS from_m,
          date_trunc('month', $2::date) AS to_m
 ),
 filtered AS (
-  SELECT s.*
+  SELECT s.*, ` + categoryExpr + ` AS eff_category
   FROM subscriptions s, bounds b
   WHERE s.user_id = $3
     AND ( $4 = '' OR s.service_name = $4)
+    AND ( $5 = '' OR lower(` + categoryExpr + `) = lower($5))
+    AND ( $6 = '' OR EXISTS (SELECT 1 FROM subscription_tags t WHERE t.subscription_id = s.id AND t.tag = $6))
     AND date_trunc('month', s.start_date) <= b.to_m
     AND date_trunc('month', COALESCE(s.end_date, b.to_m)) >= b.from_m
 ),
 months AS (
-  SELECT f.id, f.price, gs::date AS month
+  SELECT f.id, ` + priceAt("f", "gs") + ` AS price,
+         f.service_name, f.eff_category AS category, gs::date AS month
   FROM filtered f, bounds b,
        generate_series(
-         GREATEST(date_trunc('month', f.start_date), b.from_m),
+         date_trunc('month', f.start_date),
          LEAST(date_trunc('month', COALESCE(f.end_date, b.to_m)), b.to_m),
-         interval '1 month'
+         make_interval(months => f.billing_period)
        ) AS gs
-)
+  WHERE gs >= b.from_m
+)`
+
+// Total: суммарная стоимость подписок по месяцам в интервале [From..To] (YYYY-MM)
+func (r *SubscriptionsRepo) Total(ctx context.Context, q model.TotalQuery) (total int64, err error) {
+	sqlQ := periodMonths + `
 SELECT COALESCE(SUM(price),0) FROM months;
 `
-	var total int64
-	err := r.db.QueryRowContext(ctx, sqlQ, q.From, q.To, q.UserID, q.ServiceName).Scan(&total)
+	ctx, end := track(ctx, r.timeout, "subscriptions.Total", sqlQ)
+	defer func() { end(err) }()
+	err = r.db.QueryRowContext(ctx, sqlQ, q.From, q.To, q.UserID, q.ServiceName, q.Category, q.Tag).Scan(&total)
 	return total, err
 }
+
+// TotalBy: стоимость за интервал [From..To] по категориям (model.GroupByCategory) или меткам
+// (model.GroupByTag). Подписка без категории или меток попадает в группу с пустым ключом;
+// подписка с несколькими метками учитывается в каждой из них.
+func (r *SubscriptionsRepo) TotalBy(ctx context.Context, q model.TotalQuery, groupBy string) (_ []model.GroupAmount, err error) {
+	var sqlQ string
+	switch groupBy {
+	case model.GroupByCategory:
+		sqlQ = periodMonths + `
+SELECT category, SUM(price) FROM months
+GROUP BY category
+ORDER BY 2 DESC, 1;
+`
+	case model.GroupByTag:
+		sqlQ = periodMonths + `
+SELECT COALESCE(t.tag, ''), SUM(m.price) FROM months m
+LEFT JOIN subscription_tags t ON t.subscription_id = m.id
+GROUP BY 1
+ORDER BY 2 DESC, 1;
+`
+	default:
+		return nil, fmt.Errorf("unknown group_by %q", groupBy)
+	}
+	ctx, end := track(ctx, r.timeout, "subscriptions.TotalBy", sqlQ)
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, sqlQ, q.From, q.To, q.UserID, q.ServiceName, q.Category, q.Tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.GroupAmount{}
+	for rows.Next() {
+		var g model.GroupAmount
+		if err := rows.Scan(&g.Key, &g.Amount); err != nil {
+			return nil, err
+		}
+		res = append(res, g)
+	}
+	return res, rows.Err()
+}
+
+// Monthly: стоимость за интервал [From..To] по месяцам; месяцы без подписок не возвращаются
+func (r *SubscriptionsRepo) Monthly(ctx context.Context, q model.TotalQuery) (_ []model.MonthAmount, err error) {
+	sqlQ := periodMonths + `
+SELECT month, SUM(price) FROM months
+GROUP BY month
+ORDER BY month;
+`
+	ctx, end := track(ctx, r.timeout, "subscriptions.Monthly", sqlQ)
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, sqlQ, q.From, q.To, q.UserID, q.ServiceName, q.Category, q.Tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.MonthAmount
+	for rows.Next() {
+		var m model.MonthAmount
+		if err := rows.Scan(&m.Month, &m.Amount); err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+// Breakdown: стоимость за интервал [From..To] по месяцам и сервисам
+func (r *SubscriptionsRepo) Breakdown(ctx context.Context, q model.TotalQuery) (_ []model.BreakdownItem, err error) {
+	sqlQ := periodMonths + `
+SELECT month, service_name, SUM(price) FROM months
+GROUP BY month, service_name
+ORDER BY month, service_name;
+`
+	ctx, end := track(ctx, r.timeout, "subscriptions.Breakdown", sqlQ)
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, sqlQ, q.From, q.To, q.UserID, q.ServiceName, q.Category, q.Tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.BreakdownItem
+	for rows.Next() {
+		var it model.BreakdownItem
+		if err := rows.Scan(&it.Month, &it.ServiceName, &it.Amount); err != nil {
+			return nil, err
+		}
+		res = append(res, it)
+	}
+	return res, rows.Err()
+}
+
+// Stats: активные в текущем месяце подписки и их суммарная стоимость в пересчёте на месяц
+// (цена текущего месяца, делённая на период оплаты)
+func (r *SubscriptionsRepo) Stats(ctx context.Context) (st metrics.BusinessStats, err error) {
+	q := `
+SELECT COUNT(*), COALESCE(SUM(` + priceAt("s", "date_trunc('month', now())") + ` / s.billing_period),0)
+FROM subscriptions s
+WHERE status <> '` + model.StatusArchived + `'
+  AND date_trunc('month', start_date) <= date_trunc('month', now())
+  AND (end_date IS NULL OR date_trunc('month', end_date) >= date_trunc('month', now()))`
+	ctx, end := track(ctx, r.timeout, "subscriptions.Stats", q)
+	defer func() { end(err) }()
+	err = r.db.QueryRowContext(ctx, q).Scan(&st.ActiveSubscriptions, &st.MRR)
+	return st, err
+}
+
+// RecalculateStatuses приводит status к текущему месяцу (та же логика, что model.StatusAt)
+// и возвращает число изменённых подписок; архивные не трогает
+func (r *SubscriptionsRepo) RecalculateStatuses(ctx context.Context) (n int64, err error) {
+	q := `
+WITH calc AS (
+  SELECT id, CASE
+    WHEN date_trunc('month', start_date) > date_trunc('month', now()) THEN 'upcoming'
+    WHEN end_date IS NOT NULL AND date_trunc('month', end_date) < date_trunc('month', now()) THEN 'expired'
+    ELSE 'active'
+  END AS status
+  FROM subscriptions
+  WHERE status <> '` + model.StatusArchived + `'
+)
+UPDATE subscriptions s SET status = calc.status, updated_at = now()
+FROM calc
+WHERE s.id = calc.id AND s.status <> calc.status`
+	ctx, end := track(ctx, 0, "subscriptions.RecalculateStatuses", q)
+	defer func() { end(err) }()
+	res, err := r.db.ExecContext(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
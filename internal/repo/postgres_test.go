@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+)
+
+func TestTotalBillingPeriodAndPrices(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	ym := func(y int, m time.Month) time.Time { return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC) }
+
+	user, err := NewUsersRepo(db, 0).Create(ctx, model.User{ID: uuid.New(), Timezone: "UTC", Currency: "RUB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM subscriptions WHERE user_id=$1`, user.ID)
+		db.Exec(`DELETE FROM users WHERE id=$1`, user.ID)
+	})
+
+	r := NewSubscriptionsRepo(db, 0)
+	var subs []model.Subscription
+	for _, s := range []struct {
+		s      model.Subscription
+		change model.PriceChange
+	}{
+		{
+			s:      model.Subscription{ServiceName: "Monthly", Price: 100, BillingPeriod: model.BillingMonthly, StartDate: ym(2025, 1)},
+			change: model.PriceChange{Month: ym(2025, 6), Price: 150},
+		},
+		{
+			s:      model.Subscription{ServiceName: "Yearly", Price: 1200, BillingPeriod: model.BillingYearly, StartDate: ym(2025, 3)},
+			change: model.PriceChange{Month: ym(2025, 9), Price: 1500},
+		},
+	} {
+		s.s.ID, s.s.UserID, s.s.Status = uuid.New(), user.ID, model.StatusActive
+		if _, err := r.Create(ctx, s.s); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.SetPrice(ctx, s.s.ID, nil, s.change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.BillingPeriod != s.s.BillingPeriod || len(got.PriceChanges) != 1 || got.PriceChanges[0] != s.change {
+			t.Fatalf("stored %+v, want billing period %d and price change %+v", got, s.s.BillingPeriod, s.change)
+		}
+		subs = append(subs, got)
+	}
+
+	from, to := ym(2025, 1), ym(2026, 12)
+	var want int64
+	for _, s := range subs {
+		want += model.CostInPeriod(s, from, to)
+	}
+	// 100×5 + 150×19 за помесячную, 1200 + 1500 за годовую (списания 2025-03 и 2026-03)
+	if want != 500+150*19+1200+1500 {
+		t.Fatalf("CostInPeriod sum = %d", want)
+	}
+	total, err := r.Total(ctx, model.TotalQuery{UserID: user.ID.String(), From: from, To: to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != want {
+		t.Fatalf("Total = %d, want %d (CostInPeriod)", total, want)
+	}
+
+	months, err := r.Monthly(ctx, model.TotalQuery{UserID: user.ID.String(), From: ym(2026, 3), To: ym(2026, 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(months) != 1 || months[0].Amount != 150+1500 {
+		t.Fatalf("Monthly 2026-03 = %+v, want 1650", months)
+	}
+
+	if _, err := r.RemovePrice(ctx, subs[1].ID, nil, ym(2025, 9)); err != nil {
+		t.Fatal(err)
+	}
+	total, err = r.Total(ctx, model.TotalQuery{UserID: user.ID.String(), ServiceName: "Yearly", From: from, To: to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2400 {
+		t.Fatalf("Total after RemovePrice = %d, want 2400", total)
+	}
+}
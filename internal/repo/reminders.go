@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+)
+
+var ErrPreferencesNotFound = errors.New("notification preferences not found")
+
+type RemindersRepo struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewRemindersRepo(db *sql.DB, timeout time.Duration) *RemindersRepo {
+	return &RemindersRepo{db: db, timeout: timeout}
+}
+
+// Generate создаёт напоминания о событиях в окне (today, today+lead_days] по настройкам
+// пользователя (без настроек — defLead и defChannels):
+//   - renewal — продление первого числа следующего месяца, если подписка идёт и в нём
+//     и это месяц списания (раз в billing_period месяцев от start_date);
+//   - ending — окончание: первое число месяца после end_date.
+//
+// Уже созданные напоминания пропускаются (UNIQUE), возвращает число новых.
+func (r *RemindersRepo) Generate(ctx context.Context, today time.Time, defLead int, defChannels []string) (n int64, err error) {
+	q := `INSERT INTO reminders (subscription_id, user_id, kind, due_date, channel)
+	      SELECT s.id, s.user_id, k.kind, k.due, ch
+	      FROM subscriptions s
+	      LEFT JOIN notification_preferences p ON p.user_id = s.user_id
+	      CROSS JOIN LATERAL (VALUES
+	          ('` + model.ReminderRenewal + `', (date_trunc('month', $1::date) + interval '1 month')::date),
+	          ('` + model.ReminderEnding + `', (s.end_date + interval '1 month')::date)
+	      ) AS k(kind, due)
+	      CROSS JOIN LATERAL unnest(COALESCE(p.channels, string_to_array($3, ','))) AS ch
+	      WHERE COALESCE(p.enabled, true) AND s.status <> '` + model.StatusArchived + `'
+	        AND k.due > $1::date AND k.due <= $1::date + COALESCE(p.lead_days, $2)
+	        AND (k.kind = '` + model.ReminderEnding + `' OR (s.start_date < k.due AND ` + chargedIn("s", "k.due") + `))
+	      ON CONFLICT DO NOTHING`
+	ctx, end := track(ctx, r.timeout, "reminders.Generate", q)
+	defer func() { end(err) }()
+	res, err := r.db.ExecContext(ctx, q, today.Format("2006-01-02"), defLead, strings.Join(defChannels, ","))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Claim забирает до limit напоминаний, которым пора уйти, и откладывает их следующую попытку
+// на lease: если процесс упадёт посреди доставки, напоминание подберёт следующий проход.
+// SKIP LOCKED — реплики не берут одно и то же напоминание. Price подписки — цена месяца due_date.
+func (r *RemindersRepo) Claim(ctx context.Context, limit int, lease time.Duration) (_ []model.Reminder, err error) {
+	q := `WITH c AS (
+	          SELECT id FROM reminders
+	          WHERE status = '` + model.ReminderPending + `' AND next_attempt_at <= now()
+	          ORDER BY next_attempt_at LIMIT $1
+	          FOR UPDATE SKIP LOCKED)
+	      UPDATE reminders r
+	      SET attempts = r.attempts + 1, next_attempt_at = now() + make_interval(secs => $2::float8)
+	      FROM c, subscriptions s
+	      LEFT JOIN notification_preferences p ON p.user_id = s.user_id
+	      WHERE r.id = c.id AND s.id = r.subscription_id
+	      RETURNING r.id, r.kind, r.due_date, r.channel, r.attempts,
+	          s.id, s.service_name, ` + priceAt("s", "r.due_date") + `, s.billing_period,
+	          s.user_id, s.start_date, s.end_date, s.status, s.created_at, s.updated_at,
+	          COALESCE(p.email, ''), COALESCE(p.webhook_url, ''), COALESCE(p.enabled AND r.channel = ANY(p.channels), true)`
+	ctx, end := track(ctx, r.timeout, "reminders.Claim", q)
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, q, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.Reminder
+	for rows.Next() {
+		var m model.Reminder
+		s := &m.Subscription
+		if err := rows.Scan(&m.ID, &m.Kind, &m.DueDate, &m.Channel, &m.Attempts,
+			&s.ID, &s.ServiceName, &s.Price, &s.BillingPeriod, &s.UserID, &s.StartDate, &s.EndDate, &s.Status, &s.CreatedAt, &s.UpdatedAt,
+			&m.Email, &m.WebhookURL, &m.Wanted); err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+func (r *RemindersRepo) MarkSent(ctx context.Context, id int64) (err error) {
+	q := `UPDATE reminders SET status='` + model.ReminderSent + `', sent_at=now(), last_error=NULL WHERE id=$1`
+	ctx, end := track(ctx, r.timeout, "reminders.MarkSent", q)
+	defer func() { end(err) }()
+	_, err = r.db.ExecContext(ctx, q, id)
+	return err
+}
+
+// Cancel снимает неактуальное напоминание с доставки
+func (r *RemindersRepo) Cancel(ctx context.Context, id int64) (err error) {
+	q := `UPDATE reminders SET status='` + model.ReminderCancelled + `' WHERE id=$1`
+	ctx, end := track(ctx, r.timeout, "reminders.Cancel", q)
+	defer func() { end(err) }()
+	_, err = r.db.ExecContext(ctx, q, id)
+	return err
+}
+
+// MarkFailed записывает ошибку доставки: retryIn > 0 — повторить через retryIn, иначе попытки исчерпаны
+func (r *RemindersRepo) MarkFailed(ctx context.Context, id int64, msg string, retryIn time.Duration) (err error) {
+	q := `UPDATE reminders
+	      SET last_error=$2,
+	          status = CASE WHEN $3::float8 > 0 THEN status ELSE '` + model.ReminderFailed + `' END,
+	          next_attempt_at = now() + make_interval(secs => $3::float8)
+	      WHERE id=$1`
+	ctx, end := track(ctx, r.timeout, "reminders.MarkFailed", q)
+	defer func() { end(err) }()
+	_, err = r.db.ExecContext(ctx, q, id, msg, retryIn.Seconds())
+	return err
+}
+
+func (r *RemindersRepo) GetPreferences(ctx context.Context, userID uuid.UUID) (p model.NotificationPreferences, err error) {
+	q := `SELECT user_id, enabled, lead_days, array_to_string(channels, ','), COALESCE(email, ''), COALESCE(webhook_url, ''), updated_at
+	      FROM notification_preferences WHERE user_id=$1`
+	ctx, end := track(ctx, r.timeout, "reminders.GetPreferences", q)
+	defer func() { end(err) }()
+	var channels string
+	err = r.db.QueryRowContext(ctx, q, userID).Scan(&p.UserID, &p.Enabled, &p.LeadDays, &channels, &p.Email, &p.WebhookURL, &p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, ErrPreferencesNotFound
+	}
+	p.Channels = splitScopes(channels)
+	return p, err
+}
+
+// UpsertPreferences: пользователь должен существовать и не быть архивированным (иначе ErrUnknownUser)
+func (r *RemindersRepo) UpsertPreferences(ctx context.Context, p model.NotificationPreferences) (_ model.NotificationPreferences, err error) {
+	q := `INSERT INTO notification_preferences (user_id, enabled, lead_days, channels, email, webhook_url)
+	      SELECT u.id,$2::boolean,$3::int,string_to_array($4, ','),NULLIF($5, ''),NULLIF($6, '')
+	      FROM users u WHERE u.id=$1 AND u.archived_at IS NULL
+	      ON CONFLICT (user_id) DO UPDATE SET enabled=EXCLUDED.enabled, lead_days=EXCLUDED.lead_days,
+	          channels=EXCLUDED.channels, email=EXCLUDED.email, webhook_url=EXCLUDED.webhook_url, updated_at=now()
+	      RETURNING updated_at`
+	ctx, end := track(ctx, r.timeout, "reminders.UpsertPreferences", q)
+	defer func() { end(err) }()
+	err = r.db.QueryRowContext(ctx, q,
+		p.UserID, p.Enabled, p.LeadDays, strings.Join(p.Channels, ","), p.Email, p.WebhookURL).Scan(&p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, ErrUnknownUser
+	}
+	return p, err
+}
@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/migrations"
+)
+
+// testDB — Postgres из TEST_DATABASE_URL с применёнными миграциями; без переменной тест пропускается
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	logger := log.FromContext(context.Background())
+	db, err := NewPostgres(dsn, PoolOptions{MaxOpenConns: 4, MaxIdleConns: 4}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := NewMigrator(db, migrations.FS, logger).Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestRemindersGenerateDedupe(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	user, err := NewUsersRepo(db, 0).Create(ctx, model.User{ID: uuid.New(), Timezone: "UTC", Currency: "RUB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := NewSubscriptionsRepo(db, 0).Create(ctx, model.Subscription{
+		ID: uuid.New(), ServiceName: "Dedupe Test", Price: 100, BillingPeriod: model.BillingMonthly, UserID: user.ID,
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Status: model.StatusActive,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM subscriptions WHERE id=$1`, sub.ID)
+		db.Exec(`DELETE FROM users WHERE id=$1`, user.ID)
+	})
+
+	count := func() (n int) {
+		t.Helper()
+		err := db.QueryRow(`SELECT count(*) FROM reminders WHERE subscription_id=$1`, sub.ID).Scan(&n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	r := NewRemindersRepo(db, 0)
+	today := time.Date(2025, 7, 29, 0, 0, 0, 0, time.UTC) // продление 2025-08-01 попадает в окно 3 дней
+	for i := 0; i < 3; i++ {
+		if _, err := r.Generate(ctx, today, 3, []string{model.ChannelEmail, model.ChannelLog}); err != nil {
+			t.Fatal(err)
+		}
+		if n := count(); n != 2 {
+			t.Fatalf("pass %d: %d reminders, want 2 (one per channel)", i, n)
+		}
+	}
+
+	// новый канал даёт только недостающее напоминание
+	if _, err := r.Generate(ctx, today, 3, []string{model.ChannelEmail, model.ChannelLog, model.ChannelWebhook}); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 3 {
+		t.Fatalf("%d reminders after adding channel, want 3", n)
+	}
+
+	// следующий день — та же дата продления, дублей нет
+	if _, err := r.Generate(ctx, today.AddDate(0, 0, 1), 3, []string{model.ChannelEmail, model.ChannelLog, model.ChannelWebhook}); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 3 {
+		t.Fatalf("%d reminders on next day, want 3", n)
+	}
+}
@@ -0,0 +1,281 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"subscription-service/internal/model"
+)
+
+var (
+	ErrServiceNotFound = errors.New("service not found")
+	// ErrServiceNameTaken — название или псевдоним уже принадлежит другому сервису каталога
+	ErrServiceNameTaken = errors.New("service name already in catalog")
+)
+
+// ServicesRepo — каталог сервисов. Названия (каноническое и псевдонимы) хранятся в service_names
+// по ключу model.ServiceKey; запись в каталог переименовывает подписки с этими названиями.
+type ServicesRepo struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewServicesRepo(db *sql.DB, timeout time.Duration) *ServicesRepo {
+	return &ServicesRepo{db: db, timeout: timeout}
+}
+
+// serviceColumns — псевдонимы собираются строкой через \x1f (в названии его не бывает)
+const serviceColumns = `s.id, s.name,
+	COALESCE((SELECT string_agg(n.name, E'\x1f' ORDER BY n.name) FROM service_names n
+	          WHERE n.service_id = s.id AND NOT n.canonical), ''),
+	COALESCE(s.category, ''), COALESCE(s.website, ''), s.plans::text, s.created_at, s.updated_at`
+
+func scanService(sc scanner) (s model.CatalogService, err error) {
+	var aliases, plans string
+	if err = sc.Scan(&s.ID, &s.Name, &aliases, &s.Category, &s.Website, &plans, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return s, err
+	}
+	s.Aliases = []string{}
+	if aliases != "" {
+		s.Aliases = strings.Split(aliases, "\x1f")
+	}
+	if err = json.Unmarshal([]byte(plans), &s.Plans); s.Plans == nil {
+		s.Plans = []model.ServicePlan{}
+	}
+	return s, err
+}
+
+// rewriteSubscriptions переименовывает подписки, чьё название совпадает с одним из названий сервиса $1
+// (ключ — model.ServiceKey на стороне БД), и возвращает их для событий updated
+const rewriteSubscriptions = `UPDATE subscriptions s SET service_name = svc.name, updated_at = now()
+	FROM services svc
+	WHERE svc.id = $1 AND s.service_name <> svc.name
+	  AND lower(btrim(regexp_replace(s.service_name, '\s+', ' ', 'g'))) IN (SELECT key FROM service_names WHERE service_id = $1)
+	RETURNING ` + subscriptionColumns
+
+// nameError переводит нарушение уникальности ключа названия в ErrServiceNameTaken
+func nameError(err error) error {
+	var pg *pgconn.PgError
+	if errors.As(err, &pg) && pg.Code == "23505" {
+		return ErrServiceNameTaken
+	}
+	return err
+}
+
+// Create добавляет сервис с названиями и переименовывает подписки с ними; renamed — переименованные подписки
+func (r *ServicesRepo) Create(ctx context.Context, s model.CatalogService) (_ model.CatalogService, renamed []model.Subscription, err error) {
+	q := `INSERT INTO services (id, name, category, website, plans) VALUES ($1,$2,NULLIF($3, ''),NULLIF($4, ''),$5::jsonb)`
+	ctx, end := track(ctx, r.timeout, "services.Create", q)
+	defer func() { end(err) }()
+	plans, err := json.Marshal(s.Plans)
+	if err != nil {
+		return s, nil, err
+	}
+	renamed, err = r.inTx(ctx, s.ID, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, q, s.ID, s.Name, s.Category, s.Website, string(plans)); err != nil {
+			return err
+		}
+		return insertNames(ctx, tx, s)
+	})
+	if err != nil {
+		return s, nil, err
+	}
+	s, err = r.Get(ctx, s.ID)
+	return s, renamed, err
+}
+
+// Update заменяет сервис и его названия целиком
+func (r *ServicesRepo) Update(ctx context.Context, s model.CatalogService) (_ model.CatalogService, renamed []model.Subscription, err error) {
+	q := `UPDATE services SET name=$2, category=NULLIF($3, ''), website=NULLIF($4, ''), plans=$5::jsonb, updated_at=now()
+	      WHERE id=$1`
+	ctx, end := track(ctx, r.timeout, "services.Update", q)
+	defer func() { end(err) }()
+	plans, err := json.Marshal(s.Plans)
+	if err != nil {
+		return s, nil, err
+	}
+	renamed, err = r.inTx(ctx, s.ID, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, q, s.ID, s.Name, s.Category, s.Website, string(plans))
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrServiceNotFound
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM service_names WHERE service_id=$1`, s.ID); err != nil {
+			return err
+		}
+		return insertNames(ctx, tx, s)
+	})
+	if err != nil {
+		return s, nil, err
+	}
+	s, err = r.Get(ctx, s.ID)
+	return s, renamed, err
+}
+
+func insertNames(ctx context.Context, tx *sql.Tx, s model.CatalogService) error {
+	q := `INSERT INTO service_names (key, service_id, name, canonical) VALUES ($1,$2,$3,$4)`
+	for i, name := range append([]string{s.Name}, s.Aliases...) {
+		if _, err := tx.ExecContext(ctx, q, model.ServiceKey(name), s.ID, name, i == 0); err != nil {
+			return nameError(err)
+		}
+	}
+	return nil
+}
+
+// Merge вливает в сервис id другие сервисы каталога sources (их названия становятся псевдонимами,
+// записи удаляются; id среди них быть не должно) и названия не из каталога, затем переименовывает
+// подписки со всеми его названиями. Повторы в sources не считаются ошибкой.
+func (r *ServicesRepo) Merge(ctx context.Context, id uuid.UUID, sources []uuid.UUID, names []string) (_ model.CatalogService, renamed []model.Subscription, err error) {
+	qMove := `UPDATE service_names SET service_id=$1, canonical=false WHERE service_id = ANY($2::uuid[])`
+	qName := `INSERT INTO service_names (key, service_id, name) VALUES ($1,$2,$3)
+	          ON CONFLICT (key) DO UPDATE SET name = service_names.name WHERE service_names.service_id = EXCLUDED.service_id`
+	ctx, end := track(ctx, r.timeout, "services.Merge", qMove+";\n"+qName)
+	defer func() { end(err) }()
+	ids := make([]string, 0, len(sources))
+	for _, s := range sources {
+		if !slices.Contains(ids, s.String()) {
+			ids = append(ids, s.String())
+		}
+	}
+	renamed, err = r.inTx(ctx, id, func(tx *sql.Tx) error {
+		var found int
+		err := tx.QueryRowContext(ctx, `SELECT count(*) FROM (SELECT id FROM services WHERE id = ANY($1::uuid[]) FOR UPDATE) s`,
+			append([]string{id.String()}, ids...)).Scan(&found)
+		if err != nil {
+			return err
+		}
+		if found != len(ids)+1 {
+			return ErrServiceNotFound
+		}
+		if _, err := tx.ExecContext(ctx, qMove, id, ids); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM services WHERE id = ANY($1::uuid[])`, ids); err != nil {
+			return err
+		}
+		for _, name := range names {
+			// уже свой псевдоним — не ошибка; чужой — ErrServiceNameTaken
+			res, err := tx.ExecContext(ctx, qName, model.ServiceKey(name), id, name)
+			if err != nil {
+				return err
+			}
+			if k, _ := res.RowsAffected(); k == 0 {
+				return fmt.Errorf("%q: %w", name, ErrServiceNameTaken)
+			}
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE services SET updated_at=now() WHERE id=$1`, id)
+		return err
+	})
+	if err != nil {
+		return model.CatalogService{}, nil, err
+	}
+	s, err := r.Get(ctx, id)
+	return s, renamed, err
+}
+
+// inTx выполняет fn в транзакции, затем переименовывает подписки по названиям сервиса id;
+// возвращает переименованные подписки
+func (r *ServicesRepo) inTx(ctx context.Context, id uuid.UUID, fn func(tx *sql.Tx) error) ([]model.Subscription, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, rewriteSubscriptions, id)
+	if err != nil {
+		return nil, err
+	}
+	var renamed []model.Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		renamed = append(renamed, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return renamed, nil
+}
+
+func (r *ServicesRepo) Get(ctx context.Context, id uuid.UUID) (s model.CatalogService, err error) {
+	q := `SELECT ` + serviceColumns + ` FROM services s WHERE s.id=$1`
+	ctx, end := track(ctx, r.timeout, "services.Get", q)
+	defer func() { end(err) }()
+	s, err = scanService(r.db.QueryRowContext(ctx, q, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrServiceNotFound
+	}
+	return s, err
+}
+
+// Resolve находит сервис по названию или псевдониму в любом регистре
+func (r *ServicesRepo) Resolve(ctx context.Context, name string) (s model.CatalogService, err error) {
+	q := `SELECT ` + serviceColumns + ` FROM service_names k JOIN services s ON s.id = k.service_id WHERE k.key=$1`
+	ctx, end := track(ctx, r.timeout, "services.Resolve", q)
+	defer func() { end(err) }()
+	s, err = scanService(r.db.QueryRowContext(ctx, q, model.ServiceKey(name)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrServiceNotFound
+	}
+	return s, err
+}
+
+func (r *ServicesRepo) List(ctx context.Context, f model.CatalogQuery) (_ []model.CatalogService, err error) {
+	q := `SELECT ` + serviceColumns + ` FROM services s
+	      WHERE ($1 = '' OR lower(s.category) = lower($1))
+	        AND ($2 = '' OR EXISTS (SELECT 1 FROM service_names k WHERE k.service_id = s.id AND strpos(k.key, $2) > 0))
+	      ORDER BY s.name LIMIT $3 OFFSET $4`
+	ctx, end := track(ctx, r.timeout, "services.List", q)
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, q, f.Category, model.ServiceKey(f.Search), f.Limit, f.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.CatalogService
+	for rows.Next() {
+		s, err := scanService(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+// Delete удаляет сервис из каталога; подписки сохраняют своё название
+func (r *ServicesRepo) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	q := `DELETE FROM services WHERE id=$1`
+	ctx, end := track(ctx, r.timeout, "services.Delete", q)
+	defer func() { end(err) }()
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrServiceNotFound
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+)
+
+func TestServicesMerge(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	suffix := " " + uuid.NewString()[:8] // названия каталога уникальны во всей БД
+	name := func(s string) string { return s + suffix }
+
+	user, err := NewUsersRepo(db, 0).Create(ctx, model.User{ID: uuid.New(), Timezone: "UTC", Currency: "RUB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := NewServicesRepo(db, 0)
+	var created []uuid.UUID
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM subscriptions WHERE user_id=$1`, user.ID)
+		db.Exec(`DELETE FROM users WHERE id=$1`, user.ID)
+		for _, id := range created {
+			db.Exec(`DELETE FROM services WHERE id=$1`, id)
+		}
+	})
+	create := func(s model.CatalogService) model.CatalogService {
+		t.Helper()
+		s.ID = uuid.New()
+		created = append(created, s.ID)
+		res, _, err := services.Create(ctx, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	subs := NewSubscriptionsRepo(db, 0)
+	subIDs := map[string]uuid.UUID{}
+	for _, n := range []string{"netflix", "Netflix  Premium", "NFLX", "Spotify"} {
+		id := uuid.New()
+		if _, err := subs.Create(ctx, model.Subscription{
+			ID: id, ServiceName: name(n), Price: 100, UserID: user.ID, StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			Status: model.StatusActive, BillingPeriod: model.BillingMonthly,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		subIDs[n] = id
+	}
+
+	target := create(model.CatalogService{Name: name("Netflix"), Plans: []model.ServicePlan{}})
+	dup := create(model.CatalogService{Name: name("Netflix Premium"), Plans: []model.ServicePlan{}})
+	other := create(model.CatalogService{Name: name("Spotify"), Plans: []model.ServicePlan{}})
+
+	tests := []struct {
+		name    string
+		sources []uuid.UUID
+		names   []string
+		err     error
+	}{
+		{name: "missing source", sources: []uuid.UUID{uuid.New()}, err: ErrServiceNotFound},
+		{name: "name of another service", names: []string{name("spotify")}, err: ErrServiceNameTaken},
+		// повтор источника и уже свой псевдоним — не ошибки
+		{name: "duplicate and own names", sources: []uuid.UUID{dup.ID, dup.ID}, names: []string{name("NFLX"), name("netflix premium")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, renamed, err := services.Merge(ctx, target.ID, tt.sources, tt.names)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				if _, err := services.Get(ctx, dup.ID); err != nil {
+					t.Fatalf("failed merge changed catalog: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Contains(res.Aliases, name("Netflix Premium")) || !slices.Contains(res.Aliases, name("NFLX")) {
+				t.Fatalf("aliases = %v", res.Aliases)
+			}
+			if _, err := services.Get(ctx, dup.ID); !errors.Is(err, ErrServiceNotFound) {
+				t.Fatalf("merged source still in catalog: %v", err)
+			}
+			var got []uuid.UUID
+			for _, s := range renamed {
+				if s.ServiceName != target.Name {
+					t.Errorf("renamed %s to %q, want %q", s.ID, s.ServiceName, target.Name)
+				}
+				got = append(got, s.ID)
+			}
+			// "netflix" переименована уже при создании сервиса
+			for n, want := range map[string]bool{"Netflix  Premium": true, "NFLX": true, "netflix": false, "Spotify": false} {
+				if slices.Contains(got, subIDs[n]) != want {
+					t.Errorf("subscription %q renamed = %v, want %v", n, !want, want)
+				}
+			}
+		})
+	}
+
+	if s, err := services.Resolve(ctx, "  nflx"+suffix); err != nil || s.ID != target.ID {
+		t.Fatalf("Resolve by merged name = %+v, %v", s, err)
+	}
+	if s, err := services.Resolve(ctx, name("SPOTIFY")); err != nil || s.ID != other.ID {
+		t.Fatalf("Resolve other = %+v, %v", s, err)
+	}
+}
@@ -0,0 +1,192 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"subscription-service/internal/model"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("user already exists")
+	ErrUserArchived = errors.New("user archived")
+	ErrEmailTaken   = errors.New("email already in use")
+	// ErrUnknownUser — подписка или настройки ссылаются на несуществующего или архивированного пользователя
+	ErrUnknownUser = errors.New("user not found or archived")
+)
+
+type UsersRepo struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewUsersRepo(db *sql.DB, timeout time.Duration) *UsersRepo {
+	return &UsersRepo{db: db, timeout: timeout}
+}
+
+const userColumns = `id, display_name, COALESCE(email, ''), timezone, currency, archived_at, created_at, updated_at`
+
+func scanUser(s scanner) (u model.User, err error) {
+	err = s.Scan(&u.ID, &u.DisplayName, &u.Email, &u.Timezone, &u.Currency, &u.ArchivedAt, &u.CreatedAt, &u.UpdatedAt)
+	return u, err
+}
+
+// userError переводит нарушения уникальности в ошибки репозитория
+func userError(err error) error {
+	var pg *pgconn.PgError
+	if errors.As(err, &pg) && pg.Code == "23505" {
+		if pg.ConstraintName == "idx_users_email" {
+			return ErrEmailTaken
+		}
+		return ErrUserExists
+	}
+	return err
+}
+
+func (r *UsersRepo) Create(ctx context.Context, u model.User) (_ model.User, err error) {
+	q := `INSERT INTO users (id, display_name, email, timezone, currency)
+	      VALUES ($1,$2,NULLIF($3, ''),$4,$5)
+	      RETURNING ` + userColumns
+	ctx, end := track(ctx, r.timeout, "users.Create", q)
+	defer func() { end(err) }()
+	u, err = scanUser(r.db.QueryRowContext(ctx, q, u.ID, u.DisplayName, u.Email, u.Timezone, u.Currency))
+	return u, userError(err)
+}
+
+func (r *UsersRepo) Get(ctx context.Context, id uuid.UUID) (u model.User, err error) {
+	q := `SELECT ` + userColumns + ` FROM users WHERE id=$1`
+	ctx, end := track(ctx, r.timeout, "users.Get", q)
+	defer func() { end(err) }()
+	u, err = scanUser(r.db.QueryRowContext(ctx, q, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, ErrUserNotFound
+	}
+	return u, err
+}
+
+func (r *UsersRepo) List(ctx context.Context, f model.UserQuery) (_ []model.User, err error) {
+	q := `SELECT ` + userColumns + ` FROM users
+	      WHERE $1 OR archived_at IS NULL
+	      ORDER BY created_at DESC, id LIMIT $2 OFFSET $3`
+	ctx, end := track(ctx, r.timeout, "users.List", q)
+	defer func() { end(err) }()
+	rows, err := r.db.QueryContext(ctx, q, f.Archived, f.Limit, f.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, u)
+	}
+	return res, rows.Err()
+}
+
+// Update записывает профиль; архивированного пользователя не меняет (ErrUserArchived)
+func (r *UsersRepo) Update(ctx context.Context, u model.User) (_ model.User, err error) {
+	q := `UPDATE users SET display_name=$2, email=NULLIF($3, ''), timezone=$4, currency=$5, updated_at=now()
+	      WHERE id=$1 AND archived_at IS NULL
+	      RETURNING ` + userColumns
+	ctx, end := track(ctx, r.timeout, "users.Update", q)
+	defer func() { end(err) }()
+	u, err = scanUser(r.db.QueryRowContext(ctx, q, u.ID, u.DisplayName, u.Email, u.Timezone, u.Currency))
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, r.stateError(ctx, u.ID)
+	}
+	return u, userError(err)
+}
+
+// Archive помечает пользователя архивированным, а его подписки — archived, закрывая открытые текущим
+// месяцем (но не раньше месяца начала). Возвращает изменённые подписки.
+func (r *UsersRepo) Archive(ctx context.Context, id uuid.UUID) (_ []model.Subscription, err error) {
+	qSubs := `UPDATE subscriptions s
+	          SET status='` + model.StatusArchived + `', updated_at=now(),
+	              end_date=GREATEST(start_date, LEAST(COALESCE(end_date, date_trunc('month', now())::date),
+	                                                  date_trunc('month', now())::date))
+	          WHERE s.user_id=$1 AND s.status <> '` + model.StatusArchived + `'
+	          RETURNING ` + subscriptionColumns
+	qUser := `UPDATE users SET archived_at=now(), updated_at=now() WHERE id=$1`
+	ctx, end := track(ctx, r.timeout, "users.Archive", qSubs+";\n"+qUser)
+	defer func() { end(err) }()
+	return r.withUser(ctx, id, false, qSubs, qUser)
+}
+
+// Delete удаляет пользователя (в том числе архивированного) вместе с подписками; настройки напоминаний
+// удаляются каскадом. Возвращает удалённые подписки.
+func (r *UsersRepo) Delete(ctx context.Context, id uuid.UUID) (_ []model.Subscription, err error) {
+	qSubs := `DELETE FROM subscriptions s WHERE s.user_id=$1 RETURNING ` + subscriptionColumns
+	qUser := `DELETE FROM users WHERE id=$1`
+	ctx, end := track(ctx, r.timeout, "users.Delete", qSubs+";\n"+qUser)
+	defer func() { end(err) }()
+	return r.withUser(ctx, id, true, qSubs, qUser)
+}
+
+// withUser в одной транзакции блокирует пользователя id (подписки ему в это время не создаются,
+// см. SubscriptionsRepo.Create), выполняет qSubs, возвращающий подписки, и qUser;
+// allowArchived=false — для архивированного пользователя ErrUserArchived
+func (r *UsersRepo) withUser(ctx context.Context, id uuid.UUID, allowArchived bool, qSubs, qUser string) (_ []model.Subscription, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var archivedAt *time.Time
+	err = tx.QueryRowContext(ctx, `SELECT archived_at FROM users WHERE id=$1 FOR UPDATE`, id).Scan(&archivedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrUserNotFound
+	case err != nil:
+		return nil, err
+	case archivedAt != nil && !allowArchived:
+		return nil, ErrUserArchived
+	}
+
+	rows, err := tx.QueryContext(ctx, qSubs, id)
+	if err != nil {
+		return nil, err
+	}
+	var subs []model.Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, qUser, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return subs, nil
+}
+
+func (r *UsersRepo) stateError(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id=$1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+	return ErrUserArchived
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/tracing"
+)
+
+const apiKeyPrefix = "sk_"
+
+type APIKeys struct {
+	repo   *repo.APIKeysRepo
+	logger *log.Logger
+}
+
+func NewAPIKeys(r *repo.APIKeysRepo, l *log.Logger) *APIKeys { return &APIKeys{repo: r, logger: l} }
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *APIKeys) Create(ctx context.Context, in model.APIKeyCreate) (model.APIKeyCreated, error) {
+	ctx, span := tracing.Start(ctx, "service.APIKeys.Create")
+	defer span.End()
+	if strings.TrimSpace(in.Name) == "" || len(in.Scopes) == 0 {
+		return model.APIKeyCreated{}, invalidf("name and scopes required")
+	}
+	for _, sc := range in.Scopes {
+		if !auth.KnownScope(sc) {
+			return model.APIKeyCreated{}, invalidf("unknown scope %q", sc)
+		}
+	}
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(time.Now()) {
+		return model.APIKeyCreated{}, invalidf("expires_at must be in the future")
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return model.APIKeyCreated{}, err
+	}
+	plain := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	k, err := s.repo.Create(ctx, model.APIKey{
+		ID:        uuid.New(),
+		Name:      in.Name,
+		Prefix:    plain[:len(apiKeyPrefix)+6],
+		Scopes:    in.Scopes,
+		ExpiresAt: in.ExpiresAt,
+	}, hashKey(plain))
+	if err != nil {
+		return model.APIKeyCreated{}, err
+	}
+	return model.APIKeyCreated{APIKey: k, Key: plain}, nil
+}
+
+func (s *APIKeys) List(ctx context.Context) ([]model.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "service.APIKeys.List")
+	defer span.End()
+	return s.repo.List(ctx)
+}
+
+func (s *APIKeys) Revoke(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "service.APIKeys.Revoke")
+	defer span.End()
+	return s.repo.Revoke(ctx, id)
+}
+
+// Authenticate проверяет ключ в открытом виде и возвращает сервисного Principal
+func (s *APIKeys) Authenticate(ctx context.Context, key string) (auth.Principal, error) {
+	ctx, span := tracing.Start(ctx, "service.APIKeys.Authenticate")
+	defer span.End()
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return auth.Principal{}, auth.ErrUnauthenticated
+	}
+	k, err := s.repo.Use(ctx, hashKey(key))
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	return auth.Principal{Role: auth.RoleService, KeyID: k.ID, Scopes: k.Scopes}, nil
+}
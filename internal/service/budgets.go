@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/tracing"
+)
+
+// maxBudgetMonths — сколько месяцев можно запросить в статусе бюджетов
+const maxBudgetMonths = 36
+
+// Budgets — месячные бюджеты; пользователь видит и меняет свои, admin и API-ключи — любого пользователя
+type Budgets struct {
+	repo  *repo.BudgetsRepo
+	users *repo.UsersRepo // часовой пояс: какой месяц у пользователя текущий
+	subs  *Service
+}
+
+func NewBudgets(r *repo.BudgetsRepo, users *repo.UsersRepo, subs *Service) *Budgets {
+	return &Budgets{repo: r, users: users, subs: subs}
+}
+
+func (s *Budgets) Create(ctx context.Context, in model.BudgetCreate) (model.Budget, error) {
+	ctx, span := tracing.Start(ctx, "service.Budgets.Create")
+	defer span.End()
+	uid, err := target(ctx, in.UserID)
+	if err != nil {
+		return model.Budget{}, err
+	}
+	if in.Amount <= 0 {
+		return model.Budget{}, invalidf("amount must be > 0")
+	}
+	b := model.Budget{ID: uuid.New(), UserID: uid, Amount: in.Amount}
+	if b.Category, err = normalizeCategory(in.Category); err != nil {
+		return model.Budget{}, err
+	}
+	if b.ServiceName, err = s.subs.CanonicalServiceName(ctx, in.ServiceName); err != nil {
+		return model.Budget{}, err
+	}
+	if b.Category != "" && b.ServiceName != "" {
+		return model.Budget{}, invalidf("either category or service_name, not both")
+	}
+	return s.repo.Create(ctx, b)
+}
+
+func (s *Budgets) Get(ctx context.Context, id uuid.UUID) (model.Budget, error) {
+	ctx, span := tracing.Start(ctx, "service.Budgets.Get")
+	defer span.End()
+	own, err := owner(ctx)
+	if err != nil {
+		return model.Budget{}, err
+	}
+	return s.repo.Get(ctx, id, own)
+}
+
+// List — бюджеты пользователя userID (пусто — вызывающего)
+func (s *Budgets) List(ctx context.Context, userID string) ([]model.Budget, error) {
+	ctx, span := tracing.Start(ctx, "service.Budgets.List")
+	defer span.End()
+	uid, err := target(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, uid)
+}
+
+func (s *Budgets) Update(ctx context.Context, id uuid.UUID, in model.BudgetUpdate) (model.Budget, error) {
+	ctx, span := tracing.Start(ctx, "service.Budgets.Update")
+	defer span.End()
+	own, err := owner(ctx)
+	if err != nil {
+		return model.Budget{}, err
+	}
+	if in.Amount <= 0 {
+		return model.Budget{}, invalidf("amount must be > 0")
+	}
+	return s.repo.Update(ctx, id, own, in.Amount)
+}
+
+func (s *Budgets) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "service.Budgets.Delete")
+	defer span.End()
+	own, err := owner(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id, own)
+}
+
+// Status сравнивает траты по месяцам [from..to] (как Total с фильтром бюджета) с каждым бюджетом
+// пользователя userID. Нулевой from — текущий месяц по часовому поясу пользователя, нулевой to — from.
+// Текущий и более поздние месяцы помечаются как прогноз.
+func (s *Budgets) Status(ctx context.Context, userID string, from, to time.Time) ([]model.BudgetStatus, error) {
+	ctx, span := tracing.Start(ctx, "service.Budgets.Status")
+	defer span.End()
+	uid, err := target(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.users.Get(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	current := currentMonth(u.Timezone)
+	if from.IsZero() {
+		from = current
+	}
+	if to.IsZero() {
+		to = from
+	}
+	if from.After(to) {
+		return nil, invalidf("from must be <= to")
+	}
+	if from.AddDate(0, maxBudgetMonths, 0).Before(to) {
+		return nil, invalidf("period must be up to %d months", maxBudgetMonths)
+	}
+	budgets, err := s.repo.List(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.BudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		spent, err := s.subs.repo.Monthly(ctx, model.TotalQuery{
+			UserID:      uid.String(),
+			ServiceName: b.ServiceName,
+			Category:    b.Category,
+			From:        from,
+			To:          to,
+		})
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, model.BudgetStatus{Budget: b, Months: budgetMonths(b.Amount, spent, from, to, current)})
+	}
+	return res, nil
+}
+
+// budgetMonths сравнивает траты spent с бюджетом amount по каждому месяцу [from..to]; месяцы
+// без трат — 0, начиная с current — прогноз
+func budgetMonths(amount int64, spent []model.MonthAmount, from, to, current time.Time) []model.BudgetMonth {
+	res := []model.BudgetMonth{}
+	for m := from; !m.After(to); m = m.AddDate(0, 1, 0) {
+		bm := model.BudgetMonth{Month: m, Forecast: !m.Before(current)}
+		for _, a := range spent {
+			if a.Month.Equal(m) {
+				bm.Spent = a.Amount
+			}
+		}
+		bm.Remaining = max(amount-bm.Spent, 0)
+		bm.Overspent = max(bm.Spent-amount, 0)
+		res = append(res, bm)
+	}
+	return res
+}
+
+// currentMonth — первый день текущего месяца в часовом поясе tz (UTC, если он неизвестен)
+func currentMonth(tz string) time.Time {
+	now := time.Now().UTC()
+	if loc, err := time.LoadLocation(tz); err == nil {
+		now = now.In(loc)
+	}
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
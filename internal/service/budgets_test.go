@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/model"
+)
+
+func TestBudgetMonths(t *testing.T) {
+	ym := func(y int, m time.Month) time.Time { return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name          string
+		amount        int64
+		spent         []model.MonthAmount
+		from, to, cur time.Time
+		want          []model.BudgetMonth
+	}{
+		{
+			name: "within budget", amount: 1000,
+			spent: []model.MonthAmount{{Month: ym(2026, 3), Amount: 600}},
+			from:  ym(2026, 3), to: ym(2026, 3), cur: ym(2026, 5),
+			want: []model.BudgetMonth{{Month: ym(2026, 3), Spent: 600, Remaining: 400}},
+		},
+		{
+			name: "overspent", amount: 1000,
+			spent: []model.MonthAmount{{Month: ym(2026, 3), Amount: 1300}},
+			from:  ym(2026, 3), to: ym(2026, 3), cur: ym(2026, 5),
+			want: []model.BudgetMonth{{Month: ym(2026, 3), Spent: 1300, Overspent: 300}},
+		},
+		{
+			name: "exactly on budget", amount: 1000,
+			spent: []model.MonthAmount{{Month: ym(2026, 3), Amount: 1000}},
+			from:  ym(2026, 3), to: ym(2026, 3), cur: ym(2026, 5),
+			want: []model.BudgetMonth{{Month: ym(2026, 3), Spent: 1000}},
+		},
+		{
+			name: "months without charges and forecast", amount: 500,
+			// годовая подписка: списание только в апреле
+			spent: []model.MonthAmount{{Month: ym(2026, 4), Amount: 3990}},
+			from:  ym(2026, 3), to: ym(2026, 5), cur: ym(2026, 4),
+			want: []model.BudgetMonth{
+				{Month: ym(2026, 3), Remaining: 500},
+				{Month: ym(2026, 4), Spent: 3990, Overspent: 3490, Forecast: true},
+				{Month: ym(2026, 5), Remaining: 500, Forecast: true},
+			},
+		},
+		{
+			name: "across year", amount: 100, from: ym(2025, 12), to: ym(2026, 1), cur: ym(2025, 1),
+			want: []model.BudgetMonth{
+				{Month: ym(2025, 12), Remaining: 100, Forecast: true},
+				{Month: ym(2026, 1), Remaining: 100, Forecast: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := budgetMonths(tt.amount, tt.spent, tt.from, tt.to, tt.cur); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("budgetMonths =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Ошибки, которые Budgets возвращает до обращения к БД
+func TestBudgetsValidation(t *testing.T) {
+	user := auth.Principal{Role: auth.RoleUser, UserID: uuid.New()}
+	key := auth.Principal{Role: auth.RoleService}
+	as := func(p auth.Principal) context.Context { return auth.WithPrincipal(context.Background(), p) }
+	var invalid *ValidationError
+
+	b := NewBudgets(nil, nil, &Service{})
+	tests := []struct {
+		name string
+		ctx  context.Context
+		call func(ctx context.Context) error
+		want error // nil — ValidationError с текстом msg
+		msg  string
+	}{
+		{
+			name: "unauthenticated", ctx: context.Background(), want: auth.ErrUnauthenticated,
+			call: func(ctx context.Context) error { _, err := b.Create(ctx, model.BudgetCreate{Amount: 100}); return err },
+		},
+		{
+			name: "other user's budget", ctx: as(user), want: auth.ErrForbidden,
+			call: func(ctx context.Context) error {
+				_, err := b.Create(ctx, model.BudgetCreate{UserID: uuid.NewString(), Amount: 100})
+				return err
+			},
+		},
+		{
+			name: "api key without user_id", ctx: as(key), msg: "user_id required",
+			call: func(ctx context.Context) error { _, err := b.Create(ctx, model.BudgetCreate{Amount: 100}); return err },
+		},
+		{
+			name: "zero amount", ctx: as(user), msg: "amount must be > 0",
+			call: func(ctx context.Context) error { _, err := b.Create(ctx, model.BudgetCreate{}); return err },
+		},
+		{
+			name: "category and service", ctx: as(user), msg: "either category or service_name",
+			call: func(ctx context.Context) error {
+				_, err := b.Create(ctx, model.BudgetCreate{Category: "video", ServiceName: "Netflix", Amount: 100})
+				return err
+			},
+		},
+		{
+			name: "category too long", ctx: as(user), msg: "category must be up to 100 characters",
+			call: func(ctx context.Context) error {
+				_, err := b.Create(ctx, model.BudgetCreate{Category: strings.Repeat("x", 101), Amount: 100})
+				return err
+			},
+		},
+		{
+			name: "update to negative amount", ctx: as(user), msg: "amount must be > 0",
+			call: func(ctx context.Context) error {
+				_, err := b.Update(ctx, uuid.New(), model.BudgetUpdate{Amount: -1})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(tt.ctx)
+			switch {
+			case tt.want != nil && !errors.Is(err, tt.want):
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			case tt.want == nil && (!errors.As(err, &invalid) || !strings.Contains(err.Error(), tt.msg)):
+				t.Fatalf("err = %v, want validation error %q", err, tt.msg)
+			}
+		})
+	}
+}
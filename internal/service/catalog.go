@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/events"
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/tracing"
+)
+
+// Catalog — каталог сервисов: читать могут все, менять — admin (ограничено роутером)
+type Catalog struct {
+	repo *repo.ServicesRepo
+	subs *Service // события updated о подписках, переименованных по каталогу
+}
+
+func NewCatalog(r *repo.ServicesRepo, subs *Service) *Catalog { return &Catalog{repo: r, subs: subs} }
+
+// renamed публикует updated для подписок, которым каталог поменял service_name
+func (c *Catalog) renamed(ctx context.Context, subs []model.Subscription) {
+	for _, sub := range subs {
+		c.subs.publish(ctx, events.Updated, sub)
+	}
+}
+
+func (c *Catalog) Create(ctx context.Context, in model.CatalogServiceCreate) (model.CatalogService, error) {
+	ctx, span := tracing.Start(ctx, "service.Catalog.Create")
+	defer span.End()
+	s := model.CatalogService{
+		ID:       uuid.New(),
+		Name:     in.Name,
+		Aliases:  in.Aliases,
+		Category: in.Category,
+		Website:  in.Website,
+		Plans:    in.Plans,
+	}
+	if err := normalizeCatalogService(&s); err != nil {
+		return model.CatalogService{}, err
+	}
+	s, renamed, err := c.repo.Create(ctx, s)
+	if err != nil {
+		return model.CatalogService{}, err
+	}
+	c.renamed(ctx, renamed)
+	log.FromContext(ctx).Info("catalog service created", "service_id", s.ID, "name", s.Name, "subscriptions_renamed", len(renamed))
+	return s, nil
+}
+
+func (c *Catalog) Get(ctx context.Context, id uuid.UUID) (model.CatalogService, error) {
+	ctx, span := tracing.Start(ctx, "service.Catalog.Get")
+	defer span.End()
+	return c.repo.Get(ctx, id)
+}
+
+func (c *Catalog) List(ctx context.Context, q model.CatalogQuery) ([]model.CatalogService, error) {
+	ctx, span := tracing.Start(ctx, "service.Catalog.List")
+	defer span.End()
+	return c.repo.List(ctx, q)
+}
+
+// Update при переименовании оставляет прежнее название псевдонимом, чтобы оно по-прежнему находилось
+func (c *Catalog) Update(ctx context.Context, id uuid.UUID, in model.CatalogServiceUpdate) (model.CatalogService, error) {
+	ctx, span := tracing.Start(ctx, "service.Catalog.Update")
+	defer span.End()
+	s, err := c.repo.Get(ctx, id)
+	if err != nil {
+		return model.CatalogService{}, err
+	}
+	if in.Aliases != nil {
+		s.Aliases = *in.Aliases
+	}
+	if in.Name != nil && model.ServiceKey(*in.Name) != model.ServiceKey(s.Name) {
+		s.Aliases = append(s.Aliases, s.Name)
+		s.Name = *in.Name
+	} else if in.Name != nil {
+		s.Name = *in.Name // другое написание того же названия
+	}
+	if in.Category != nil {
+		s.Category = *in.Category
+	}
+	if in.Website != nil {
+		s.Website = *in.Website
+	}
+	if in.Plans != nil {
+		s.Plans = *in.Plans
+	}
+	if err := normalizeCatalogService(&s); err != nil {
+		return model.CatalogService{}, err
+	}
+	s, renamed, err := c.repo.Update(ctx, s)
+	if err != nil {
+		return model.CatalogService{}, err
+	}
+	c.renamed(ctx, renamed)
+	log.FromContext(ctx).Info("catalog service updated", "service_id", s.ID, "name", s.Name, "subscriptions_renamed", len(renamed))
+	return s, nil
+}
+
+// Delete убирает сервис из каталога; подписки сохраняют название
+func (c *Catalog) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "service.Catalog.Delete")
+	defer span.End()
+	return c.repo.Delete(ctx, id)
+}
+
+// Merge вливает в сервис id дубликаты из каталога и названия не из каталога;
+// подписки со всеми этими названиями переименовываются в каноническое
+func (c *Catalog) Merge(ctx context.Context, id uuid.UUID, in model.CatalogMerge) (model.CatalogMergeResult, error) {
+	ctx, span := tracing.Start(ctx, "service.Catalog.Merge")
+	defer span.End()
+	if len(in.ServiceIDs) == 0 && len(in.Names) == 0 {
+		return model.CatalogMergeResult{}, invalidf("service_ids or names required")
+	}
+	sources := make([]uuid.UUID, 0, len(in.ServiceIDs))
+	for _, s := range in.ServiceIDs {
+		sid, err := uuid.Parse(s)
+		if err != nil {
+			return model.CatalogMergeResult{}, invalidf("invalid service id %q", s)
+		}
+		if sid == id {
+			return model.CatalogMergeResult{}, invalidf("cannot merge service into itself")
+		}
+		if !slices.Contains(sources, sid) {
+			sources = append(sources, sid)
+		}
+	}
+	names, err := normalizeNames(in.Names)
+	if err != nil {
+		return model.CatalogMergeResult{}, err
+	}
+	s, renamed, err := c.repo.Merge(ctx, id, sources, names)
+	if err != nil {
+		return model.CatalogMergeResult{}, err
+	}
+	c.renamed(ctx, renamed)
+	log.FromContext(ctx).Info("catalog services merged", "service_id", id, "merged_services", len(sources),
+		"merged_names", len(names), "subscriptions_renamed", len(renamed))
+	return model.CatalogMergeResult{Service: s, SubscriptionsUpdated: int64(len(renamed))}, nil
+}
+
+// Resolve — сервис из каталога по названию или псевдониму (ErrServiceNotFound, если его нет)
+func (c *Catalog) Resolve(ctx context.Context, name string) (model.CatalogService, error) {
+	return c.repo.Resolve(ctx, name)
+}
+
+func normalizeCatalogService(s *model.CatalogService) error {
+	s.Name = model.NormalizeServiceName(s.Name)
+	if s.Name == "" || len([]rune(s.Name)) > 100 {
+		return invalidf("name required (up to 100 characters)")
+	}
+	aliases, err := normalizeNames(s.Aliases)
+	if err != nil {
+		return err
+	}
+	// псевдоним, совпадающий с названием, не нужен
+	s.Aliases = slices.DeleteFunc(aliases, func(a string) bool { return model.ServiceKey(a) == model.ServiceKey(s.Name) })
+	s.Category = model.NormalizeServiceName(s.Category)
+	if s.Website != "" {
+		if u, err := url.Parse(s.Website); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return invalidf("website must be an http(s) URL")
+		}
+	}
+	if s.Plans == nil {
+		s.Plans = []model.ServicePlan{}
+	}
+	for i := range s.Plans {
+		p := &s.Plans[i]
+		p.Name = model.NormalizeServiceName(p.Name)
+		if p.Name == "" || p.Price <= 0 {
+			return invalidf("plans: name and price (>0) required")
+		}
+		if slices.ContainsFunc(s.Plans[:i], func(o model.ServicePlan) bool { return o.Name == p.Name }) {
+			return invalidf("plans: duplicate plan %q", p.Name)
+		}
+	}
+	return nil
+}
+
+// normalizeNames убирает лишние пробелы и повторы (без учёта регистра)
+func normalizeNames(in []string) ([]string, error) {
+	var res []string
+	for _, a := range in {
+		a = model.NormalizeServiceName(a)
+		if a == "" || len([]rune(a)) > 100 {
+			return nil, invalidf("names must be non-empty (up to 100 characters)")
+		}
+		if !slices.ContainsFunc(res, func(o string) bool { return model.ServiceKey(o) == model.ServiceKey(a) }) {
+			res = append(res, a)
+		}
+	}
+	return res, nil
+}
+
+// plan — тариф по названию (пусто — первый, по умолчанию)
+func plan(s model.CatalogService, name string) (model.ServicePlan, error) {
+	if len(s.Plans) == 0 {
+		return model.ServicePlan{}, invalidf("service %q has no plans, price required", s.Name)
+	}
+	if name == "" {
+		return s.Plans[0], nil
+	}
+	for _, p := range s.Plans {
+		if model.ServiceKey(p.Name) == model.ServiceKey(name) {
+			return p, nil
+		}
+	}
+	return model.ServicePlan{}, invalidf("unknown plan %q of service %q", name, s.Name)
+}
+
+// resolveService приводит название к каноническому из каталога. Нет в каталоге — название без
+// лишних пробелов или ошибка, если неизвестные сервисы запрещены (SetCatalog)
+func (s *Service) resolveService(ctx context.Context, name string) (string, *model.CatalogService, error) {
+	name = model.NormalizeServiceName(name)
+	if s.catalog == nil || name == "" {
+		return name, nil, nil
+	}
+	cs, err := s.catalog.Resolve(ctx, name)
+	switch {
+	case errors.Is(err, repo.ErrServiceNotFound):
+		if s.rejectUnknown {
+			return "", nil, invalidf("unknown service %q (see /api/v1/services)", name)
+		}
+		return name, nil, nil
+	case err != nil:
+		return "", nil, err
+	}
+	return cs.Name, &cs, nil
+}
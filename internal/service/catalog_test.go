@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+)
+
+func TestNormalizeCatalogService(t *testing.T) {
+	tests := []struct {
+		name string
+		in   model.CatalogService
+		want model.CatalogService
+		err  string
+	}{
+		{
+			name: "spaces, alias repeats and alias equal to name",
+			in: model.CatalogService{
+				Name: "  Yandex   Plus ", Aliases: []string{"yandex plus", "Яндекс Плюс", "  ЯНДЕКС плюс", "Plus"},
+				Category: " music ",
+			},
+			want: model.CatalogService{
+				Name: "Yandex Plus", Aliases: []string{"Яндекс Плюс", "Plus"}, Category: "music", Plans: []model.ServicePlan{},
+			},
+		},
+		{
+			name: "plans normalized",
+			in:   model.CatalogService{Name: "Netflix", Website: "https://netflix.com", Plans: []model.ServicePlan{{Name: " Basic ", Price: 599}, {Name: "Premium", Price: 999}}},
+			want: model.CatalogService{Name: "Netflix", Website: "https://netflix.com", Plans: []model.ServicePlan{{Name: "Basic", Price: 599}, {Name: "Premium", Price: 999}}},
+		},
+		{name: "empty name", in: model.CatalogService{Name: "   "}, err: "name required"},
+		{name: "long name", in: model.CatalogService{Name: strings.Repeat("я", 101)}, err: "name required"},
+		{name: "empty alias", in: model.CatalogService{Name: "Netflix", Aliases: []string{" "}}, err: "names must be non-empty"},
+		{name: "website scheme", in: model.CatalogService{Name: "Netflix", Website: "ftp://netflix.com"}, err: "website must be"},
+		{name: "website without host", in: model.CatalogService{Name: "Netflix", Website: "https://"}, err: "website must be"},
+		{name: "plan without price", in: model.CatalogService{Name: "Netflix", Plans: []model.ServicePlan{{Name: "Basic"}}}, err: "name and price"},
+		{
+			name: "duplicate plan", err: "duplicate plan",
+			in: model.CatalogService{Name: "Netflix", Plans: []model.ServicePlan{{Name: "Basic", Price: 1}, {Name: " Basic", Price: 2}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.in
+			err := normalizeCatalogService(&s)
+			if tt.err != "" {
+				var invalid *ValidationError
+				if !errors.As(err, &invalid) || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want validation error %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(s, tt.want) {
+				t.Fatalf("got %+v, want %+v", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	s := model.CatalogService{Name: "Netflix", Plans: []model.ServicePlan{{Name: "Basic", Price: 599}, {Name: "Premium", Price: 999}}}
+	tests := []struct {
+		name, plan string
+		service    model.CatalogService
+		want       int64
+		err        string
+	}{
+		{name: "default is first", service: s, want: 599},
+		{name: "case-insensitive", service: s, plan: " premium ", want: 999},
+		{name: "unknown", service: s, plan: "Ultra", err: `unknown plan "Ultra"`},
+		{name: "no plans", service: model.CatalogService{Name: "Netflix"}, err: "no plans, price required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := plan(tt.service, tt.plan)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || p.Price != tt.want {
+				t.Fatalf("plan = %+v, %v; want price %d", p, err, tt.want)
+			}
+		})
+	}
+}
+
+// Ошибки Merge, которые возвращаются до обращения к БД
+func TestCatalogMergeValidation(t *testing.T) {
+	c := NewCatalog(nil, &Service{})
+	id := uuid.New()
+	tests := []struct {
+		name string
+		in   model.CatalogMerge
+		err  string
+	}{
+		{name: "nothing to merge", err: "service_ids or names required"},
+		{name: "invalid id", in: model.CatalogMerge{ServiceIDs: []string{"netflix"}}, err: `invalid service id "netflix"`},
+		{name: "into itself", in: model.CatalogMerge{ServiceIDs: []string{uuid.NewString(), id.String()}}, err: "into itself"},
+		{name: "empty name", in: model.CatalogMerge{Names: []string{"Netflix HD", "  "}}, err: "names must be non-empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var invalid *ValidationError
+			_, err := c.Merge(context.Background(), id, tt.in)
+			if !errors.As(err, &invalid) || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("err = %v, want validation error %q", err, tt.err)
+			}
+		})
+	}
+}
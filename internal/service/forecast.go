@@ -0,0 +1,69 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+	"subscription-service/internal/tracing"
+)
+
+// Forecast — стоимость подписок пользователя по месяцам [From..From+Months-1] при неизменных
+// подписках (те же правила, что у Total: период оплаты и запланированные цены); в каждом месяце —
+// подписки со списанием в нём
+func (s *Service) Forecast(ctx context.Context, in model.ForecastQuery) (model.Forecast, error) {
+	ctx, span := tracing.Start(ctx, "service.Forecast")
+	defer span.End()
+	if in.Months < 1 || in.Months > model.MaxForecastMonths {
+		return model.Forecast{}, invalidf("months must be 1..%d", model.MaxForecastMonths)
+	}
+	to := in.From.AddDate(0, in.Months-1, 0)
+	q, err := periodQuery(ctx, model.TotalQuery{
+		UserID:      in.UserID,
+		ServiceName: in.ServiceName,
+		Category:    in.Category,
+		Tag:         in.Tag,
+		From:        in.From,
+		To:          to,
+	})
+	if err != nil {
+		return model.Forecast{}, err
+	}
+	if q.ServiceName, err = s.CanonicalServiceName(ctx, q.ServiceName); err != nil {
+		return model.Forecast{}, err
+	}
+	uid, err := uuid.Parse(q.UserID)
+	if err != nil {
+		return model.Forecast{}, invalidf("invalid user_id")
+	}
+	subs, err := s.repo.ListByUsers(ctx, []uuid.UUID{uid})
+	if err != nil {
+		return model.Forecast{}, err
+	}
+	subs = slices.DeleteFunc(subs, func(sub model.Subscription) bool {
+		return (q.ServiceName != "" && sub.ServiceName != q.ServiceName) ||
+			(q.Category != "" && !strings.EqualFold(sub.Category, q.Category)) ||
+			(q.Tag != "" && !slices.Contains(sub.Tags, q.Tag))
+	})
+
+	res := model.Forecast{From: in.From, To: to, Months: make([]model.ForecastMonth, 0, in.Months)}
+	for m := in.From; !m.After(to); m = m.AddDate(0, 1, 0) {
+		fm := model.ForecastMonth{Month: m, Subscriptions: []model.ForecastItem{}}
+		for _, sub := range subs {
+			if c := model.CostInPeriod(sub, m, m); c > 0 {
+				fm.Amount += c
+				fm.Subscriptions = append(fm.Subscriptions, model.ForecastItem{ID: sub.ID, ServiceName: sub.ServiceName, Amount: c})
+			}
+		}
+		slices.SortStableFunc(fm.Subscriptions, func(a, b model.ForecastItem) int {
+			return cmp.Or(cmp.Compare(b.Amount, a.Amount), strings.Compare(a.ServiceName, b.ServiceName))
+		})
+		res.Total += fm.Amount
+		res.Months = append(res.Months, fm)
+	}
+	return res, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/model"
+	"subscription-service/internal/notify"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/tracing"
+)
+
+// Notifications — настройки напоминаний; пользователь видит и меняет свои,
+// admin и API-ключи — любого пользователя
+type Notifications struct {
+	repo        *repo.RemindersRepo
+	defLead     int
+	defChannels []string
+}
+
+// NewNotifications: lead и channels — настройки для пользователей, которые их не меняли
+func NewNotifications(r *repo.RemindersRepo, lead int, channels []string) *Notifications {
+	return &Notifications{repo: r, defLead: lead, defChannels: channels}
+}
+
+// target — чьи настройки: userID из запроса (пусто — сам вызывающий)
+func target(ctx context.Context, userID string) (uuid.UUID, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return uuid.Nil, auth.ErrUnauthenticated
+	}
+	if userID == "" {
+		if p.UserID == uuid.Nil {
+			return uuid.Nil, invalidf("user_id required")
+		}
+		return p.UserID, nil
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, invalidf("invalid user_id")
+	}
+	if !p.Unrestricted() && id != p.UserID {
+		return uuid.Nil, auth.ErrForbidden
+	}
+	return id, nil
+}
+
+func (s *Notifications) Get(ctx context.Context, userID string) (model.NotificationPreferences, error) {
+	ctx, span := tracing.Start(ctx, "service.Notifications.Get")
+	defer span.End()
+	id, err := target(ctx, userID)
+	if err != nil {
+		return model.NotificationPreferences{}, err
+	}
+	return s.get(ctx, id)
+}
+
+func (s *Notifications) get(ctx context.Context, id uuid.UUID) (model.NotificationPreferences, error) {
+	p, err := s.repo.GetPreferences(ctx, id)
+	if errors.Is(err, repo.ErrPreferencesNotFound) {
+		return model.NotificationPreferences{
+			UserID:   id,
+			Enabled:  true,
+			LeadDays: s.defLead,
+			Channels: slices.Clone(s.defChannels),
+		}, nil
+	}
+	return p, err
+}
+
+func (s *Notifications) Update(ctx context.Context, userID string, in model.NotificationPreferencesUpdate) (model.NotificationPreferences, error) {
+	ctx, span := tracing.Start(ctx, "service.Notifications.Update")
+	defer span.End()
+	// webhook_url проверяется первым: адрес на внутреннюю сеть не должен попасть в БД ни при каких условиях
+	if in.WebhookURL != nil && *in.WebhookURL != "" {
+		if err := notify.CheckWebhookURL(ctx, *in.WebhookURL); err != nil {
+			return model.NotificationPreferences{}, invalidf("webhook_url: %v", err)
+		}
+	}
+	id, err := target(ctx, userID)
+	if err != nil {
+		return model.NotificationPreferences{}, err
+	}
+	p, err := s.get(ctx, id)
+	if err != nil {
+		return model.NotificationPreferences{}, err
+	}
+	if in.Enabled != nil {
+		p.Enabled = *in.Enabled
+	}
+	if in.LeadDays != nil {
+		p.LeadDays = *in.LeadDays
+	}
+	if in.Channels != nil {
+		p.Channels = *in.Channels
+	}
+	if in.Email != nil {
+		p.Email = *in.Email
+	}
+	if in.WebhookURL != nil {
+		p.WebhookURL = *in.WebhookURL
+	}
+	if err := validatePreferences(p); err != nil {
+		return model.NotificationPreferences{}, err
+	}
+	return s.repo.UpsertPreferences(ctx, p)
+}
+
+func validatePreferences(p model.NotificationPreferences) error {
+	if p.LeadDays < 1 || p.LeadDays > 28 {
+		return invalidf("lead_days must be between 1 and 28")
+	}
+	for i, c := range p.Channels {
+		if !model.KnownChannel(c) {
+			return invalidf("unknown channel %q (email | webhook | log)", c)
+		}
+		if slices.Contains(p.Channels[:i], c) {
+			return invalidf("duplicate channel %q", c)
+		}
+	}
+	if p.Email != "" {
+		if a, err := mail.ParseAddress(p.Email); err != nil || a.Address != p.Email {
+			return invalidf("invalid email")
+		}
+	} else if slices.Contains(p.Channels, model.ChannelEmail) {
+		return invalidf("email required for email channel")
+	}
+	// сам webhook_url проверен в Update до чтения настроек
+	if p.WebhookURL == "" && slices.Contains(p.Channels, model.ChannelWebhook) {
+		return invalidf("webhook_url required for webhook channel")
+	}
+	return nil
+}
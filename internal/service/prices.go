@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/events"
+	"subscription-service/internal/model"
+	"subscription-service/internal/tracing"
+)
+
+const maxPriceChanges = 24
+
+// SetPrice планирует цену подписки с месяца in.Month (позже start_date); изменение на тот же
+// месяц заменяется. Цена действует до следующего изменения и учитывается в Total и Forecast.
+func (s *Service) SetPrice(ctx context.Context, id uuid.UUID, in model.PriceChangeCreate) (model.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "service.SetPrice")
+	defer span.End()
+	month, err := model.ParseYearMonth(in.Month)
+	if err != nil {
+		return model.Subscription{}, invalidf("invalid month (YYYY-MM)")
+	}
+	if in.Price <= 0 {
+		return model.Subscription{}, invalidf("price must be > 0")
+	}
+	cur, err := s.editable(ctx, id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if !month.After(cur.StartDate) {
+		return model.Subscription{}, invalidf("month must be after start_date")
+	}
+	if len(cur.PriceChanges) >= maxPriceChanges && !scheduled(cur, month) {
+		return model.Subscription{}, invalidf("up to %d price changes per subscription", maxPriceChanges)
+	}
+	own, _ := owner(ctx)
+	updated, err := s.repo.SetPrice(ctx, id, own, model.PriceChange{Month: month, Price: in.Price})
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	s.publish(ctx, events.Updated, updated)
+	return updated, nil
+}
+
+// RemovePrice отменяет изменение цены с месяца monthYM (YYYY-MM); отсутствующее — не ошибка
+func (s *Service) RemovePrice(ctx context.Context, id uuid.UUID, monthYM string) (model.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "service.RemovePrice")
+	defer span.End()
+	month, err := model.ParseYearMonth(monthYM)
+	if err != nil {
+		return model.Subscription{}, invalidf("invalid month (YYYY-MM)")
+	}
+	cur, err := s.editable(ctx, id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if !scheduled(cur, month) {
+		return cur, nil
+	}
+	own, _ := owner(ctx)
+	updated, err := s.repo.RemovePrice(ctx, id, own, month)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	s.publish(ctx, events.Updated, updated)
+	return updated, nil
+}
+
+// scheduled — у подписки есть изменение цены с месяца month
+func scheduled(sub model.Subscription, month time.Time) bool {
+	return slices.ContainsFunc(sub.PriceChanges, func(c model.PriceChange) bool { return c.Month.Equal(month) })
+}
@@ -2,76 +2,209 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 
+	"subscription-service/internal/auth"
+	"subscription-service/internal/events"
 	"subscription-service/internal/log"
 	"subscription-service/internal/model"
 	"subscription-service/internal/repo"
+	"subscription-service/internal/tracing"
 )
 
+// ValidationError — некорректные входные данные запроса: REST отдаёт 400, gRPC — InvalidArgument.
+// Остальные ошибки без доменного смысла считаются внутренними
+type ValidationError struct{ msg string }
+
+func (e *ValidationError) Error() string { return e.msg }
+
+func invalidf(format string, args ...any) error {
+	return &ValidationError{msg: fmt.Sprintf(format, args...)}
+}
+
 type Service struct {
-	repo   *repo.SubscriptionsRepo
-	logger *log.Logger
+	repo          *repo.SubscriptionsRepo
+	logger        *log.Logger
+	events        events.Publisher
+	catalog       *Catalog
+	rejectUnknown bool
 }
 
 func New(r *repo.SubscriptionsRepo, l *log.Logger) *Service { return &Service{repo: r, logger: l} }
 
+// SetEvents включает публикацию событий created/updated/deleted (SSE)
+func (s *Service) SetEvents(p events.Publisher) { s.events = p }
+
+// SetCatalog включает приведение service_name к названиям из каталога; rejectUnknown — запретить
+// подписки на сервисы не из каталога
+func (s *Service) SetCatalog(c *Catalog, rejectUnknown bool) {
+	s.catalog, s.rejectUnknown = c, rejectUnknown
+}
+
+// CanonicalServiceName приводит service_name фильтра к каноническому; неизвестное название остаётся как есть
+func (s *Service) CanonicalServiceName(ctx context.Context, name string) (string, error) {
+	if name == "" || s.catalog == nil {
+		return name, nil
+	}
+	cs, err := s.catalog.Resolve(ctx, name)
+	if errors.Is(err, repo.ErrServiceNotFound) {
+		return name, nil
+	}
+	return cs.Name, err
+}
+
+// publish не влияет на результат операции: изменение уже записано
+func (s *Service) publish(ctx context.Context, typ string, sub model.Subscription) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Publish(ctx, typ, sub); err != nil {
+		log.FromContext(ctx).Warn("event publish failed", "type", typ, "id", sub.ID, "err", err)
+	}
+}
+
+// owner возвращает ID пользователя, которым ограничиваются запросы; nil — для admin и API-ключей
+func owner(ctx context.Context) (*uuid.UUID, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, auth.ErrUnauthenticated
+	}
+	if p.Unrestricted() {
+		return nil, nil
+	}
+	uid := p.UserID
+	return &uid, nil
+}
+
 func (s *Service) Create(ctx context.Context, in model.SubscriptionCreate) (model.Subscription, error) {
-	if in.ServiceName == "" || in.Price <= 0 || in.UserID == "" || in.StartYM == "" {
-		return model.Subscription{}, fmt.Errorf("service_name, price (>0), user_id, start_date required")
+	ctx, span := tracing.Start(ctx, "service.Create")
+	defer span.End()
+	own, err := owner(ctx)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if own != nil {
+		if in.UserID != "" && in.UserID != own.String() {
+			log.FromContext(ctx).Warn("user_id replaced with caller", "requested_user_id", in.UserID)
+		}
+		in.UserID = own.String()
+	}
+	name, cs, err := s.resolveService(ctx, in.ServiceName)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if in.BillingPeriod == 0 {
+		in.BillingPeriod = model.BillingMonthly
+	}
+	if !model.ValidBillingPeriod(in.BillingPeriod) {
+		return model.Subscription{}, invalidf("billing_period must be 1, 3, 6 or 12 (months)")
+	}
+	if in.Price == 0 && cs != nil {
+		p, err := plan(*cs, in.Plan)
+		if err != nil {
+			return model.Subscription{}, err
+		}
+		in.Price = p.Price * int64(in.BillingPeriod) // цена тарифа — за месяц
+	}
+	if name == "" || in.Price <= 0 || in.UserID == "" || in.StartYM == "" {
+		return model.Subscription{}, invalidf("service_name, price (>0), user_id, start_date required")
 	}
 	uid, err := uuid.Parse(in.UserID)
 	if err != nil {
-		return model.Subscription{}, fmt.Errorf("invalid user_id")
+		return model.Subscription{}, invalidf("invalid user_id")
 	}
 	start, err := model.ParseYearMonth(in.StartYM)
 	if err != nil {
-		return model.Subscription{}, fmt.Errorf("invalid start_date (YYYY-MM)")
+		return model.Subscription{}, invalidf("invalid start_date (YYYY-MM)")
 	}
 	var end *time.Time
 	if in.EndYM != "" {
 		t, err := model.ParseYearMonth(in.EndYM)
 		if err != nil {
-			return model.Subscription{}, fmt.Errorf("invalid end_date (YYYY-MM)")
+			return model.Subscription{}, invalidf("invalid end_date (YYYY-MM)")
 		}
 		end = &t
 	}
 	subs := model.Subscription{
-		ID:          uuid.New(),
-		ServiceName: in.ServiceName,
-		Price:       in.Price,
-		UserID:      uid,
-		StartDate:   start,
-		EndDate:     end,
+		ID:            uuid.New(),
+		ServiceName:   name,
+		Price:         in.Price,
+		BillingPeriod: in.BillingPeriod,
+		UserID:        uid,
+		StartDate:     start,
+		EndDate:       end,
+		Status:        model.StatusAt(start, end, time.Now()),
+	}
+	if subs.Tags, err = normalizeTags(in.Tags); err != nil {
+		return model.Subscription{}, err
+	}
+	if subs.Category, err = normalizeCategory(in.Category); err != nil {
+		return model.Subscription{}, err
+	}
+	subs.CustomCategory = subs.Category != ""
+	created, err := s.repo.Create(ctx, subs)
+	if err != nil {
+		return model.Subscription{}, err
 	}
-	return s.repo.Create(ctx, subs)
+	s.publish(ctx, events.Created, created)
+	return created, nil
 }
 
 func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (model.Subscription, error) {
-	return s.repo.GetByID(ctx, id)
+	ctx, span := tracing.Start(ctx, "service.GetByID")
+	defer span.End()
+	own, err := owner(ctx)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	return s.repo.GetByID(ctx, id, own)
 }
 
 func (s *Service) Update(ctx context.Context, id uuid.UUID, in model.SubscriptionUpdate) (model.Subscription, error) {
-	cur, err := s.repo.GetByID(ctx, id)
+	ctx, span := tracing.Start(ctx, "service.Update")
+	defer span.End()
+	own, err := owner(ctx)
 	if err != nil {
 		return model.Subscription{}, err
 	}
+	cur, err := s.repo.GetByID(ctx, id, own)
+	if err != nil {
+		log.FromContext(ctx).Debug("update target not accessible", "id", id, "err", err)
+		return model.Subscription{}, err
+	}
+	if cur.Status == model.StatusArchived {
+		return model.Subscription{}, repo.ErrSubscriptionArchived
+	}
 	if in.ServiceName != nil {
-		cur.ServiceName = *in.ServiceName
+		name, _, err := s.resolveService(ctx, *in.ServiceName)
+		if err != nil {
+			return model.Subscription{}, err
+		}
+		if name == "" {
+			return model.Subscription{}, invalidf("service_name must not be empty")
+		}
+		cur.ServiceName = name
 	}
 	if in.Price != nil {
 		if *in.Price <= 0 {
-			return model.Subscription{}, fmt.Errorf("price must be > 0")
+			return model.Subscription{}, invalidf("price must be > 0")
 		}
 		cur.Price = *in.Price
 	}
+	if in.BillingPeriod != nil {
+		if !model.ValidBillingPeriod(*in.BillingPeriod) {
+			return model.Subscription{}, invalidf("billing_period must be 1, 3, 6 or 12 (months)")
+		}
+		cur.BillingPeriod = *in.BillingPeriod
+	}
 	if in.StartYM != nil {
 		t, err := model.ParseYearMonth(*in.StartYM)
 		if err != nil {
-			return model.Subscription{}, fmt.Errorf("invalid start_date")
+			return model.Subscription{}, invalidf("invalid start_date")
 		}
 		cur.StartDate = t
 	}
@@ -81,25 +214,167 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, in model.Subscriptio
 		} else {
 			t, err := model.ParseYearMonth(*in.EndYM)
 			if err != nil {
-				return model.Subscription{}, fmt.Errorf("invalid end_date")
+				return model.Subscription{}, invalidf("invalid end_date")
 			}
 			cur.EndDate = &t
 		}
 	}
-	return s.repo.Update(ctx, cur)
+	if in.Category != nil {
+		// пустая категория — снова категория сервиса из каталога
+		if cur.Category, err = normalizeCategory(*in.Category); err != nil {
+			return model.Subscription{}, err
+		}
+		cur.CustomCategory = cur.Category != ""
+	}
+	cur.Status = model.StatusAt(cur.StartDate, cur.EndDate, time.Now())
+	updated, err := s.repo.Update(ctx, cur, own)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	s.publish(ctx, events.Updated, updated)
+	return updated, nil
 }
 
 func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+	ctx, span := tracing.Start(ctx, "service.Delete")
+	defer span.End()
+	own, err := owner(ctx)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.repo.Delete(ctx, id, own)
+	if err != nil {
+		return err
+	}
+	s.publish(ctx, events.Deleted, deleted)
+	return nil
 }
 
 func (s *Service) List(ctx context.Context, q model.ListQuery) ([]model.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "service.List")
+	defer span.End()
+	own, err := owner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if own != nil {
+		if q.UserID != "" && q.UserID != own.String() {
+			log.FromContext(ctx).Warn("user_id filter replaced with caller", "requested_user_id", q.UserID)
+		}
+		q.UserID = own.String()
+	}
+	if q.UserID != "" {
+		if _, err := uuid.Parse(q.UserID); err != nil {
+			return nil, invalidf("invalid user_id")
+		}
+	}
+	if q.ServiceName, err = s.CanonicalServiceName(ctx, q.ServiceName); err != nil {
+		return nil, err
+	}
+	q.Category, q.Tag = model.NormalizeServiceName(q.Category), model.NormalizeTag(q.Tag)
 	return s.repo.List(ctx, q)
 }
 
+// CheckUserAccess: данные пользователя id доступны admin, API-ключам и самому пользователю
+func (s *Service) CheckUserAccess(ctx context.Context, id uuid.UUID) error {
+	own, err := owner(ctx)
+	if err != nil {
+		return err
+	}
+	if own != nil && *own != id {
+		return auth.ErrForbidden
+	}
+	return nil
+}
+
+// ListByUsers — все подписки пользователей ids одним запросом, сгруппированные по пользователю
+// (для батчинга в GraphQL); обычному пользователю доступен только он сам
+func (s *Service) ListByUsers(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]model.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "service.ListByUsers")
+	defer span.End()
+	for _, id := range ids {
+		if err := s.CheckUserAccess(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	items, err := s.repo.ListByUsers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[uuid.UUID][]model.Subscription, len(ids))
+	for _, it := range items {
+		res[it.UserID] = append(res[it.UserID], it)
+	}
+	return res, nil
+}
+
 func (s *Service) Total(ctx context.Context, q model.TotalQuery) (int64, error) {
-	if q.From.After(q.To) {
-		return 0, fmt.Errorf("from must be <= to")
+	ctx, span := tracing.Start(ctx, "service.Total")
+	defer span.End()
+	q, err := periodQuery(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	if q.ServiceName, err = s.CanonicalServiceName(ctx, q.ServiceName); err != nil {
+		return 0, err
 	}
 	return s.repo.Total(ctx, q)
 }
+
+// Breakdown — стоимость за период по месяцам и сервисам (те же правила, что у Total)
+func (s *Service) Breakdown(ctx context.Context, q model.TotalQuery) ([]model.BreakdownItem, error) {
+	ctx, span := tracing.Start(ctx, "service.Breakdown")
+	defer span.End()
+	q, err := periodQuery(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if q.ServiceName, err = s.CanonicalServiceName(ctx, q.ServiceName); err != nil {
+		return nil, err
+	}
+	return s.repo.Breakdown(ctx, q)
+}
+
+// periodQuery ограничивает запрос за период вызывающим пользователем и проверяет его
+func periodQuery(ctx context.Context, q model.TotalQuery) (model.TotalQuery, error) {
+	own, err := owner(ctx)
+	if err != nil {
+		return q, err
+	}
+	if own != nil {
+		if q.UserID != "" && q.UserID != own.String() {
+			log.FromContext(ctx).Warn("user_id filter replaced with caller", "requested_user_id", q.UserID)
+		}
+		q.UserID = own.String()
+	}
+	if q.UserID == "" {
+		return q, invalidf("user_id required")
+	}
+	if _, err := uuid.Parse(q.UserID); err != nil {
+		return q, invalidf("invalid user_id")
+	}
+	if q.From.After(q.To) {
+		return q, invalidf("from must be <= to")
+	}
+	q.Category, q.Tag = model.NormalizeServiceName(q.Category), model.NormalizeTag(q.Tag)
+	return q, nil
+}
+
+// RecalculateStatuses пересчитывает статусы всех подписок; только для admin и API-ключей
+func (s *Service) RecalculateStatuses(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "service.RecalculateStatuses")
+	defer span.End()
+	own, err := owner(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if own != nil {
+		return 0, auth.ErrForbidden
+	}
+	n, err := s.repo.RecalculateStatuses(ctx)
+	if err != nil {
+		return 0, err
+	}
+	log.FromContext(ctx).Info("subscription statuses recalculated", "updated", n)
+	return n, nil
+}
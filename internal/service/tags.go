@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/events"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/tracing"
+)
+
+const (
+	maxTags      = 20
+	maxTagLength = 50
+)
+
+// AddTags добавляет подписке метки; уже имеющиеся пропускаются
+func (s *Service) AddTags(ctx context.Context, id uuid.UUID, tags []string) (model.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "service.AddTags")
+	defer span.End()
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if len(tags) == 0 {
+		return model.Subscription{}, invalidf("tags required")
+	}
+	cur, err := s.editable(ctx, id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	for _, t := range cur.Tags {
+		if !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) > maxTags {
+		return model.Subscription{}, invalidf("up to %d tags per subscription", maxTags)
+	}
+	own, _ := owner(ctx)
+	updated, err := s.repo.AddTags(ctx, id, own, tags)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	s.publish(ctx, events.Updated, updated)
+	return updated, nil
+}
+
+// RemoveTag снимает метку с подписки; отсутствующая метка — не ошибка
+func (s *Service) RemoveTag(ctx context.Context, id uuid.UUID, tag string) (model.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "service.RemoveTag")
+	defer span.End()
+	cur, err := s.editable(ctx, id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	tag = model.NormalizeTag(tag)
+	if !slices.Contains(cur.Tags, tag) {
+		return cur, nil
+	}
+	own, _ := owner(ctx)
+	updated, err := s.repo.RemoveTag(ctx, id, own, tag)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	s.publish(ctx, events.Updated, updated)
+	return updated, nil
+}
+
+// TotalBy — стоимость за период по категориям или меткам (те же правила, что у Total)
+func (s *Service) TotalBy(ctx context.Context, q model.TotalQuery, groupBy string) ([]model.GroupAmount, error) {
+	ctx, span := tracing.Start(ctx, "service.TotalBy")
+	defer span.End()
+	if groupBy != model.GroupByCategory && groupBy != model.GroupByTag {
+		return nil, invalidf("invalid group_by (category | tag)")
+	}
+	q, err := periodQuery(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if q.ServiceName, err = s.CanonicalServiceName(ctx, q.ServiceName); err != nil {
+		return nil, err
+	}
+	return s.repo.TotalBy(ctx, q, groupBy)
+}
+
+// editable — подписка, доступная вызывающему и не архивированная
+func (s *Service) editable(ctx context.Context, id uuid.UUID) (model.Subscription, error) {
+	own, err := owner(ctx)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	cur, err := s.repo.GetByID(ctx, id, own)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if cur.Status == model.StatusArchived {
+		return model.Subscription{}, repo.ErrSubscriptionArchived
+	}
+	return cur, nil
+}
+
+// normalizeTags приводит метки к model.NormalizeTag, убирает повторы и сортирует (как их отдаёт БД).
+// Запятая в метке запрещена: метки хранятся в запросах строкой через запятую.
+func normalizeTags(in []string) ([]string, error) {
+	res := []string{}
+	for _, t := range in {
+		t = model.NormalizeTag(t)
+		if t == "" || len([]rune(t)) > maxTagLength || strings.Contains(t, ",") {
+			return nil, invalidf("tags must be non-empty, up to %d characters, without commas", maxTagLength)
+		}
+		if !slices.Contains(res, t) {
+			res = append(res, t)
+		}
+	}
+	if len(res) > maxTags {
+		return nil, invalidf("up to %d tags per subscription", maxTags)
+	}
+	slices.Sort(res)
+	return res, nil
+}
+
+func normalizeCategory(c string) (string, error) {
+	c = model.NormalizeServiceName(c)
+	if len([]rune(c)) > 100 {
+		return "", invalidf("category must be up to 100 characters")
+	}
+	return c, nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/auth"
+	"subscription-service/internal/events"
+	"subscription-service/internal/log"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repo"
+	"subscription-service/internal/tracing"
+)
+
+// Users — профили пользователей. Пользователь видит и меняет свой профиль, admin и API-ключи — любой;
+// список и удаление — только admin и API-ключи.
+type Users struct {
+	repo *repo.UsersRepo
+	subs *Service // события об изменённых при архивации и удалении подписках
+}
+
+func NewUsers(r *repo.UsersRepo, subs *Service) *Users { return &Users{repo: r, subs: subs} }
+
+func (s *Users) Create(ctx context.Context, in model.UserCreate) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "service.Users.Create")
+	defer span.End()
+	own, err := owner(ctx)
+	if err != nil {
+		return model.User{}, err
+	}
+	id := uuid.New()
+	switch {
+	case own != nil:
+		if in.ID != "" && in.ID != own.String() {
+			log.FromContext(ctx).Warn("user id replaced with caller", "requested_user_id", in.ID)
+		}
+		id = *own
+	case in.ID != "":
+		if id, err = uuid.Parse(in.ID); err != nil {
+			return model.User{}, invalidf("invalid id")
+		}
+	}
+	u := model.User{
+		ID:          id,
+		DisplayName: strings.TrimSpace(in.DisplayName),
+		Email:       in.Email,
+		Timezone:    in.Timezone,
+		Currency:    in.Currency,
+	}
+	if u.Timezone == "" {
+		u.Timezone = model.DefaultTimezone
+	}
+	if u.Currency == "" {
+		u.Currency = model.DefaultCurrency
+	}
+	if u.DisplayName == "" {
+		return model.User{}, invalidf("display_name required")
+	}
+	if err := validateUser(u); err != nil {
+		return model.User{}, err
+	}
+	return s.repo.Create(ctx, u)
+}
+
+// Get возвращает и архивированного пользователя (archived_at заполнен)
+func (s *Users) Get(ctx context.Context, id uuid.UUID) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "service.Users.Get")
+	defer span.End()
+	if err := s.subs.CheckUserAccess(ctx, id); err != nil {
+		return model.User{}, err
+	}
+	return s.repo.Get(ctx, id)
+}
+
+func (s *Users) List(ctx context.Context, q model.UserQuery) ([]model.User, error) {
+	ctx, span := tracing.Start(ctx, "service.Users.List")
+	defer span.End()
+	if err := unrestricted(ctx); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, q)
+}
+
+func (s *Users) Update(ctx context.Context, id uuid.UUID, in model.UserUpdate) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "service.Users.Update")
+	defer span.End()
+	u, err := s.Get(ctx, id)
+	if err != nil {
+		return model.User{}, err
+	}
+	if u.ArchivedAt != nil {
+		return model.User{}, repo.ErrUserArchived
+	}
+	if in.DisplayName != nil {
+		// у перенесённых миграцией пользователей display_name пуст, но задать пустой нельзя
+		if u.DisplayName = strings.TrimSpace(*in.DisplayName); u.DisplayName == "" {
+			return model.User{}, invalidf("display_name must not be empty")
+		}
+	}
+	if in.Email != nil {
+		u.Email = *in.Email
+	}
+	if in.Timezone != nil {
+		u.Timezone = *in.Timezone
+	}
+	if in.Currency != nil {
+		u.Currency = *in.Currency
+	}
+	if err := validateUser(u); err != nil {
+		return model.User{}, err
+	}
+	return s.repo.Update(ctx, u)
+}
+
+// Delete: mode archive (по умолчанию) архивирует пользователя и его подписки, cascade — удаляет их
+func (s *Users) Delete(ctx context.Context, id uuid.UUID, mode string) error {
+	ctx, span := tracing.Start(ctx, "service.Users.Delete")
+	defer span.End()
+	if err := unrestricted(ctx); err != nil {
+		return err
+	}
+	if mode == "" {
+		mode = model.UserDeleteArchive
+	}
+	var (
+		subs []model.Subscription
+		typ  string
+		err  error
+	)
+	switch mode {
+	case model.UserDeleteArchive:
+		subs, err = s.repo.Archive(ctx, id)
+		typ = events.Updated
+	case model.UserDeleteCascade:
+		subs, err = s.repo.Delete(ctx, id)
+		typ = events.Deleted
+	default:
+		return invalidf("invalid mode (archive | cascade)")
+	}
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		s.subs.publish(ctx, typ, sub)
+	}
+	log.FromContext(ctx).Info("user deleted", "user_id", id, "mode", mode, "subscriptions", len(subs))
+	return nil
+}
+
+// unrestricted — операция только для admin и API-ключей
+func unrestricted(ctx context.Context) error {
+	own, err := owner(ctx)
+	if err != nil {
+		return err
+	}
+	if own != nil {
+		return auth.ErrForbidden
+	}
+	return nil
+}
+
+func validateUser(u model.User) error {
+	if len([]rune(u.DisplayName)) > 200 {
+		return invalidf("display_name must be up to 200 characters")
+	}
+	if u.Email != "" {
+		if a, err := mail.ParseAddress(u.Email); err != nil || a.Address != u.Email {
+			return invalidf("invalid email")
+		}
+	}
+	// Local — часовой пояс сервера, а не пользователя
+	if _, err := time.LoadLocation(u.Timezone); err != nil || u.Timezone == "" || u.Timezone == "Local" {
+		return invalidf("invalid timezone (IANA name, e.g. Europe/Moscow)")
+	}
+	if len(u.Currency) != 3 || strings.Trim(u.Currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return invalidf("invalid currency (ISO 4217 code, e.g. RUB)")
+	}
+	return nil
+}
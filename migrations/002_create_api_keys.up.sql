@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+id UUID PRIMARY KEY,
+name TEXT NOT NULL,
+prefix TEXT NOT NULL,
+key_hash TEXT NOT NULL UNIQUE,
+scopes TEXT[] NOT NULL,
+expires_at TIMESTAMP NULL,
+last_used_at TIMESTAMP NULL,
+revoked_at TIMESTAMP NULL,
+created_at TIMESTAMP NOT NULL DEFAULT now()
+);
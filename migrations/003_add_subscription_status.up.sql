@@ -0,0 +1,9 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';
+
+UPDATE subscriptions SET status = CASE
+WHEN date_trunc('month', start_date) > date_trunc('month', now()) THEN 'upcoming'
+WHEN end_date IS NOT NULL AND date_trunc('month', end_date) < date_trunc('month', now()) THEN 'expired'
+ELSE 'active'
+END;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_status ON subscriptions(status);
@@ -0,0 +1 @@
+DROP SEQUENCE IF EXISTS subscription_events_seq;
@@ -0,0 +1,2 @@
+-- ID событий SSE в режиме EVENTS_BACKEND=postgres: общие для всех реплик
+CREATE SEQUENCE IF NOT EXISTS subscription_events_seq;
@@ -0,0 +1,30 @@
+-- Настройки напоминаний пользователя; нет строки — значения по умолчанию из конфига (REMINDERS_*)
+CREATE TABLE IF NOT EXISTS notification_preferences (
+user_id UUID PRIMARY KEY,
+enabled BOOLEAN NOT NULL DEFAULT true,
+lead_days INT NOT NULL CHECK (lead_days BETWEEN 1 AND 28),
+channels TEXT[] NOT NULL,
+email TEXT NULL,
+webhook_url TEXT NULL,
+updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Напоминание о продлении (renewal) или окончании (ending) подписки: одно на подписку, событие,
+-- его дату и канал — повторные проходы планировщика дублей не создают
+CREATE TABLE IF NOT EXISTS reminders (
+id BIGSERIAL PRIMARY KEY,
+subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+user_id UUID NOT NULL,
+kind TEXT NOT NULL,
+due_date DATE NOT NULL,
+channel TEXT NOT NULL,
+status TEXT NOT NULL DEFAULT 'pending',
+attempts INT NOT NULL DEFAULT 0,
+last_error TEXT NULL,
+next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+created_at TIMESTAMP NOT NULL DEFAULT now(),
+sent_at TIMESTAMP NULL,
+UNIQUE (subscription_id, kind, due_date, channel)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_pending ON reminders(next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,22 @@
+-- Очередь фоновых задач: воркеры забирают их через SELECT ... FOR UPDATE SKIP LOCKED
+CREATE TABLE IF NOT EXISTS jobs (
+id BIGSERIAL PRIMARY KEY,
+kind TEXT NOT NULL,
+payload JSONB NOT NULL DEFAULT '{}',
+status TEXT NOT NULL DEFAULT 'queued',
+attempts INT NOT NULL DEFAULT 0,
+max_attempts INT NOT NULL CHECK (max_attempts > 0),
+run_at TIMESTAMP NOT NULL DEFAULT now(),
+locked_until TIMESTAMP NULL,
+last_error TEXT NULL,
+unique_key TEXT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT now(),
+updated_at TIMESTAMP NOT NULL DEFAULT now(),
+finished_at TIMESTAMP NULL
+);
+
+-- unique_key — защита от дублей (запуски по расписанию с нескольких реплик)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_unique_key ON jobs(unique_key) WHERE unique_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_jobs_queued ON jobs(run_at) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs(locked_until) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_jobs_kind_status ON jobs(kind, status);
@@ -0,0 +1,5 @@
+ALTER TABLE notification_preferences DROP CONSTRAINT IF EXISTS notification_preferences_user_id_fkey;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_user_id_fkey;
+-- архивные подписки после отката снова пересчитываются как обычные
+UPDATE subscriptions SET status = 'active' WHERE status = 'archived';
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,25 @@
+-- Пользователи; подписки ссылаются на них, поэтому опечатка в user_id больше не создаёт «нового» пользователя
+CREATE TABLE IF NOT EXISTS users (
+id UUID PRIMARY KEY,
+display_name TEXT NOT NULL DEFAULT '',
+email TEXT NULL,
+timezone TEXT NOT NULL DEFAULT 'UTC',
+currency TEXT NOT NULL DEFAULT 'RUB',
+archived_at TIMESTAMP NULL,
+created_at TIMESTAMP NOT NULL DEFAULT now(),
+updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(lower(email)) WHERE email IS NOT NULL;
+
+-- Пользователи, которые уже встречаются в данных, переносятся с пустым display_name
+INSERT INTO users (id)
+SELECT user_id FROM subscriptions
+UNION
+SELECT user_id FROM notification_preferences
+ON CONFLICT DO NOTHING;
+
+-- Подписки удаляются вместе с пользователем явно (DELETE /users/{id}?mode=cascade), поэтому без каскада
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+ALTER TABLE notification_preferences ADD CONSTRAINT notification_preferences_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS service_names;
+DROP TABLE IF EXISTS services;
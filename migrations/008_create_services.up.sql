@@ -0,0 +1,21 @@
+-- Каталог сервисов: каноническое название, категория, сайт и тарифы ([{"name":"Standard","price":799}])
+CREATE TABLE IF NOT EXISTS services (
+id UUID PRIMARY KEY,
+name TEXT NOT NULL,
+category TEXT NULL,
+website TEXT NULL,
+plans JSONB NOT NULL DEFAULT '[]',
+created_at TIMESTAMP NOT NULL DEFAULT now(),
+updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Названия, по которым находится сервис: каноническое (canonical) и псевдонимы. key — название
+-- в нижнем регистре со схлопнутыми пробелами; одно название — у одного сервиса
+CREATE TABLE IF NOT EXISTS service_names (
+key TEXT PRIMARY KEY,
+service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+name TEXT NOT NULL,
+canonical BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_names_service_id ON service_names(service_id);
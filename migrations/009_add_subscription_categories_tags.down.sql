@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS subscription_tags;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS category;
@@ -0,0 +1,11 @@
+-- Категория подписки, если задана вручную; иначе берётся категория сервиса из каталога
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS category TEXT NULL;
+
+-- Произвольные метки подписок
+CREATE TABLE IF NOT EXISTS subscription_tags (
+subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+tag TEXT NOT NULL,
+PRIMARY KEY (subscription_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_tags_tag ON subscription_tags(tag);
@@ -0,0 +1,24 @@
+-- Месячный бюджет пользователя: общий (category и service_name пусты), на категорию или на сервис
+CREATE TABLE IF NOT EXISTS budgets (
+id UUID PRIMARY KEY,
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+category TEXT NULL,
+service_name TEXT NULL,
+amount BIGINT NOT NULL CHECK (amount > 0),
+created_at TIMESTAMP NOT NULL DEFAULT now(),
+updated_at TIMESTAMP NOT NULL DEFAULT now(),
+CHECK (category IS NULL OR service_name IS NULL)
+);
+
+-- Один бюджет на пользователя и область (категория — без учёта регистра)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_scope
+    ON budgets(user_id, lower(COALESCE(category, '')), COALESCE(service_name, ''));
+
+-- Превышения, о которых уже предупредили: одно предупреждение на бюджет и месяц
+CREATE TABLE IF NOT EXISTS budget_alerts (
+budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+month DATE NOT NULL,
+spent BIGINT NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT now(),
+PRIMARY KEY (budget_id, month)
+);
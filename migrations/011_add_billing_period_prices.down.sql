@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS subscription_prices;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_period;
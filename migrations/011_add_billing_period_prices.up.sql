@@ -0,0 +1,11 @@
+-- Период оплаты в месяцах: price списывается в месяц начала и далее раз в billing_period месяцев
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS billing_period SMALLINT NOT NULL DEFAULT 1
+    CHECK (billing_period IN (1, 3, 6, 12));
+
+-- Запланированные изменения цены: с месяца month подписка стоит price за период оплаты
+CREATE TABLE IF NOT EXISTS subscription_prices (
+subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+month DATE NOT NULL,
+price BIGINT NOT NULL CHECK (price > 0),
+PRIMARY KEY (subscription_id, month)
+);
@@ -0,0 +1,7 @@
+// Package migrations встраивает SQL-миграции в бинарник: NNN_name.up.sql / NNN_name.down.sql
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,386 @@
+// Package client — типизированный Go-клиент HTTP API сервиса подписок.
+//
+//	c, err := client.New("http://localhost:8080", client.WithToken(jwt))
+//	sub, err := c.Create(ctx, client.SubscriptionCreate{ServiceName: "Netflix", Price: 799, StartYM: "2025-07"})
+//	for s, err := range c.All(ctx, client.ListParams{Status: "active"}) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"subscription-service/internal/model"
+)
+
+// Типы API — те же, что у сервиса
+type (
+	Subscription       = model.Subscription
+	SubscriptionCreate = model.SubscriptionCreate
+	SubscriptionUpdate = model.SubscriptionUpdate
+)
+
+// Статусы подписки (Subscription.Status, ListParams.Status)
+const (
+	StatusUpcoming = model.StatusUpcoming
+	StatusActive   = model.StatusActive
+	StatusExpired  = model.StatusExpired
+	StatusArchived = model.StatusArchived
+)
+
+type Client struct {
+	base       *url.URL
+	hc         *http.Client
+	token      string
+	apiKey     string
+	userAgent  string
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient задаёт свой http.Client (транспорт, таймауты, прокси)
+func WithHTTPClient(hc *http.Client) Option { return func(c *Client) { c.hc = hc } }
+
+// WithToken — аутентификация по JWT (Authorization: Bearer)
+func WithToken(token string) Option { return func(c *Client) { c.token = token } }
+
+// WithAPIKey — аутентификация сервисным ключом (X-API-Key); приоритетнее токена
+func WithAPIKey(key string) Option { return func(c *Client) { c.apiKey = key } }
+
+func WithUserAgent(ua string) Option { return func(c *Client) { c.userAgent = ua } }
+
+// WithRetry: до max повторов с экспоненциальной паузой от min до max (с джиттером); max=0 — без повторов
+func WithRetry(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.minBackoff, c.maxBackoff = maxRetries, minBackoff, maxBackoff }
+}
+
+// New создаёт клиент для baseURL вида http://host:8080
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("client: invalid base URL %q", baseURL)
+	}
+	c := &Client{
+		base:       u,
+		hc:         &http.Client{Timeout: 30 * time.Second},
+		userAgent:  "subscription-service-go-client",
+		maxRetries: 3,
+		minBackoff: 200 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c, nil
+}
+
+// --- Ошибки ---
+
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+// APIError — ответ сервиса с кодом >= 400 ({"error": "..."}); errors.Is сопоставляет его
+// с ErrNotFound, ErrRateLimited и т.д. по коду
+type APIError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // для 429
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("subscription api: %d %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// --- Подписки ---
+
+func (c *Client) Create(ctx context.Context, in SubscriptionCreate) (s Subscription, err error) {
+	err = c.do(ctx, http.MethodPost, "/api/v1/subscriptions/", nil, in, &s)
+	return s, err
+}
+
+func (c *Client) Get(ctx context.Context, id uuid.UUID) (s Subscription, err error) {
+	err = c.do(ctx, http.MethodGet, "/api/v1/subscriptions/"+id.String(), nil, nil, &s)
+	return s, err
+}
+
+// Update меняет только заданные (не nil) поля; EndYM = "" снимает дату окончания
+func (c *Client) Update(ctx context.Context, id uuid.UUID, in SubscriptionUpdate) (s Subscription, err error) {
+	err = c.do(ctx, http.MethodPut, "/api/v1/subscriptions/"+id.String(), nil, in, &s)
+	return s, err
+}
+
+func (c *Client) Delete(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/subscriptions/"+id.String(), nil, nil, nil)
+}
+
+// AddTags добавляет подписке метки (уже имеющиеся пропускаются)
+func (c *Client) AddTags(ctx context.Context, id uuid.UUID, tags ...string) (s Subscription, err error) {
+	err = c.do(ctx, http.MethodPost, "/api/v1/subscriptions/"+id.String()+"/tags", nil, model.TagsUpdate{Tags: tags}, &s)
+	return s, err
+}
+
+// RemoveTag снимает метку с подписки
+func (c *Client) RemoveTag(ctx context.Context, id uuid.UUID, tag string) (s Subscription, err error) {
+	err = c.do(ctx, http.MethodDelete, "/api/v1/subscriptions/"+id.String()+"/tags/"+url.PathEscape(tag), nil, nil, &s)
+	return s, err
+}
+
+// SetPrice планирует цену подписки с месяца monthYM (YYYY-MM); изменение на тот же месяц заменяется
+func (c *Client) SetPrice(ctx context.Context, id uuid.UUID, monthYM string, price int64) (s Subscription, err error) {
+	in := model.PriceChangeCreate{Month: monthYM, Price: price}
+	err = c.do(ctx, http.MethodPost, "/api/v1/subscriptions/"+id.String()+"/prices", nil, in, &s)
+	return s, err
+}
+
+// RemovePrice отменяет запланированную с месяца monthYM цену
+func (c *Client) RemovePrice(ctx context.Context, id uuid.UUID, monthYM string) (s Subscription, err error) {
+	err = c.do(ctx, http.MethodDelete, "/api/v1/subscriptions/"+id.String()+"/prices/"+url.PathEscape(monthYM), nil, nil, &s)
+	return s, err
+}
+
+// ListParams — фильтры и страница; для обычного пользователя UserID игнорируется сервисом
+type ListParams struct {
+	UserID      string
+	ServiceName string
+	Status      string
+	Category    string
+	Tag         string
+	Limit       int // 0 — по умолчанию сервиса (50)
+	Offset      int
+}
+
+func (p ListParams) values() url.Values {
+	v := url.Values{}
+	for k, s := range map[string]string{
+		"user_id": p.UserID, "service_name": p.ServiceName, "status": p.Status, "category": p.Category, "tag": p.Tag,
+	} {
+		if s != "" {
+			v.Set(k, s)
+		}
+	}
+	if p.Limit > 0 {
+		v.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Offset > 0 {
+		v.Set("offset", strconv.Itoa(p.Offset))
+	}
+	return v
+}
+
+// List возвращает одну страницу
+func (c *Client) List(ctx context.Context, p ListParams) ([]Subscription, error) {
+	var res []Subscription
+	err := c.do(ctx, http.MethodGet, "/api/v1/subscriptions/", p.values(), nil, &res)
+	return res, err
+}
+
+const defaultPageSize = 100
+
+// All перебирает все подписки по фильтру, запрашивая страницы по мере чтения (размер — p.Limit,
+// по умолчанию 100, начиная с p.Offset). Ошибка отдаётся последним элементом.
+func (c *Client) All(ctx context.Context, p ListParams) iter.Seq2[Subscription, error] {
+	return func(yield func(Subscription, error) bool) {
+		if p.Limit <= 0 {
+			p.Limit = defaultPageSize
+		}
+		for {
+			page, err := c.List(ctx, p)
+			if err != nil {
+				yield(Subscription{}, err)
+				return
+			}
+			for _, s := range page {
+				if !yield(s, nil) {
+					return
+				}
+			}
+			if len(page) < p.Limit {
+				return
+			}
+			p.Offset += len(page)
+		}
+	}
+}
+
+// TotalParams — период по месяцам включительно; учитываются год и месяц From/To
+type TotalParams struct {
+	UserID      string
+	ServiceName string
+	Category    string
+	Tag         string
+	From        time.Time
+	To          time.Time
+}
+
+// Total — суммарная стоимость подписок за период, руб.
+func (c *Client) Total(ctx context.Context, p TotalParams) (int64, error) {
+	v := url.Values{"from": {p.From.Format("2006-01")}, "to": {p.To.Format("2006-01")}}
+	for k, s := range map[string]string{
+		"user_id": p.UserID, "service_name": p.ServiceName, "category": p.Category, "tag": p.Tag,
+	} {
+		if s != "" {
+			v.Set(k, s)
+		}
+	}
+	var res struct {
+		Total int64 `json:"total"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/v1/subscriptions/total", v, nil, &res)
+	return res.Total, err
+}
+
+// RecalculateStatuses пересчитывает статусы всех подписок (admin) и возвращает число изменённых
+func (c *Client) RecalculateStatuses(ctx context.Context) (int64, error) {
+	var res struct {
+		Updated int64 `json:"updated"`
+	}
+	err := c.do(ctx, http.MethodPost, "/api/v1/admin/subscriptions/recalculate-statuses", nil, nil, &res)
+	return res.Updated, err
+}
+
+// --- Транспорт ---
+
+// do выполняет запрос с повторами: 429 повторяется для любого метода (запрос не обработан),
+// 5xx и сетевые ошибки — только для идемпотентных, чтобы POST не создал дубликат
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	u := *c.base
+	u.Path += path
+	u.RawQuery = query.Encode()
+	idempotent := method != http.MethodPost
+
+	for attempt := 0; ; attempt++ {
+		err := c.once(ctx, method, u.String(), body, out)
+		if err == nil || attempt >= c.maxRetries || ctx.Err() != nil {
+			return err
+		}
+		var apiErr *APIError
+		wait := c.backoff(attempt)
+		switch {
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+			if apiErr.RetryAfter > 0 {
+				wait = apiErr.RetryAfter
+			}
+		case errors.As(err, &apiErr) && apiErr.StatusCode >= 500 && idempotent:
+		case !errors.As(err, &apiErr) && idempotent:
+		default:
+			return err
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+func (c *Client) once(ctx context.Context, method, u string, body []byte, out any) error {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	} else if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return responseError(resp)
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("subscription api: decode response: %w", err)
+	}
+	return nil
+}
+
+func responseError(resp *http.Response) error {
+	e := &APIError{StatusCode: resp.StatusCode}
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&payload); err == nil {
+		e.Message = payload.Error
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+		e.RetryAfter = time.Duration(s) * time.Second
+	}
+	return e
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.minBackoff << attempt
+	if d <= 0 || d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+	// джиттер в пределах [d/2, d]
+	return d/2 + rand.N(d/2+1)
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// drop — вместо ответа закрыть соединение (сетевая ошибка)
+const drop = 0
+
+// script отвечает кодами codes по очереди (последний повторяется) и считает запросы
+func script(t *testing.T, retryAfter string, codes ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(n.Add(1)) - 1
+		code := codes[min(i, len(codes)-1)]
+		switch {
+		case code == drop:
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		case code == http.StatusTooManyRequests && retryAfter != "":
+			w.Header().Set("Retry-After", retryAfter)
+		case code == http.StatusNoContent:
+			w.WriteHeader(code)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if code >= 400 {
+			w.Write([]byte(`{"error":"` + strconv.Itoa(code) + `"}`))
+			return
+		}
+		w.Write([]byte(`{"service_name":"Netflix"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &n
+}
+
+func TestClientRetries(t *testing.T) {
+	id := uuid.New()
+	get := func(c *Client) error { _, err := c.Get(context.Background(), id); return err }
+	post := func(c *Client) error {
+		_, err := c.Create(context.Background(), SubscriptionCreate{ServiceName: "Netflix"})
+		return err
+	}
+	del := func(c *Client) error { return c.Delete(context.Background(), id) }
+
+	tests := []struct {
+		name     string
+		call     func(*Client) error
+		codes    []int
+		attempts int32
+		fails    bool
+		err      error // ожидаемая причина ошибки, nil — любая
+	}{
+		{name: "GET retries 5xx", call: get, codes: []int{503, 502, 200}, attempts: 3},
+		{name: "GET gives up after max retries", call: get, codes: []int{500}, attempts: 4, fails: true, err: ErrServer},
+		{name: "GET retries network error", call: get, codes: []int{drop, 200}, attempts: 2},
+		{name: "DELETE retries 5xx", call: del, codes: []int{502, 204}, attempts: 2},
+		{name: "POST not retried on 5xx", call: post, codes: []int{503, 201}, attempts: 1, fails: true, err: ErrServer},
+		{name: "POST not retried on network error", call: post, codes: []int{drop, 201}, attempts: 1, fails: true},
+		{name: "POST retried on 429", call: post, codes: []int{429, 429, 201}, attempts: 3},
+		{name: "429 gives up after max retries", call: get, codes: []int{429}, attempts: 4, fails: true, err: ErrRateLimited},
+		{name: "4xx not retried", call: get, codes: []int{404, 200}, attempts: 1, fails: true, err: ErrNotFound},
+		{name: "400 not retried", call: post, codes: []int{400, 201}, attempts: 1, fails: true, err: ErrBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, n := script(t, "", tt.codes...)
+			c, err := New(srv.URL, WithRetry(3, time.Millisecond, 5*time.Millisecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tt.call(c)
+			switch {
+			case !tt.fails && err != nil:
+				t.Fatalf("err = %v, want success", err)
+			case tt.fails && err == nil:
+				t.Fatal("want error, got success")
+			case tt.err != nil && !errors.Is(err, tt.err):
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got := n.Load(); got != tt.attempts {
+				t.Fatalf("attempts = %d, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	srv, n := script(t, "1", http.StatusTooManyRequests, http.StatusOK)
+	c, err := New(srv.URL, WithRetry(3, time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := c.Get(context.Background(), uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	// Retry-After главнее собственной паузы клиента
+	if elapsed := time.Since(start); elapsed < time.Second || n.Load() != 2 {
+		t.Fatalf("retried after %s in %d attempts, want >= 1s and 2 attempts", elapsed, n.Load())
+	}
+}
+
+func TestClientRetryStopsOnContext(t *testing.T) {
+	srv, n := script(t, "", http.StatusServiceUnavailable)
+	c, err := New(srv.URL, WithRetry(3, time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Get(ctx, uuid.New()); !errors.Is(err, ErrServer) {
+		t.Fatalf("err = %v, want last response error", err)
+	}
+	if n.Load() != 1 {
+		t.Fatalf("attempts = %d, want 1", n.Load())
+	}
+}
+
+func TestClientNoRetries(t *testing.T) {
+	srv, n := script(t, "", http.StatusServiceUnavailable, http.StatusOK)
+	c, err := New(srv.URL, WithRetry(0, time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *APIError
+	if _, err := c.Get(context.Background(), uuid.New()); !errors.As(err, &apiErr) || apiErr.StatusCode != 503 || apiErr.Message != "503" {
+		t.Fatalf("err = %v, want APIError 503", err)
+	}
+	if n.Load() != 1 {
+		t.Fatalf("attempts = %d, want 1", n.Load())
+	}
+}